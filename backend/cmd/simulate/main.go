@@ -0,0 +1,254 @@
+// simulate 奖池离线蒙特卡洛模拟：使用线上选鱼逻辑模拟多名玩家连续抽奖，
+// 评估调整初始权重、借用顺序或用户鱼权重公式对积分经济的影响。不连接Redis。
+//
+//	go run ./cmd/simulate -players 1000 -draws 200
+//	go run ./cmd/simulate -pool pool.json -batch 10 -guarantee -user-fish 20
+//	go run ./cmd/simulate -spot sea -draws 500
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"fishing-game/model"
+	"fishing-game/service"
+)
+
+func main() {
+	poolFile := flag.String("pool", "", "奖池定义JSON（奖池接口响应或Redis导出），为空时使用钓点配置中的初始奖池")
+	spot := flag.String("spot", "", "钓点ID，为空时为默认钓点。决定初始奖池、Redis导出中读取的钓点及用户鱼的借用顺序")
+	players := flag.Int("players", 1000, "模拟玩家数")
+	draws := flag.Int("draws", 100, "每名玩家的抽奖次数")
+	batch := flag.Int("batch", 1, "每次请求的抽数（1为单抽）")
+	guarantee := flag.Bool("guarantee", false, "连抽保底")
+	strategy := flag.String("strategy", "", "抽奖策略，为空时使用奖池默认策略")
+	drawContext := flag.String("context", "", `抽奖修正Context JSON，如 {"spot":"sea","time_of_day":"night"}`)
+	userFish := flag.Int("user-fish", 0, "模拟前按线上规则新增的用户鱼数量")
+	userFishDesc := flag.Int("user-fish-desc", service.MaxDescLength, "新增用户鱼的描述长度")
+	noPity := flag.Bool("no-pity", false, "关闭保底（默认读取 PITY_* 环境变量）")
+	rareTag := flag.String("rare-tag", service.TagRare, "统计首次钓到所需抽数的鱼类标签")
+	workers := flag.Int("workers", runtime.NumCPU(), "并发数")
+	jsonOutput := flag.Bool("json", false, "以JSON输出报告")
+	flag.Parse()
+
+	spotDef, err := service.LoadSpotDefinition(*spot)
+	if err != nil {
+		log.Fatalf("Failed to load spot: %v", err)
+	}
+
+	pool, err := loadPool(*poolFile, spotDef)
+	if err != nil {
+		log.Fatalf("Failed to load pool: %v", err)
+	}
+
+	borrowOrder := service.SpotBorrowOrder(spotDef)
+	for i := 0; i < *userFish; i++ {
+		if err := addUserFish(pool, borrowOrder, i, *userFishDesc); err != nil {
+			log.Fatalf("Failed to add user fish: %v", err)
+		}
+	}
+
+	cfg := &service.SimulationConfig{
+		Items:          pool.Items,
+		Weights:        pool.Weights,
+		Strategy:       *strategy,
+		Players:        *players,
+		DrawsPerPlayer: *draws,
+		BatchSize:      *batch,
+		Guarantee:      *guarantee,
+		RareTag:        *rareTag,
+		Workers:        *workers,
+	}
+	if cfg.Strategy == "" {
+		cfg.Strategy = pool.Strategy
+	}
+	if !*noPity {
+		cfg.Pity = service.LoadPityConfig()
+	}
+	if *drawContext != "" {
+		if err := json.Unmarshal([]byte(*drawContext), &cfg.Context); err != nil {
+			log.Fatalf("Invalid context: %v", err)
+		}
+		cfg.Modifiers, err = service.LoadModifierPipeline()
+		if err != nil {
+			log.Fatalf("Failed to load draw modifiers: %v", err)
+		}
+	}
+
+	report, err := service.RunSimulation(cfg)
+	if err != nil {
+		log.Fatalf("Simulation failed: %v", err)
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			log.Fatalf("Failed to encode report: %v", err)
+		}
+		return
+	}
+	printReport(report)
+}
+
+// loadPool 加载奖池定义，支持：
+// 1. 为空时使用钓点定义中的初始奖池
+// 2. 奖池接口响应（GET /fishing/lottery/pool，带或不带 code/data 外层）
+// 3. Redis导出：{"lottery:pool:{spot}:items": {id: 鱼类JSON}, "lottery:pool:{spot}:weights": {id: 权重}}，
+// 也支持迁移前的 lottery:pool:items / lottery:pool:weights
+func loadPool(path string, spot *model.SpotDefinition) (*model.PoolInfoResponse, error) {
+	if path == "" {
+		pool := &model.PoolInfoResponse{
+			Spot:     spot.ID,
+			Items:    make(map[string]*model.LotteryItem),
+			Weights:  make(map[string]int, len(spot.Weights)),
+			Strategy: spot.Strategy,
+		}
+		if pool.Strategy == "" {
+			pool.Strategy = service.StrategyDefault
+		}
+		for _, item := range spot.Items {
+			pool.Items[item.ID] = item
+		}
+		for itemID, weight := range spot.Weights {
+			pool.Weights[itemID] = weight
+		}
+		return pool, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if inner, ok := raw["data"]; ok {
+		data = inner
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	}
+
+	itemsKey, weightsKey, strategyKey := service.SpotPoolKeys(spot.ID)
+	if _, ok := raw[itemsKey]; ok {
+		return parseRedisExport(raw, itemsKey, weightsKey, strategyKey)
+	}
+	if _, ok := raw[service.PoolItemsKey]; ok {
+		return parseRedisExport(raw, service.PoolItemsKey, service.PoolWeightsKey, service.PoolStrategyKey)
+	}
+
+	var pool model.PoolInfoResponse
+	if err := json.Unmarshal(data, &pool); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if len(pool.Items) == 0 {
+		return nil, fmt.Errorf("no items found in %s", path)
+	}
+	return &pool, nil
+}
+
+// parseRedisExport 解析Redis导出的奖池hash，值均为字符串
+func parseRedisExport(raw map[string]json.RawMessage, itemsKey, weightsKey, strategyKey string) (*model.PoolInfoResponse, error) {
+	var itemsData, weightsData map[string]string
+	if err := json.Unmarshal(raw[itemsKey], &itemsData); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", itemsKey, err)
+	}
+	if err := json.Unmarshal(raw[weightsKey], &weightsData); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", weightsKey, err)
+	}
+
+	pool := &model.PoolInfoResponse{
+		Items:    make(map[string]*model.LotteryItem),
+		Weights:  make(map[string]int),
+		Strategy: service.StrategyDefault,
+	}
+	if strategy, ok := raw[strategyKey]; ok {
+		if err := json.Unmarshal(strategy, &pool.Strategy); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", strategyKey, err)
+		}
+	}
+
+	for fishID, itemJSON := range itemsData {
+		var item model.LotteryItem
+		if err := json.Unmarshal([]byte(itemJSON), &item); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal item %s: %w", fishID, err)
+		}
+		pool.Items[fishID] = &item
+	}
+	for fishID, weightStr := range weightsData {
+		weight, err := strconv.Atoi(strings.TrimSpace(weightStr))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse weight for %s: %w", fishID, err)
+		}
+		pool.Weights[fishID] = weight
+	}
+
+	return pool, nil
+}
+
+// addUserFish 按线上规则（CalculateWeight + 钓点借用顺序）新增一条用户鱼
+func addUserFish(pool *model.PoolInfoResponse, borrowOrder []service.BorrowInfo, index, descLength int) error {
+	weight := service.CalculateWeight(strings.Repeat("鱼", descLength))
+	weights, err := service.BorrowWeightsInOrder(pool.Weights, weight, borrowOrder)
+	if err != nil {
+		return err
+	}
+
+	fishID := fmt.Sprintf("sim-user-fish-%d", index+1)
+	pool.Items[fishID] = &model.LotteryItem{
+		ID:         fishID,
+		Name:       fmt.Sprintf("用户鱼%d", index+1),
+		Points:     250,
+		IsUserFish: true,
+		Tags:       []string{service.TagUserFish},
+	}
+	weights[fishID] = weight
+	pool.Weights = weights
+	return nil
+}
+
+// printReport 输出文本报告
+func printReport(report *model.SimulationReport) {
+	fmt.Printf("玩家 %d × 每人 %d 抽（共 %d 抽），策略 %s，每次 %d 抽，连抽保底 %v\n\n",
+		report.Players, report.DrawsPerPlayer, report.TotalDraws, report.Strategy, report.BatchSize, report.Guarantee)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "鱼类\t积分\t配置概率\t次数\t实际频率\t")
+	for _, item := range report.Items {
+		fmt.Fprintf(w, "%s\t%d\t%.4f%%\t%d\t%.4f%%\t\n",
+			item.ItemName, item.Points, item.ConfiguredProbability*100, item.Count, item.ObservedFrequency*100)
+	}
+	w.Flush()
+
+	fmt.Printf("\n每抽期望积分：配置 %.3f，模拟 %.3f\n\n", report.ExpectedValuePerDraw, report.ObservedValuePerDraw)
+
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "\tmin\tp10\tp25\tp50\tp75\tp90\tp99\tmax\tmean\tstddev\t")
+	printSummary(w, "玩家积分", report.Points)
+	if report.FirstRare.Draws != nil {
+		printSummary(w, "首次"+report.FirstRare.Tag+"抽数", report.FirstRare.Draws)
+	}
+	w.Flush()
+	fmt.Printf("\n钓到%s的玩家 %d，未钓到 %d\n", report.FirstRare.Tag, report.FirstRare.Reached, report.FirstRare.NeverReached)
+
+	lb := report.Leaderboard
+	fmt.Printf("\n榜单预测：第1名 %.0f，第10名 %.0f，中位数 %.0f，末位 %.0f\n", lb.Top1, lb.Top10Cutoff, lb.Median, lb.Bottom)
+	fmt.Printf("第1名/中位数 %.2f，前10名积分占比 %.2f%%，P90-P10 %.0f，基尼系数 %.3f\n",
+		lb.Top1MedianRatio, lb.Top10Share*100, lb.InterDecileRange, lb.GiniCoefficient)
+}
+
+// printSummary 输出一行分布摘要
+func printSummary(w *tabwriter.Writer, name string, s *model.DistributionSummary) {
+	fmt.Fprintf(w, "%s\t%.0f\t%.0f\t%.0f\t%.0f\t%.0f\t%.0f\t%.0f\t%.0f\t%.1f\t%.1f\t\n",
+		name, s.Min, s.P10, s.P25, s.P50, s.P75, s.P90, s.P99, s.Max, s.Mean, s.StdDev)
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+	_ "time/tzdata" // 运行镜像中没有时区数据库
+)
+
+// GetEnvDuration 从环境变量读取时长配置（如 "24h"、"30m"），未设置或格式错误时返回默认值
+func GetEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		log.Printf("Invalid duration for %s: %q, using default %s", key, value, defaultValue)
+		return defaultValue
+	}
+
+	return duration
+}
+
+// ParseEnvDuration 从环境变量读取时长配置，未设置时返回默认值，格式错误时返回错误而不回退到默认值。
+// 不校验取值范围，由调用方在启动时校验
+func ParseEnvDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration for %s: %q", key, value)
+	}
+
+	return duration, nil
+}
+
+// GetEnvInt 从环境变量读取整数配置，未设置或格式错误时返回默认值
+func GetEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid integer for %s: %q, using default %d", key, value, defaultValue)
+		return defaultValue
+	}
+
+	return n
+}
+
+// GetEnvFloat 从环境变量读取浮点数配置，未设置或格式错误时返回默认值
+func GetEnvFloat(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Invalid float for %s: %q, using default %g", key, value, defaultValue)
+		return defaultValue
+	}
+
+	return f
+}
+
+// GetEnvLocation 从环境变量读取时区配置（如 "Asia/Shanghai"），未设置或无法识别时返回默认时区
+func GetEnvLocation(key string, defaultName string) *time.Location {
+	name := os.Getenv(key)
+	if name == "" {
+		name = defaultName
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		log.Printf("Invalid timezone for %s: %q, using UTC", key, name)
+		return time.UTC
+	}
+
+	return location
+}
+
+// GetEnvString 从环境变量读取字符串配置，未设置时返回默认值
+func GetEnvString(key string, defaultValue string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// GetConfigDir 配置文件目录，默认为 ./configs（容器中挂载到 /root/configs）
+func GetConfigDir() string {
+	dir := os.Getenv("CONFIG_DIR")
+	if dir == "" {
+		dir = "configs"
+	}
+	return dir
+}
+
+// LoadJSON 从配置目录读取JSON配置文件。文件不存在时返回 false 且不报错，由调用方使用默认配置
+func LoadJSON(name string, v interface{}) (bool, error) {
+	path := filepath.Join(GetConfigDir(), name)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read config %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http"
+
+	"fishing-game/model"
+	"fishing-game/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AchievementHandler struct {
+	achievementService *service.AchievementService
+}
+
+// NewAchievementHandler 创建成就处理器
+func NewAchievementHandler(achievementService *service.AchievementService) *AchievementHandler {
+	return &AchievementHandler{
+		achievementService: achievementService,
+	}
+}
+
+// GetAchievements 获取用户成就进度
+// GET /fishing/users/{id}/achievements
+func (ah *AchievementHandler) GetAchievements(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse())
+		return
+	}
+
+	response, err := ah.achievementService.GetAchievements(c.Request.Context(), userID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
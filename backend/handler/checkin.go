@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http"
+
+	"fishing-game/model"
+	"fishing-game/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CheckinHandler struct {
+	checkinService *service.CheckinService
+}
+
+// NewCheckinHandler 创建签到处理器
+func NewCheckinHandler(checkinService *service.CheckinService) *CheckinHandler {
+	return &CheckinHandler{
+		checkinService: checkinService,
+	}
+}
+
+// Checkin 每日签到
+// POST /fishing/checkin
+func (ch *CheckinHandler) Checkin(c *gin.Context) {
+	var req model.CheckinRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse())
+		return
+	}
+
+	response, err := ch.checkinService.Checkin(c.Request.Context(), &req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"fishing-game/model"
+	"fishing-game/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CreatorHandler struct {
+	creatorService *service.CreatorService
+}
+
+// NewCreatorHandler 创建创建者处理器
+func NewCreatorHandler(creatorService *service.CreatorService) *CreatorHandler {
+	return &CreatorHandler{
+		creatorService: creatorService,
+	}
+}
+
+// GetCreatorFish 获取创建者的用户鱼概况
+// GET /fishing/creators/{wx_id}/fish?days=30
+func (ch *CreatorHandler) GetCreatorFish(c *gin.Context) {
+	wxID := c.Param("wx_id")
+	if wxID == "" {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse())
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(service.DefaultCreatorSeriesDays)))
+	if err != nil || days <= 0 {
+		days = service.DefaultCreatorSeriesDays
+	}
+	if days > service.MaxCreatorSeriesDays {
+		days = service.MaxCreatorSeriesDays // 每日统计只保留90天
+	}
+
+	response, err := ch.creatorService.GetCreatorFish(c.Request.Context(), wxID, days)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"fishing-game/model"
+	"fishing-game/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondError 根据业务错误类型返回对应的错误响应，未识别的错误统一返回500
+func respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrIdempotencyConflict), errors.Is(err, service.ErrIdempotencyInProgress),
+		errors.Is(err, service.ErrIdempotencyExpired), errors.Is(err, service.ErrDrawConflict):
+		c.JSON(http.StatusConflict, model.NewBusinessErrorResponse(http.StatusConflict, err.Error(), nil))
+	case errors.Is(err, service.ErrUnknownStrategy), errors.Is(err, service.ErrInvalidCursor),
+		errors.Is(err, service.ErrInvalidStatsQuery), errors.Is(err, service.ErrInvalidTrade),
+		errors.Is(err, service.ErrInvalidPoolEvent), errors.Is(err, service.ErrInvalidTournament),
+		errors.Is(err, service.ErrDrawCostExceedsMax):
+		c.JSON(http.StatusBadRequest, model.NewBusinessErrorResponse(http.StatusBadRequest, err.Error(), nil))
+	case errors.Is(err, service.ErrOutOfStamina):
+		var staminaErr *service.OutOfStaminaError
+		errors.As(err, &staminaErr)
+		c.JSON(http.StatusTooManyRequests, model.NewBusinessErrorResponse(http.StatusTooManyRequests, err.Error(), staminaErr.Detail))
+	case errors.Is(err, service.ErrInsufficientItems), errors.Is(err, service.ErrInsufficientCoins),
+		errors.Is(err, service.ErrTradeNotPending), errors.Is(err, service.ErrTradeExpired),
+		errors.Is(err, service.ErrAlreadyCheckedIn), errors.Is(err, service.ErrCheckinRepair),
+		errors.Is(err, service.ErrGearNotForSale), errors.Is(err, service.ErrGearNotOwned),
+		errors.Is(err, service.ErrPoolEventClosed), errors.Is(err, service.ErrTournamentState),
+		errors.Is(err, service.ErrTournamentEntryClosed), errors.Is(err, service.ErrTournamentFull),
+		errors.Is(err, service.ErrAlreadyRegistered):
+		c.JSON(http.StatusConflict, model.NewBusinessErrorResponse(http.StatusConflict, err.Error(), nil))
+	case errors.Is(err, service.ErrTradeLimit):
+		c.JSON(http.StatusTooManyRequests, model.NewBusinessErrorResponse(http.StatusTooManyRequests, err.Error(), nil))
+	case errors.Is(err, service.ErrTradeForbidden):
+		c.JSON(http.StatusForbidden, model.NewBusinessErrorResponse(http.StatusForbidden, err.Error(), nil))
+	case errors.Is(err, service.ErrDrawNotFound), errors.Is(err, service.ErrItemNotFound),
+		errors.Is(err, service.ErrTradeNotFound), errors.Is(err, service.ErrGearNotFound),
+		errors.Is(err, service.ErrUnknownSpot), errors.Is(err, service.ErrPoolEventNotFound),
+		errors.Is(err, service.ErrTournamentNotFound):
+		c.JSON(http.StatusNotFound, model.NewBusinessErrorResponse(http.StatusNotFound, err.Error(), nil))
+	default:
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse())
+	}
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"fishing-game/model"
+	"fishing-game/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FairnessHandler struct {
+	fairnessService *service.FairnessService
+}
+
+// NewFairnessHandler 创建可验证抽奖处理器
+func NewFairnessHandler(fairnessService *service.FairnessService) *FairnessHandler {
+	return &FairnessHandler{
+		fairnessService: fairnessService,
+	}
+}
+
+// GetCommitment 获取用户当前周期公布的服务端种子哈希
+// GET /fishing/lotteries/fair/{user_id}
+func (fh *FairnessHandler) GetCommitment(c *gin.Context) {
+	userID := c.Param("user_id")
+	if userID == "" {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse())
+		return
+	}
+
+	response, err := fh.fairnessService.GetCommitment(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse())
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
+
+// Rotate 揭示当前服务端种子并开启新周期
+// POST /fishing/lotteries/fair/{user_id}/rotate
+func (fh *FairnessHandler) Rotate(c *gin.Context) {
+	userID := c.Param("user_id")
+	if userID == "" {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse())
+		return
+	}
+
+	response, err := fh.fairnessService.Rotate(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse())
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
+
+// GetProofs 获取用户某周期的抽奖证明
+// GET /fishing/lotteries/fair/{user_id}/proofs?epoch=1
+func (fh *FairnessHandler) GetProofs(c *gin.Context) {
+	userID := c.Param("user_id")
+	if userID == "" {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse())
+		return
+	}
+
+	epoch, err := strconv.ParseInt(c.DefaultQuery("epoch", "1"), 10, 64)
+	if err != nil || epoch <= 0 {
+		epoch = 1
+	}
+
+	response, err := fh.fairnessService.GetProofs(c.Request.Context(), userID, epoch)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse())
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
+
+// Verify 验证一次可验证抽奖
+// POST /fishing/lotteries/fair/verify
+func (fh *FairnessHandler) Verify(c *gin.Context) {
+	var req model.FairVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse())
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(service.VerifyFairDraw(&req)))
+}
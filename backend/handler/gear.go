@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"net/http"
+
+	"fishing-game/model"
+	"fishing-game/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type GearHandler struct {
+	gearService *service.GearService
+}
+
+// NewGearHandler 创建装备处理器
+func NewGearHandler(gearService *service.GearService) *GearHandler {
+	return &GearHandler{
+		gearService: gearService,
+	}
+}
+
+// ListGear 获取所有装备定义
+// GET /fishing/gear
+func (gh *GearHandler) ListGear(c *gin.Context) {
+	c.JSON(http.StatusOK, model.NewSuccessResponse(gh.gearService.ListGear()))
+}
+
+// GetUserGear 获取用户装备
+// GET /fishing/users/{id}/gear
+func (gh *GearHandler) GetUserGear(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse())
+		return
+	}
+
+	response, err := gh.gearService.GetUserGear(c.Request.Context(), userID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
+
+// Purchase 用金币购买装备
+// POST /fishing/gear/purchase
+func (gh *GearHandler) Purchase(c *gin.Context) {
+	var req model.GearPurchaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse())
+		return
+	}
+
+	response, err := gh.gearService.Purchase(c.Request.Context(), &req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
+
+// Equip 装备
+// POST /fishing/gear/equip
+func (gh *GearHandler) Equip(c *gin.Context) {
+	var req model.GearEquipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse())
+		return
+	}
+
+	response, err := gh.gearService.Equip(c.Request.Context(), &req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
+
+// Unequip 卸下装备
+// POST /fishing/gear/unequip
+func (gh *GearHandler) Unequip(c *gin.Context) {
+	var req model.GearUnequipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse())
+		return
+	}
+
+	response, err := gh.gearService.Unequip(c.Request.Context(), &req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
@@ -32,15 +32,33 @@ func (lh *LotteryHandler) Draw(c *gin.Context) {
 
 	response, err := lh.lotteryService.Draw(c.Request.Context(), &req)
 	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
+
+// BatchDraw 执行连抽
+// POST /fishing/lotteries/draw/batch
+func (lh *LotteryHandler) BatchDraw(c *gin.Context) {
+	var req model.LotteryBatchDrawRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusInternalServerError, model.NewErrorResponse())
 		return
 	}
 
+	response, err := lh.lotteryService.BatchDraw(c.Request.Context(), &req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
 	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
 }
 
-// GetUserDrawHistory 获取用户抽奖历史（可选功能）
-// GET /fishing/lotteries/history/{user_id}?limit=10
+// GetUserDrawHistory 获取用户抽奖历史（游标分页）
+// GET /fishing/lotteries/history/{user_id}?limit=10&cursor=&from=&to=&item_id=&result=win|loss
 func (lh *LotteryHandler) GetUserDrawHistory(c *gin.Context) {
 	userID := c.Param("user_id")
 	if userID == "" {
@@ -55,14 +73,68 @@ func (lh *LotteryHandler) GetUserDrawHistory(c *gin.Context) {
 		limit = 10
 	}
 	if limit > 100 {
-		limit = 100 // 最多返回100条
+		limit = 100 // 每页最多返回100条
+	}
+
+	query := &model.DrawHistoryQuery{
+		UserID: userID,
+		Limit:  limit,
+		Cursor: c.Query("cursor"),
+		ItemID: c.Query("item_id"),
+		Result: c.Query("result"),
+	}
+
+	// 时间范围支持RFC3339或毫秒时间戳
+	if query.From, err = parseTimeQuery(c.Query("from")); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewBusinessErrorResponse(http.StatusBadRequest, "invalid from", nil))
+		return
+	}
+	if query.To, err = parseTimeQuery(c.Query("to")); err != nil {
+		c.JSON(http.StatusBadRequest, model.NewBusinessErrorResponse(http.StatusBadRequest, "invalid to", nil))
+		return
+	}
+
+	response, err := lh.lotteryService.GetUserDrawHistory(c.Request.Context(), query)
+	if err != nil {
+		respondError(c, err)
+		return
 	}
 
-	records, err := lh.lotteryService.GetUserDrawHistory(c.Request.Context(), userID, limit)
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
+
+// GetDraw 按抽奖ID查询抽奖记录
+// GET /fishing/lotteries/draws/{draw_id}
+func (lh *LotteryHandler) GetDraw(c *gin.Context) {
+	drawID := c.Param("draw_id")
+	if drawID == "" {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse())
+		return
+	}
+
+	record, err := lh.lotteryService.GetDraw(c.Request.Context(), drawID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(record))
+}
+
+// GetPityState 获取用户保底状态
+// GET /fishing/lotteries/pity/{user_id}
+func (lh *LotteryHandler) GetPityState(c *gin.Context) {
+	userID := c.Param("user_id")
+	if userID == "" {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse())
+		return
+	}
+
+	response, err := lh.lotteryService.GetPityState(c.Request.Context(), userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, model.NewErrorResponse())
 		return
 	}
 
-	c.JSON(http.StatusOK, model.NewSuccessResponse(records))
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
 }
@@ -33,7 +33,7 @@ func (ph *PoolHandler) AddFish(c *gin.Context) {
 
 	response, err := ph.poolService.AddFish(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.NewErrorResponse())
+		respondError(c, err)
 		return
 	}
 
@@ -53,10 +53,39 @@ func (ph *PoolHandler) AddFish(c *gin.Context) {
 	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
 }
 
-// GetPool 获取奖池信息
-// GET /fishing/lottery/pool
+// GetPool 获取钓点奖池信息
+// GET /fishing/lottery/pool?spot=xxx
 func (ph *PoolHandler) GetPool(c *gin.Context) {
-	response, err := ph.poolService.GetPool(c.Request.Context())
+	response, err := ph.poolService.GetPool(c.Request.Context(), c.Query("spot"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
+
+// SetStrategy 设置奖池默认抽奖策略
+// PUT /fishing/lottery/pool/strategy
+func (ph *PoolHandler) SetStrategy(c *gin.Context) {
+	var req model.SetPoolStrategyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse())
+		return
+	}
+
+	if err := ph.poolService.SetStrategy(c.Request.Context(), req.Spot, req.Strategy); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(req))
+}
+
+// ListSpots 获取所有钓点
+// GET /fishing/lottery/spots
+func (ph *PoolHandler) ListSpots(c *gin.Context) {
+	response, err := ph.poolService.ListSpots(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, model.NewErrorResponse())
 		return
@@ -64,3 +93,11 @@ func (ph *PoolHandler) GetPool(c *gin.Context) {
 
 	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
 }
+
+// ListStrategies 获取可用的抽奖策略
+// GET /fishing/lottery/strategies
+func (ph *PoolHandler) ListStrategies(c *gin.Context) {
+	c.JSON(http.StatusOK, model.NewSuccessResponse(&model.StrategyListResponse{
+		Strategies: service.DrawStrategies.Names(),
+	}))
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"fishing-game/model"
+	"fishing-game/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PoolEventHandler struct {
+	eventService *service.PoolEventService
+}
+
+// NewPoolEventHandler 创建限时活动处理器
+func NewPoolEventHandler(eventService *service.PoolEventService) *PoolEventHandler {
+	return &PoolEventHandler{
+		eventService: eventService,
+	}
+}
+
+// CreateEvent 创建限时活动
+// POST /fishing/lottery/events
+func (eh *PoolEventHandler) CreateEvent(c *gin.Context) {
+	var req model.CreatePoolEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse())
+		return
+	}
+
+	response, err := eh.eventService.CreateEvent(c.Request.Context(), &req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
+
+// ListEvents 获取限时活动列表
+// GET /fishing/lottery/events?spot=xxx&status=scheduled|active|ended|cancelled
+func (eh *PoolEventHandler) ListEvents(c *gin.Context) {
+	response, err := eh.eventService.ListEvents(c.Request.Context(), c.Query("spot"), c.Query("status"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
+
+// CancelEvent 取消限时活动
+// DELETE /fishing/lottery/events/{event_id}
+func (eh *PoolEventHandler) CancelEvent(c *gin.Context) {
+	response, err := eh.eventService.CancelEvent(c.Request.Context(), c.Param("event_id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
+
+// GetEffectivePool 获取指定时间点的有效奖池
+// GET /fishing/lottery/pool/effective?spot=xxx&at=RFC3339|毫秒时间戳（默认当前时间）
+func (eh *PoolEventHandler) GetEffectivePool(c *gin.Context) {
+	at, err := parseTimeQuery(c.Query("at"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.NewBusinessErrorResponse(http.StatusBadRequest, "invalid at", nil))
+		return
+	}
+	if at == nil {
+		now := time.Now()
+		at = &now
+	}
+
+	response, err := eh.eventService.GetEffectivePool(c.Request.Context(), c.Query("spot"), *at)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"strconv"
+	"time"
+)
+
+// parseTimeQuery 解析时间查询参数，支持RFC3339和毫秒时间戳，空字符串返回nil
+func parseTimeQuery(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		t := time.UnixMilli(ms)
+		return &t, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -31,7 +31,7 @@ func (rh *RankingHandler) IncrementScore(c *gin.Context) {
 
 	response, err := rh.rankingService.IncrementScore(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.NewErrorResponse())
+		respondError(c, err)
 		return
 	}
 
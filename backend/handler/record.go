@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"net/http"
+
+	"fishing-game/model"
+	"fishing-game/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RecordHandler struct {
+	recordService *service.RecordService
+}
+
+// NewRecordHandler 创建纪录处理器
+func NewRecordHandler(recordService *service.RecordService) *RecordHandler {
+	return &RecordHandler{
+		recordService: recordService,
+	}
+}
+
+// GetPersonalRecords 获取用户个人最佳纪录
+// GET /fishing/users/{id}/records
+func (rh *RecordHandler) GetPersonalRecords(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse())
+		return
+	}
+
+	response, err := rh.recordService.GetPersonalRecords(c.Request.Context(), userID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
+
+// GetGlobalRecords 获取全服最大渔获纪录
+// GET /fishing/lottery/records
+func (rh *RecordHandler) GetGlobalRecords(c *gin.Context) {
+	response, err := rh.recordService.GetGlobalRecords(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http"
+
+	"fishing-game/model"
+	"fishing-game/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RoyaltyHandler struct {
+	royaltyService *service.RoyaltyService
+}
+
+// NewRoyaltyHandler 创建版税处理器
+func NewRoyaltyHandler(royaltyService *service.RoyaltyService) *RoyaltyHandler {
+	return &RoyaltyHandler{
+		royaltyService: royaltyService,
+	}
+}
+
+// GetEarnings 获取用户鱼创建者的版税收益
+// GET /fishing/users/{id}/royalties
+func (rh *RoyaltyHandler) GetEarnings(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse())
+		return
+	}
+
+	response, err := rh.royaltyService.GetEarnings(c.Request.Context(), userID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
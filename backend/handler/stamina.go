@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http"
+
+	"fishing-game/model"
+	"fishing-game/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StaminaHandler struct {
+	staminaService *service.StaminaService
+}
+
+// NewStaminaHandler 创建体力处理器
+func NewStaminaHandler(staminaService *service.StaminaService) *StaminaHandler {
+	return &StaminaHandler{
+		staminaService: staminaService,
+	}
+}
+
+// GetStamina 获取用户体力状态
+// GET /fishing/users/{id}/stamina
+func (sh *StaminaHandler) GetStamina(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse())
+		return
+	}
+
+	response, err := sh.staminaService.GetState(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse())
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"net/http"
+
+	"fishing-game/model"
+	"fishing-game/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StatsHandler struct {
+	statsService *service.StatsService
+}
+
+// NewStatsHandler 创建统计处理器
+func NewStatsHandler(statsService *service.StatsService) *StatsHandler {
+	return &StatsHandler{
+		statsService: statsService,
+	}
+}
+
+// GetStats 获取出鱼统计及与配置概率的对比
+// GET /fishing/lotteries/stats?scope=global|daily|user&date=YYYYMMDD&user_id=xxx
+func (sh *StatsHandler) GetStats(c *gin.Context) {
+	response, err := sh.statsService.GetStats(c.Request.Context(), c.Query("scope"), c.Query("date"), c.Query("user_id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
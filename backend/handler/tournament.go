@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"fishing-game/model"
+	"fishing-game/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TournamentHandler struct {
+	tournamentService *service.TournamentService
+}
+
+// NewTournamentHandler 创建比赛处理器
+func NewTournamentHandler(tournamentService *service.TournamentService) *TournamentHandler {
+	return &TournamentHandler{
+		tournamentService: tournamentService,
+	}
+}
+
+// CreateTournament 创建比赛
+// POST /fishing/tournaments
+func (th *TournamentHandler) CreateTournament(c *gin.Context) {
+	var req model.CreateTournamentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse())
+		return
+	}
+
+	response, err := th.tournamentService.CreateTournament(c.Request.Context(), &req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
+
+// ListTournaments 获取比赛列表
+// GET /fishing/tournaments?status=registration|running|settled|cancelled&limit=20
+func (th *TournamentHandler) ListTournaments(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100 // 最多返回100场
+	}
+
+	response, err := th.tournamentService.ListTournaments(c.Request.Context(), c.Query("status"), limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
+
+// GetTournament 获取比赛详情
+// GET /fishing/tournaments/{tournament_id}
+func (th *TournamentHandler) GetTournament(c *gin.Context) {
+	response, err := th.tournamentService.GetTournament(c.Request.Context(), c.Param("tournament_id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
+
+// Register 报名比赛
+// POST /fishing/tournaments/{tournament_id}/register
+func (th *TournamentHandler) Register(c *gin.Context) {
+	var req model.TournamentEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse())
+		return
+	}
+
+	response, err := th.tournamentService.Register(c.Request.Context(), c.Param("tournament_id"), &req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
+
+// GetLeaderboard 获取比赛榜单
+// GET /fishing/tournaments/{tournament_id}/leaderboard?limit=50
+func (th *TournamentHandler) GetLeaderboard(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		limit = 50
+	}
+	if limit > 500 {
+		limit = 500 // 每组最多返回500名
+	}
+
+	response, err := th.tournamentService.GetLeaderboard(c.Request.Context(), c.Param("tournament_id"), limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
+
+// Start 开赛
+// POST /fishing/tournaments/{tournament_id}/start
+func (th *TournamentHandler) Start(c *gin.Context) {
+	th.action(c, th.tournamentService.Start)
+}
+
+// Cancel 取消比赛
+// POST /fishing/tournaments/{tournament_id}/cancel
+func (th *TournamentHandler) Cancel(c *gin.Context) {
+	th.action(c, th.tournamentService.Cancel)
+}
+
+// Settle 结算比赛并发奖，返回最终排名
+// POST /fishing/tournaments/{tournament_id}/settle
+func (th *TournamentHandler) Settle(c *gin.Context) {
+	response, err := th.tournamentService.Settle(c.Request.Context(), c.Param("tournament_id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
+
+// action 处理开赛、取消比赛
+func (th *TournamentHandler) action(c *gin.Context, fn func(ctx context.Context, tournamentID string) (*model.Tournament, error)) {
+	response, err := fn(c.Request.Context(), c.Param("tournament_id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"fishing-game/model"
+	"fishing-game/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TradeHandler struct {
+	tradeService *service.TradeService
+}
+
+// NewTradeHandler 创建交易处理器
+func NewTradeHandler(tradeService *service.TradeService) *TradeHandler {
+	return &TradeHandler{
+		tradeService: tradeService,
+	}
+}
+
+// CreateOffer 发起交易
+// POST /fishing/trades
+func (th *TradeHandler) CreateOffer(c *gin.Context) {
+	var req model.CreateTradeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse())
+		return
+	}
+
+	response, err := th.tradeService.CreateOffer(c.Request.Context(), &req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
+
+// GetTrade 获取交易详情
+// GET /fishing/trades/{trade_id}
+func (th *TradeHandler) GetTrade(c *gin.Context) {
+	response, err := th.tradeService.GetTrade(c.Request.Context(), c.Param("trade_id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
+
+// Accept 接受交易
+// POST /fishing/trades/{trade_id}/accept
+func (th *TradeHandler) Accept(c *gin.Context) {
+	th.action(c, th.tradeService.Accept)
+}
+
+// Reject 拒绝交易
+// POST /fishing/trades/{trade_id}/reject
+func (th *TradeHandler) Reject(c *gin.Context) {
+	th.action(c, th.tradeService.Reject)
+}
+
+// Cancel 取消交易
+// POST /fishing/trades/{trade_id}/cancel
+func (th *TradeHandler) Cancel(c *gin.Context) {
+	th.action(c, th.tradeService.Cancel)
+}
+
+// action 处理接受、拒绝、取消交易
+func (th *TradeHandler) action(c *gin.Context, fn func(ctx context.Context, tradeID, userID string) (*model.TradeOffer, error)) {
+	var req model.TradeActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse())
+		return
+	}
+
+	response, err := fn(c.Request.Context(), c.Param("trade_id"), req.UserID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
+
+// GetUserTrades 获取用户交易记录
+// GET /fishing/users/{id}/trades?limit=20&cursor=&status=pending
+func (th *TradeHandler) GetUserTrades(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse())
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100 // 每页最多返回100条
+	}
+
+	response, err := th.tradeService.GetUserTrades(c.Request.Context(), userID, limit, c.Query("cursor"), c.Query("status"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(response))
+}
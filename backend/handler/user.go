@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"net/http"
+
+	"fishing-game/model"
+	"fishing-game/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UserHandler struct {
+	userService    *service.UserService
+	checkinService *service.CheckinService
+}
+
+// NewUserHandler 创建用户处理器
+func NewUserHandler(userService *service.UserService, checkinService *service.CheckinService) *UserHandler {
+	return &UserHandler{
+		userService:    userService,
+		checkinService: checkinService,
+	}
+}
+
+// GetProfile 获取用户资料（含签到状态）
+// GET /fishing/users/{id}/profile
+func (uh *UserHandler) GetProfile(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		c.JSON(http.StatusInternalServerError, model.NewErrorResponse())
+		return
+	}
+
+	user, err := uh.userService.GetUser(c.Request.Context(), userID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	checkin, err := uh.checkinService.GetState(c.Request.Context(), userID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(&model.UserProfileResponse{
+		UserID:   userID,
+		Username: user.Username,
+		Found:    user.Found,
+		Checkin:  checkin,
+	}))
+}
@@ -18,9 +18,22 @@ func main() {
 	log.Println("Redis connection initialized")
 
 	// 初始化服务层
+	idempotencyService := service.NewIdempotencyService(config.GetEnvDuration("IDEMPOTENCY_WINDOW", service.DefaultIdempotencyWindow))
 	userService := service.NewUserService()
-	rankingService := service.NewRankingService(userService)
-	poolService := service.NewPoolService()
+	rankingService := service.NewRankingService(userService, idempotencyService)
+	poolService, err := service.NewPoolService()
+	if err != nil {
+		log.Fatalf("Failed to initialize pool service: %v", err)
+	}
+	staminaService, err := service.NewStaminaService()
+	if err != nil {
+		log.Fatalf("Failed to initialize stamina service: %v", err)
+	}
+	fairnessService := service.NewFairnessService()
+	gearService, err := service.NewGearService(idempotencyService)
+	if err != nil {
+		log.Fatalf("Failed to initialize gear service: %v", err)
+	}
 
 	// 初始化奖池数据
 	if err := poolService.InitializePool(context.Background()); err != nil {
@@ -28,16 +41,57 @@ func main() {
 	}
 	log.Println("Pool initialized")
 
-	lotteryService, err := service.NewLotteryService(rankingService, poolService)
+	poolEventService := service.NewPoolEventService(poolService)
+	tournamentService, err := service.NewTournamentService(poolService, rankingService)
+	if err != nil {
+		log.Fatalf("Failed to initialize tournament service: %v", err)
+	}
+	lotteryService, err := service.NewLotteryService(rankingService, poolService, idempotencyService, staminaService, fairnessService, gearService, poolEventService, tournamentService)
 	if err != nil {
 		log.Fatalf("Failed to initialize lottery service: %v", err)
 	}
+	statsService := service.NewStatsService(poolService)
+	inventoryService := service.NewInventoryService(poolService)
+	recordService := service.NewRecordService()
+	walletService := service.NewWalletService(poolService, idempotencyService)
+	tradeService := service.NewTradeService(poolService, idempotencyService)
+	achievementService, err := service.NewAchievementService(rankingService, poolService, inventoryService)
+	if err != nil {
+		log.Fatalf("Failed to initialize achievement service: %v", err)
+	}
+	checkinService, err := service.NewCheckinService(rankingService)
+	if err != nil {
+		log.Fatalf("Failed to initialize check-in service: %v", err)
+	}
+	royaltyService := service.NewRoyaltyService(poolService, rankingService)
+	creatorService := service.NewCreatorService(poolService, poolEventService)
+	lotteryService.AddDrawListener(statsService)
+	lotteryService.AddDrawListener(achievementService)
+	lotteryService.AddDrawListener(royaltyService)
+	rankingService.AddScoreListener(achievementService)
 	log.Println("Services initialized")
 
 	// 初始化处理器
-	rankingHandler := handler.NewRankingHandler(rankingService)
-	lotteryHandler := handler.NewLotteryHandler(lotteryService)
-	poolHandler := handler.NewPoolHandler(poolService, userService)
+	h := &handlers{
+		ranking:     handler.NewRankingHandler(rankingService),
+		lottery:     handler.NewLotteryHandler(lotteryService),
+		pool:        handler.NewPoolHandler(poolService, userService),
+		poolEvent:   handler.NewPoolEventHandler(poolEventService),
+		stamina:     handler.NewStaminaHandler(staminaService),
+		fairness:    handler.NewFairnessHandler(fairnessService),
+		stats:       handler.NewStatsHandler(statsService),
+		inventory:   handler.NewInventoryHandler(inventoryService),
+		record:      handler.NewRecordHandler(recordService),
+		wallet:      handler.NewWalletHandler(walletService),
+		trade:       handler.NewTradeHandler(tradeService),
+		achievement: handler.NewAchievementHandler(achievementService),
+		checkin:     handler.NewCheckinHandler(checkinService),
+		user:        handler.NewUserHandler(userService, checkinService),
+		gear:        handler.NewGearHandler(gearService),
+		tournament:  handler.NewTournamentHandler(tournamentService),
+		royalty:     handler.NewRoyaltyHandler(royaltyService),
+		creator:     handler.NewCreatorHandler(creatorService),
+	}
 
 	// 创建Gin路由器
 	r := gin.Default()
@@ -46,7 +100,7 @@ func main() {
 	r.Use(CORSMiddleware())
 
 	// 设置路由
-	setupRoutes(r, rankingHandler, lotteryHandler, poolHandler)
+	setupRoutes(r, h)
 
 	// 启动服务器
 	log.Println("Server starting on :8080")
@@ -55,8 +109,30 @@ func main() {
 	}
 }
 
+// handlers 各模块的HTTP处理器，由setupRoutes注册路由
+type handlers struct {
+	ranking     *handler.RankingHandler
+	lottery     *handler.LotteryHandler
+	pool        *handler.PoolHandler
+	poolEvent   *handler.PoolEventHandler
+	stamina     *handler.StaminaHandler
+	fairness    *handler.FairnessHandler
+	stats       *handler.StatsHandler
+	inventory   *handler.InventoryHandler
+	record      *handler.RecordHandler
+	wallet      *handler.WalletHandler
+	trade       *handler.TradeHandler
+	achievement *handler.AchievementHandler
+	checkin     *handler.CheckinHandler
+	user        *handler.UserHandler
+	gear        *handler.GearHandler
+	tournament  *handler.TournamentHandler
+	royalty     *handler.RoyaltyHandler
+	creator     *handler.CreatorHandler
+}
+
 // setupRoutes 设置路由
-func setupRoutes(r *gin.Engine, rankingHandler *handler.RankingHandler, lotteryHandler *handler.LotteryHandler, poolHandler *handler.PoolHandler) {
+func setupRoutes(r *gin.Engine, h *handlers) {
 	// 设置静态资源服务
 	r.Static("/assets", "./assets")
 
@@ -67,31 +143,143 @@ func setupRoutes(r *gin.Engine, rankingHandler *handler.RankingHandler, lotteryH
 	leaderboards := api.Group("/leaderboards")
 	{
 		// 增加积分
-		leaderboards.POST("/scores/increment", rankingHandler.IncrementScore)
+		leaderboards.POST("/scores/increment", h.ranking.IncrementScore)
 		// 获取Top N排行榜
-		leaderboards.GET("/top", rankingHandler.GetTopRanking)
+		leaderboards.GET("/top", h.ranking.GetTopRanking)
 		// 获取单个用户的排名和积分
-		leaderboards.GET("/users/:user_id", rankingHandler.GetUserRanking)
+		leaderboards.GET("/users/:user_id", h.ranking.GetUserRanking)
 	}
 
 	// 抽奖相关路由
 	lotteries := api.Group("/lotteries")
 	{
 		// 执行抽奖
-		lotteries.POST("/draw", lotteryHandler.Draw)
+		lotteries.POST("/draw", h.lottery.Draw)
+		// 执行连抽
+		lotteries.POST("/draw/batch", h.lottery.BatchDraw)
 		// 获取用户抽奖历史
-		lotteries.GET("/history/:user_id", lotteryHandler.GetUserDrawHistory)
+		lotteries.GET("/history/:user_id", h.lottery.GetUserDrawHistory)
+		// 按抽奖ID查询抽奖记录
+		lotteries.GET("/draws/:draw_id", h.lottery.GetDraw)
+		// 获取用户保底状态
+		lotteries.GET("/pity/:user_id", h.lottery.GetPityState)
+		// 可验证抽奖：公布种子哈希、揭示种子、查询证明、验证
+		lotteries.GET("/fair/:user_id", h.fairness.GetCommitment)
+		lotteries.POST("/fair/:user_id/rotate", h.fairness.Rotate)
+		lotteries.GET("/fair/:user_id/proofs", h.fairness.GetProofs)
+		lotteries.POST("/fair/verify", h.fairness.Verify)
+		// 出鱼统计（实际频率 vs 配置概率）
+		lotteries.GET("/stats", h.stats.GetStats)
 	}
 
 	// 奖池相关路由
 	lottery := api.Group("/lottery")
 	{
 		// 添加新鱼到奖池
-		lottery.POST("/items", poolHandler.AddFish)
+		lottery.POST("/items", h.pool.AddFish)
 		// 获取奖池信息
-		lottery.GET("/pool", poolHandler.GetPool)
+		lottery.GET("/pool", h.pool.GetPool)
+		// 设置奖池默认抽奖策略
+		lottery.PUT("/pool/strategy", h.pool.SetStrategy)
+		// 获取可用的抽奖策略
+		lottery.GET("/strategies", h.pool.ListStrategies)
+		// 获取所有钓点
+		lottery.GET("/spots", h.pool.ListSpots)
+		// 限时活动：创建、列表、取消，以及指定时间点的有效奖池
+		lottery.POST("/events", h.poolEvent.CreateEvent)
+		lottery.GET("/events", h.poolEvent.ListEvents)
+		lottery.DELETE("/events/:event_id", h.poolEvent.CancelEvent)
+		lottery.GET("/pool/effective", h.poolEvent.GetEffectivePool)
+		// 获取全服各鱼类最大渔获纪录
+		lottery.GET("/records", h.record.GetGlobalRecords)
+	}
+
+	// 用户相关路由
+	users := api.Group("/users")
+	{
+		// 获取用户资料（含签到状态）
+		users.GET("/:id/profile", h.user.GetProfile)
+		// 获取用户体力状态
+		users.GET("/:id/stamina", h.stamina.GetStamina)
+		// 获取用户背包与图鉴
+		users.GET("/:id/inventory", h.inventory.GetInventory)
+		users.GET("/:id/fishdex", h.inventory.GetFishdex)
+		// 获取用户各鱼类个人最佳纪录
+		users.GET("/:id/records", h.record.GetPersonalRecords)
+		// 获取用户钱包与账本
+		users.GET("/:id/wallet", h.wallet.GetWallet)
+		users.GET("/:id/ledger", h.wallet.GetLedger)
+		// 获取用户交易记录
+		users.GET("/:id/trades", h.trade.GetUserTrades)
+		// 获取用户成就进度
+		users.GET("/:id/achievements", h.achievement.GetAchievements)
+		// 获取用户装备
+		users.GET("/:id/gear", h.gear.GetUserGear)
+		// 获取用户鱼创建者的版税收益
+		users.GET("/:id/royalties", h.royalty.GetEarnings)
 	}
 
+	// 用户鱼创建者相关路由
+	creators := api.Group("/creators")
+	{
+		// 获取创建的用户鱼的权重、概率及被钓情况
+		creators.GET("/:wx_id/fish", h.creator.GetCreatorFish)
+	}
+
+	// 背包交易相关路由
+	inventory := api.Group("/inventory")
+	{
+		// 卖鱼换取金币
+		inventory.POST("/sell", h.wallet.Sell)
+		// 放生换取榜单积分
+		inventory.POST("/release", h.wallet.Release)
+	}
+
+	// 玩家交易相关路由
+	trades := api.Group("/trades")
+	{
+		// 发起交易（托管发起方的鱼和金币）
+		trades.POST("", h.trade.CreateOffer)
+		// 获取交易详情
+		trades.GET("/:trade_id", h.trade.GetTrade)
+		// 接受、拒绝、取消交易
+		trades.POST("/:trade_id/accept", h.trade.Accept)
+		trades.POST("/:trade_id/reject", h.trade.Reject)
+		trades.POST("/:trade_id/cancel", h.trade.Cancel)
+	}
+
+	// 装备相关路由
+	gear := api.Group("/gear")
+	{
+		// 获取装备定义
+		gear.GET("", h.gear.ListGear)
+		// 用金币购买装备
+		gear.POST("/purchase", h.gear.Purchase)
+		// 装备、卸下
+		gear.POST("/equip", h.gear.Equip)
+		gear.POST("/unequip", h.gear.Unequip)
+	}
+
+	// 比赛相关路由
+	tournaments := api.Group("/tournaments")
+	{
+		// 创建比赛、获取比赛列表
+		tournaments.POST("", h.tournament.CreateTournament)
+		tournaments.GET("", h.tournament.ListTournaments)
+		// 获取比赛详情、比赛榜单
+		tournaments.GET("/:tournament_id", h.tournament.GetTournament)
+		tournaments.GET("/:tournament_id/leaderboard", h.tournament.GetLeaderboard)
+		// 报名
+		tournaments.POST("/:tournament_id/register", h.tournament.Register)
+		// 开赛、取消、结算发奖
+		tournaments.POST("/:tournament_id/start", h.tournament.Start)
+		tournaments.POST("/:tournament_id/cancel", h.tournament.Cancel)
+		tournaments.POST("/:tournament_id/settle", h.tournament.Settle)
+	}
+
+	// 每日签到
+	api.POST("/checkin", h.checkin.Checkin)
+
 	// 健康检查
 	api.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{
@@ -0,0 +1,48 @@
+package model
+
+import "time"
+
+// AchievementConfig 成就配置（configs/achievements.json）
+type AchievementConfig struct {
+	Achievements []*AchievementRule `json:"achievements"`
+}
+
+// AchievementRule 成就规则
+type AchievementRule struct {
+	ID          string             `json:"id"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Type        string             `json:"type"`             // catch_count / collection / streak / score
+	Filter      *AchievementFilter `json:"filter,omitempty"` // 参与计算的鱼类（score类型不使用）
+	Target      int                `json:"target,omitempty"` // 目标值（collection类型不使用）
+	Points      int                `json:"points,omitempty"` // 解锁时奖励的榜单积分
+}
+
+// AchievementFilter 鱼类筛选条件，多个条件同时满足才算匹配
+type AchievementFilter struct {
+	ItemID     string `json:"item_id,omitempty"`
+	Tag        string `json:"tag,omitempty"`
+	ExcludeTag string `json:"exclude_tag,omitempty"`
+	WxID       string `json:"wx_id,omitempty"` // 用户鱼的创建者
+}
+
+// UserAchievement 用户的单个成就进度
+type UserAchievement struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Type        string     `json:"type"`
+	Points      int        `json:"points"`
+	Progress    int        `json:"progress"`
+	Target      int        `json:"target"`
+	Unlocked    bool       `json:"unlocked"`
+	UnlockedAt  *time.Time `json:"unlocked_at,omitempty"`
+}
+
+// AchievementsResponse 用户成就列表
+type AchievementsResponse struct {
+	UserID        string             `json:"user_id"`
+	UnlockedCount int                `json:"unlocked_count"`
+	TotalCount    int                `json:"total_count"`
+	Achievements  []*UserAchievement `json:"achievements"`
+}
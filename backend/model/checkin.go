@@ -0,0 +1,56 @@
+package model
+
+import "time"
+
+// CheckinRewardConfig 签到奖励表（configs/checkin_rewards.json）
+type CheckinRewardConfig struct {
+	Cycle   int              `json:"cycle"`   // 奖励循环天数，为0时连续天数超过奖励表后一直使用最后一档
+	Rewards []*CheckinReward `json:"rewards"` // 按天数配置的奖励，未配置的天数使用之前最近一档
+}
+
+// CheckinReward 签到奖励
+type CheckinReward struct {
+	Day         int        `json:"day"`                    // 连续签到第几天起生效
+	Points      int        `json:"points,omitempty"`       // 榜单积分
+	ExtraDraws  int        `json:"extra_draws,omitempty"`  // 额外抽奖次数
+	RepairItems int        `json:"repair_items,omitempty"` // 补签卡
+	Boost       *DrawBoost `json:"boost,omitempty"`        // 下一次抽奖的权重加成
+}
+
+// CheckinRequest 签到请求
+type CheckinRequest struct {
+	UserID    string `json:"user_id" binding:"required"`
+	UseRepair bool   `json:"use_repair,omitempty"` // 漏签时使用补签卡延续连续天数（每漏签一天消耗一张）
+}
+
+// CheckinResponse 签到响应
+type CheckinResponse struct {
+	UserID       string         `json:"user_id"`
+	Date         string         `json:"date"`          // 签到日期（签到时区，YYYY-MM-DD）
+	Streak       int            `json:"streak"`        // 签到后的连续天数
+	RepairedDays int            `json:"repaired_days"` // 本次补签的天数
+	Reward       *CheckinReward `json:"reward,omitempty"`
+	State        *CheckinState  `json:"state"`
+}
+
+// CheckinState 用户签到状态
+type CheckinState struct {
+	Streak          int            `json:"streak"`                      // 连续签到天数（漏签时为漏签前的天数）
+	BestStreak      int            `json:"best_streak"`                 // 历史最长连续天数
+	TotalDays       int            `json:"total_days"`                  // 累计签到天数
+	LastCheckinDate string         `json:"last_checkin_date,omitempty"` // 最近签到日期（YYYY-MM-DD）
+	CheckedInToday  bool           `json:"checked_in_today"`
+	MissedDays      int            `json:"missed_days"`           // 最近签到之后漏签的天数
+	RepairItems     int            `json:"repair_items"`          // 持有的补签卡
+	Repairable      bool           `json:"repairable"`            // 漏签的天数能否用补签卡补上
+	NextReward      *CheckinReward `json:"next_reward,omitempty"` // 下一次签到（不补签）可获得的奖励
+	NextResetAt     time.Time      `json:"next_reset_at"`         // 下一个签到日的开始时间
+}
+
+// UserProfileResponse 用户资料
+type UserProfileResponse struct {
+	UserID   string        `json:"user_id"`
+	Username string        `json:"username,omitempty"`
+	Found    bool          `json:"found"` // 是否存在用户信息
+	Checkin  *CheckinState `json:"checkin"`
+}
@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// CreatorFishResponse 创建者的用户鱼概况
+type CreatorFishResponse struct {
+	WxID string         `json:"wx_id"`
+	Days int            `json:"days"` // 每日被钓次数序列的天数
+	Fish []*CreatorFish `json:"fish"` // 按钓点配置顺序、鱼名排列
+}
+
+// CreatorFish 一条用户鱼的当前权重与被钓情况
+type CreatorFish struct {
+	ItemID          string             `json:"item_id"`
+	Name            string             `json:"name"`
+	Description     string             `json:"description"`
+	ImageURL        string             `json:"image_url"`
+	Points          int                `json:"points"`
+	Spot            string             `json:"spot"`
+	Weight          int                `json:"weight"`           // 奖池存储权重
+	EffectiveWeight int                `json:"effective_weight"` // 叠加当前生效活动后的权重
+	Probability     float64            `json:"probability"`      // 当前每抽被钓到的概率（按有效权重）
+	TotalCaught     int64              `json:"total_caught"`
+	UniqueCatchers  int64              `json:"unique_catchers"` // 去重玩家数（近似值）
+	FirstCaughtAt   *time.Time         `json:"first_caught_at,omitempty"`
+	LastCaughtAt    *time.Time         `json:"last_caught_at,omitempty"`
+	DailyCatches    []*DailyCatchCount `json:"daily_catches"` // 从早到晚，包含今天
+}
+
+// DailyCatchCount 某天的被钓次数
+type DailyCatchCount struct {
+	Date  string `json:"date"` // YYYYMMDD
+	Count int64  `json:"count"`
+}
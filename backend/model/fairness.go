@@ -0,0 +1,67 @@
+package model
+
+// FairWeight 可验证抽奖使用的有效权重（按鱼类ID排序）
+type FairWeight struct {
+	ItemID string `json:"item_id"`
+	Weight int    `json:"weight"`
+}
+
+// FairProof 可验证抽奖的证明
+type FairProof struct {
+	DrawID         string       `json:"draw_id"`
+	UserID         string       `json:"user_id"`
+	Epoch          int64        `json:"epoch"`            // 服务端种子所属周期
+	ServerSeedHash string       `json:"server_seed_hash"` // 服务端种子的SHA256（抽奖前已公布）
+	ClientSeed     string       `json:"client_seed"`      // 客户端种子
+	Nonce          int64        `json:"nonce"`            // 本周期内的抽奖序号
+	Roll           int64        `json:"roll"`             // HMAC导出的随机数 [0, total_weight)
+	TotalWeight    int64        `json:"total_weight"`
+	ItemID         string       `json:"item_id"`
+	Weights        []FairWeight `json:"weights,omitempty"`         // 抽奖时的有效权重
+	ReplacedReason string       `json:"replaced_reason,omitempty"` // 结果被替换的原因（如连抽保底），为空表示即本次抽奖的最终结果
+}
+
+// FairCommitmentResponse 当前周期公布的服务端种子哈希
+type FairCommitmentResponse struct {
+	UserID         string `json:"user_id"`
+	Epoch          int64  `json:"epoch"`
+	ServerSeedHash string `json:"server_seed_hash"`
+	NextNonce      int64  `json:"next_nonce"`
+}
+
+// FairRotateResponse 轮换服务端种子响应
+type FairRotateResponse struct {
+	UserID             string `json:"user_id"`
+	RevealedEpoch      int64  `json:"revealed_epoch"`       // 被揭示的周期
+	RevealedServerSeed string `json:"revealed_server_seed"` // 被揭示的服务端种子
+	RevealedSeedHash   string `json:"revealed_seed_hash"`
+	Epoch              int64  `json:"epoch"` // 新周期
+	ServerSeedHash     string `json:"server_seed_hash"`
+}
+
+// FairProofsResponse 用户某周期的抽奖证明
+type FairProofsResponse struct {
+	UserID     string       `json:"user_id"`
+	Epoch      int64        `json:"epoch"`
+	ServerSeed string       `json:"server_seed,omitempty"` // 周期已揭示时才返回
+	Proofs     []*FairProof `json:"proofs"`
+}
+
+// FairVerifyRequest 验证请求
+type FairVerifyRequest struct {
+	ServerSeed     string       `json:"server_seed" binding:"required"`
+	ServerSeedHash string       `json:"server_seed_hash" binding:"required"`
+	ClientSeed     string       `json:"client_seed" binding:"required"`
+	Nonce          int64        `json:"nonce"`
+	Weights        []FairWeight `json:"weights" binding:"required"`
+	ItemID         string       `json:"item_id" binding:"required"`
+}
+
+// FairVerifyResponse 验证结果
+type FairVerifyResponse struct {
+	Valid          bool   `json:"valid"`
+	SeedHashMatch  bool   `json:"seed_hash_match"` // 服务端种子与抽奖前公布的哈希一致
+	Roll           int64  `json:"roll"`
+	ExpectedItemID string `json:"expected_item_id"`
+	ItemMatch      bool   `json:"item_match"`
+}
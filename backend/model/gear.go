@@ -0,0 +1,75 @@
+package model
+
+// GearConfig 装备配置（configs/gear.json）
+type GearConfig struct {
+	Gear []*GearDefinition `json:"gear"`
+}
+
+// GearDefinition 装备定义。装备时每次抽奖消耗1点耐久，并按effects修正本次抽奖权重
+type GearDefinition struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Slot        string            `json:"slot"`            // 装备栏位，如 rod、bait，每个栏位同时只能装备一件
+	Durability  int               `json:"durability"`      // 耐久（可使用的抽奖次数）
+	Price       int64             `json:"price,omitempty"` // 金币价格，为0时不可购买
+	Effects     []*ModifierEffect `json:"effects"`
+}
+
+// GearListResponse 装备定义列表
+type GearListResponse struct {
+	Gear []*GearDefinition `json:"gear"`
+}
+
+// UserGear 用户持有的一件装备
+type UserGear struct {
+	GearID        string            `json:"gear_id"`
+	Name          string            `json:"name"`
+	Slot          string            `json:"slot"`
+	Durability    int               `json:"durability"`     // 剩余耐久
+	MaxDurability int               `json:"max_durability"` // 单件装备的耐久
+	Equipped      bool              `json:"equipped"`
+	Effects       []*ModifierEffect `json:"effects"`
+}
+
+// UserGearResponse 用户装备
+type UserGearResponse struct {
+	UserID   string            `json:"user_id"`
+	Gear     []*UserGear       `json:"gear"`
+	Equipped map[string]string `json:"equipped"` // 栏位 -> 装备ID
+}
+
+// GearPurchaseRequest 购买装备请求（再次购买已持有的装备时叠加耐久）
+type GearPurchaseRequest struct {
+	UserID  string `json:"user_id" binding:"required"`
+	GearID  string `json:"gear_id" binding:"required"`
+	TraceID string `json:"trace_id,omitempty"`
+}
+
+// GearPurchaseResponse 购买装备响应
+type GearPurchaseResponse struct {
+	Entry *LedgerEntry `json:"entry"`
+	Gear  *UserGear    `json:"gear"`
+}
+
+// GearEquipRequest 装备请求
+type GearEquipRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+	GearID string `json:"gear_id" binding:"required"`
+}
+
+// GearUnequipRequest 卸下装备请求
+type GearUnequipRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+	Slot   string `json:"slot" binding:"required"`
+}
+
+// AppliedGear 本次抽奖生效的装备
+type AppliedGear struct {
+	GearID         string `json:"gear_id"`
+	Name           string `json:"name"`
+	Slot           string `json:"slot"`
+	Draws          int    `json:"draws"`            // 生效的抽奖次数（耐久不足时只作用于前几次）
+	DurabilityLeft int    `json:"durability_left"`  // 抽奖后的剩余耐久
+	Broken         bool   `json:"broken,omitempty"` // 耐久耗尽，已自动卸下
+}
@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+// InventoryItem 背包中的一种鱼
+type InventoryItem struct {
+	ItemID        string     `json:"item_id"`
+	ItemName      string     `json:"item_name"`
+	Description   string     `json:"description"`
+	Points        int        `json:"points"`
+	ImageURL      string     `json:"image_url"`
+	IsUserFish    bool       `json:"is_user_fish"`
+	Count         int        `json:"count"`                     // 当前持有数量
+	TotalCaught   int        `json:"total_caught"`              // 累计钓到数量
+	FirstCaughtAt *time.Time `json:"first_caught_at,omitempty"` // 仅通过交易获得时为空
+	LastCaughtAt  *time.Time `json:"last_caught_at,omitempty"`
+}
+
+// InventoryResponse 用户背包
+type InventoryResponse struct {
+	UserID     string           `json:"user_id"`
+	TotalCount int              `json:"total_count"` // 当前持有鱼的总数
+	Items      []*InventoryItem `json:"items"`       // 按最近钓到时间倒序
+}
+
+// FishdexEntry 图鉴中的一种鱼，未发现的鱼隐藏名称、描述和图片
+type FishdexEntry struct {
+	ItemID        string     `json:"item_id"`
+	Discovered    bool       `json:"discovered"`
+	ItemName      string     `json:"item_name"`
+	Description   string     `json:"description,omitempty"`
+	ImageURL      string     `json:"image_url,omitempty"`
+	Points        int        `json:"points,omitempty"`
+	IsUserFish    bool       `json:"is_user_fish"`
+	TotalCaught   int        `json:"total_caught"`
+	FirstCaughtAt *time.Time `json:"first_caught_at,omitempty"`
+}
+
+// FishdexResponse 用户图鉴
+type FishdexResponse struct {
+	UserID            string          `json:"user_id"`
+	TotalSpecies      int             `json:"total_species"`      // 奖池中可收集的鱼类数量（不含空军）
+	DiscoveredSpecies int             `json:"discovered_species"` // 已发现的鱼类数量
+	CompletionPercent float64         `json:"completion_percent"` // 完成度（0-100）
+	Species           []*FishdexEntry `json:"species"`
+}
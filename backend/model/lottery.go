@@ -10,13 +10,30 @@ type LotteryPool struct {
 
 // LotteryItem 奖品配置
 type LotteryItem struct {
-	ID          string `json:"id"`              // 奖品UUID
-	Name        string `json:"name"`            // 奖品名称
-	Description string `json:"description"`     // 奖品描述
-	Points      int    `json:"points"`          // 积分奖励
-	IsUserFish  bool   `json:"is_user_fish"`    // 是否为用户添加的鱼
-	WxID        string `json:"wx_id,omitempty"` // 微信ID（仅用户添加的鱼有值）
-	ImageURL    string `json:"image_url"`       // 图片URL
+	ID          string            `json:"id"`              // 奖品UUID
+	Name        string            `json:"name"`            // 奖品名称
+	Description string            `json:"description"`     // 奖品描述
+	Points      int               `json:"points"`          // 积分奖励
+	IsUserFish  bool              `json:"is_user_fish"`    // 是否为用户添加的鱼
+	WxID        string            `json:"wx_id,omitempty"` // 微信ID（仅用户添加的鱼有值）
+	ImageURL    string            `json:"image_url"`       // 图片URL
+	Tags        []string          `json:"tags,omitempty"`  // 标签（用于修正、活动等按标签匹配）
+	Size        *SizeDistribution `json:"size,omitempty"`  // 体长/体重分布（为空时不生成尺寸）
+}
+
+// SizeRange 截断正态分布参数
+type SizeRange struct {
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stddev"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+}
+
+// SizeDistribution 每次钓到时体长、体重的分布。体长与体重使用同一个随机偏移，越长越重
+type SizeDistribution struct {
+	LengthCM    SizeRange `json:"length_cm"`
+	WeightKG    SizeRange `json:"weight_kg"`
+	ScalePoints bool      `json:"scale_points,omitempty"` // 积分是否按 体重/平均体重 缩放
 }
 
 // AddFishRequest 添加新鱼请求
@@ -25,6 +42,7 @@ type AddFishRequest struct {
 	Description string `json:"description" binding:"required"` // 鱼的描述
 	WxID        string `json:"wx_id" binding:"required"`       // 微信ID
 	ImageName   string `json:"image_name,omitempty"`           // 指定的图片名称（可选，如fish_1, fish_2等）
+	Spot        string `json:"spot,omitempty"`                 // 添加到的钓点（可选，默认钓点）
 }
 
 // AddFishResponse 添加新鱼响应
@@ -37,44 +55,126 @@ type AddFishResponse struct {
 
 // PoolInfoResponse 奖池信息响应
 type PoolInfoResponse struct {
+	Spot        string                  `json:"spot"`         // 钓点
 	TotalItems  int                     `json:"total_items"`  // 总鱼类数量
 	Items       map[string]*LotteryItem `json:"items"`        // 所有鱼类信息
 	Weights     map[string]int          `json:"weights"`      // 权重分布
 	TotalWeight int                     `json:"total_weight"` // 总权重（应该是1000000）
+	Strategy    string                  `json:"strategy"`     // 奖池默认抽奖策略
+}
+
+// SetPoolStrategyRequest 设置奖池默认策略请求
+type SetPoolStrategyRequest struct {
+	Strategy string `json:"strategy" binding:"required"`
+	Spot     string `json:"spot,omitempty"` // 钓点（可选，默认钓点）
+}
+
+// StrategyListResponse 可用策略列表响应
+type StrategyListResponse struct {
+	Strategies []string `json:"strategies"`
 }
 
 // LotteryDrawRequest 抽奖请求
 type LotteryDrawRequest struct {
-	UserID  string                 `json:"user_id" binding:"required"`
-	Context map[string]interface{} `json:"context,omitempty"`
-	TraceID string                 `json:"trace_id,omitempty"`
+	UserID   string                 `json:"user_id" binding:"required"`
+	Spot     string                 `json:"spot,omitempty"`     // 钓点（可选，默认钓点）
+	Strategy string                 `json:"strategy,omitempty"` // 指定抽奖策略（可选，默认使用奖池策略）
+	Context  map[string]interface{} `json:"context,omitempty"`  // 客户端参数（如 client_seed），不参与抽奖修正
+	TraceID  string                 `json:"trace_id,omitempty"`
 }
 
 // LotteryDrawResponse 抽奖响应
 type LotteryDrawResponse struct {
-	DrawID    string        `json:"draw_id"`
-	UserID    string        `json:"user_id"`
-	Result    LotteryResult `json:"result"`
-	CreatedAt time.Time     `json:"created_at"`
+	DrawID    string            `json:"draw_id"`
+	UserID    string            `json:"user_id"`
+	Spot      string            `json:"spot"`
+	Result    LotteryResult     `json:"result"`
+	Modifiers []AppliedModifier `json:"modifiers,omitempty"` // 本次生效的Context修正
+	Gear      []AppliedGear     `json:"gear,omitempty"`      // 本次生效的装备
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// LotteryBatchDrawRequest 连抽请求
+type LotteryBatchDrawRequest struct {
+	UserID    string                 `json:"user_id" binding:"required"`
+	Count     int                    `json:"count" binding:"required,min=1,max=10"`
+	Spot      string                 `json:"spot,omitempty"`      // 钓点（可选，默认钓点）
+	Guarantee bool                   `json:"guarantee,omitempty"` // 是否保底：整批至少一次非空军
+	Strategy  string                 `json:"strategy,omitempty"`  // 指定抽奖策略（可选，默认使用奖池策略）
+	Context   map[string]interface{} `json:"context,omitempty"`   // 客户端参数（如 client_seed），不参与抽奖修正
+	TraceID   string                 `json:"trace_id,omitempty"`
+}
+
+// LotteryBatchDrawResponse 连抽响应
+type LotteryBatchDrawResponse struct {
+	DrawID      string            `json:"draw_id"`
+	UserID      string            `json:"user_id"`
+	Spot        string            `json:"spot"`
+	Results     []LotteryResult   `json:"results"`
+	TotalPoints int               `json:"total_points"`
+	Modifiers   []AppliedModifier `json:"modifiers,omitempty"` // 本次生效的Context修正
+	Gear        []AppliedGear     `json:"gear,omitempty"`      // 本次生效的装备
+	CreatedAt   time.Time         `json:"created_at"`
 }
 
 // LotteryResult 抽奖结果
 type LotteryResult struct {
-	Win         bool   `json:"win"`
-	ItemID      string `json:"item_id"`
-	ItemName    string `json:"item_name"`
-	Description string `json:"description"`
-	Points      int    `json:"points"`
-	WxID        string `json:"wx_id,omitempty"` // 微信ID（仅用户添加的鱼有值）
-	ImageURL    string `json:"image_url"`       // 图片URL
+	DrawID      string     `json:"draw_id,omitempty"`
+	Win         bool       `json:"win"`
+	ItemID      string     `json:"item_id"`
+	ItemName    string     `json:"item_name"`
+	Description string     `json:"description"`
+	Points      int        `json:"points"`
+	WxID        string     `json:"wx_id,omitempty"` // 微信ID（仅用户添加的鱼有值）
+	ImageURL    string     `json:"image_url"`       // 图片URL
+	Fair        *FairProof `json:"fair,omitempty"`  // 可验证模式下的抽奖证明
+	LengthCM    float64    `json:"length_cm,omitempty"`
+	WeightKG    float64    `json:"weight_kg,omitempty"`
+	Records     []string   `json:"records_broken,omitempty"` // 本次打破的纪录：personal / global
 }
 
 // LotteryRecord 抽奖记录（存储在Redis中）
 type LotteryRecord struct {
+	DrawID      string    `json:"draw_id,omitempty"`
+	UserID      string    `json:"user_id,omitempty"`
+	TraceID     string    `json:"trace_id,omitempty"`
+	Spot        string    `json:"spot,omitempty"`
 	ItemID      string    `json:"item_id"`
 	ItemName    string    `json:"item_name"`
 	Description string    `json:"description"`
-	Points      int       `json:"points"`
+	WxID        string    `json:"wx_id,omitempty"`
+	Points      int       `json:"points"` // 实际获得的积分
+	LengthCM    float64   `json:"length_cm,omitempty"`
+	WeightKG    float64   `json:"weight_kg,omitempty"`
 	Strategy    string    `json:"strategy"`
 	Timestamp   time.Time `json:"timestamp"`
 }
+
+// DrawHistoryQuery 抽奖历史查询条件
+type DrawHistoryQuery struct {
+	UserID string
+	Limit  int
+	Cursor string     // 上一页返回的next_cursor
+	From   *time.Time // 起始时间（含）
+	To     *time.Time // 结束时间（含）
+	ItemID string     // 按鱼类筛选
+	Result string     // win: 仅中奖 loss: 仅未中奖
+}
+
+// DrawHistoryResponse 抽奖历史分页响应
+type DrawHistoryResponse struct {
+	UserID     string           `json:"user_id"`
+	Records    []*LotteryRecord `json:"records"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+	HasMore    bool             `json:"has_more"`
+}
+
+// PityStateResponse 用户保底状态
+type PityStateResponse struct {
+	UserID              string  `json:"user_id"`
+	Streak              int     `json:"streak"`                // 连续未出稀有鱼/用户鱼的次数
+	SoftThreshold       int     `json:"soft_threshold"`        // 开始提升权重的次数
+	HardThreshold       int     `json:"hard_threshold"`        // 必出的次数
+	DrawsUntilGuarantee int     `json:"draws_until_guarantee"` // 距离必出还需的次数
+	BoostMultiplier     float64 `json:"boost_multiplier"`      // 下一次抽奖的保底目标权重倍数
+}
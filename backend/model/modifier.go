@@ -0,0 +1,47 @@
+package model
+
+// DrawModifierConfig 抽奖修正配置（configs/draw_modifiers.json）
+type DrawModifierConfig struct {
+	Modifiers  []*DrawModifier `json:"modifiers"`
+	TimesOfDay []*TimeOfDay    `json:"times_of_day"` // 按游戏时区划分的时段，为空时使用默认划分
+}
+
+// TimeOfDay 一天中的时段 [start_hour, end_hour)，end_hour 不大于 start_hour 时跨越午夜
+type TimeOfDay struct {
+	Value     string `json:"value"` // 如 dawn、day、dusk、night
+	StartHour int    `json:"start_hour"`
+	EndHour   int    `json:"end_hour"`
+}
+
+// DrawModifier 根据服务端生成的抽奖Context触发的权重修正
+type DrawModifier struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	ContextKey string            `json:"context_key"` // spot（解析后的钓点）或 time_of_day（按游戏时区计算的时段）
+	Values     []string          `json:"values"`      // 匹配的取值，为空时只要携带该key即生效
+	Effects    []*ModifierEffect `json:"effects"`
+}
+
+// ModifierEffect 对指定鱼类或标签的权重修正：weight * multiply + add
+type ModifierEffect struct {
+	ItemID   string   `json:"item_id,omitempty"`  // 目标鱼类ID
+	Tag      string   `json:"tag,omitempty"`      // 目标标签（与item_id二选一）
+	Multiply *float64 `json:"multiply,omitempty"` // 未设置时为1，设置为0时该鱼类不会出现
+	Add      int      `json:"add,omitempty"`
+}
+
+// AppliedModifier 本次抽奖生效的修正
+type AppliedModifier struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	ContextKey string `json:"context_key"`
+	Value      string `json:"value"`
+}
+
+// DrawBoost 作用于用户下一次抽奖请求的权重加成（如签到奖励），生效后即被消耗
+type DrawBoost struct {
+	ID      string            `json:"id"`
+	Name    string            `json:"name"`
+	Source  string            `json:"source,omitempty"` // 加成来源，如 checkin
+	Effects []*ModifierEffect `json:"effects"`
+}
@@ -0,0 +1,50 @@
+package model
+
+import "time"
+
+// 限时活动状态（查询时按当前时间计算）
+const (
+	PoolEventStatusScheduled = "scheduled"
+	PoolEventStatusActive    = "active"
+	PoolEventStatusEnded     = "ended"
+	PoolEventStatusCancelled = "cancelled"
+)
+
+// PoolEvent 限时权重活动：活动期间抽奖时在奖池存储权重上叠加修正，并重新归一到总权重
+type PoolEvent struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Spot        string            `json:"spot,omitempty"` // 作用的钓点，为空时作用于所有钓点
+	StartAt     time.Time         `json:"start_at"`
+	EndAt       time.Time         `json:"end_at"`
+	Effects     []*ModifierEffect `json:"effects"`
+	Status      string            `json:"status,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	CancelledAt *time.Time        `json:"cancelled_at,omitempty"`
+}
+
+// CreatePoolEventRequest 创建限时活动请求
+type CreatePoolEventRequest struct {
+	Name    string            `json:"name" binding:"required"`
+	Spot    string            `json:"spot,omitempty"`
+	StartAt time.Time         `json:"start_at" binding:"required"`
+	EndAt   time.Time         `json:"end_at" binding:"required"`
+	Effects []*ModifierEffect `json:"effects" binding:"required,min=1"`
+}
+
+// PoolEventListResponse 限时活动列表（按开始时间排列）
+type PoolEventListResponse struct {
+	Events []*PoolEvent `json:"events"`
+}
+
+// EffectivePoolResponse 指定时间点的有效奖池：当前存储权重叠加该时间点生效的限时活动
+type EffectivePoolResponse struct {
+	Spot          string                  `json:"spot"`
+	At            time.Time               `json:"at"`
+	Items         map[string]*LotteryItem `json:"items"`
+	StoredWeights map[string]int          `json:"stored_weights"` // 奖池存储权重
+	Weights       map[string]int          `json:"weights"`        // 叠加活动并归一后的权重
+	TotalWeight   int                     `json:"total_weight"`
+	Strategy      string                  `json:"strategy"`
+	Events        []*PoolEvent            `json:"events"` // 该时间点生效的活动
+}
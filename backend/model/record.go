@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// 纪录类型
+const (
+	RecordPersonal = "personal"
+	RecordGlobal   = "global"
+)
+
+// CatchRecord 某种鱼的最大渔获纪录（按体重比较）
+type CatchRecord struct {
+	ItemID   string    `json:"item_id"`
+	ItemName string    `json:"item_name"`
+	UserID   string    `json:"user_id"`
+	DrawID   string    `json:"draw_id"`
+	LengthCM float64   `json:"length_cm"`
+	WeightKG float64   `json:"weight_kg"`
+	Points   int       `json:"points"`
+	CaughtAt time.Time `json:"caught_at"`
+}
+
+// CatchRecordsResponse 纪录列表响应
+type CatchRecordsResponse struct {
+	UserID  string         `json:"user_id,omitempty"` // 个人纪录时为用户ID
+	Records []*CatchRecord `json:"records"`           // 按鱼类ID排序
+}
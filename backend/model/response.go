@@ -2,8 +2,9 @@ package model
 
 // APIResponse 统一API响应格式
 type APIResponse struct {
-	Code int         `json:"code"`
-	Data interface{} `json:"data,omitempty"`
+	Code    int         `json:"code"`
+	Message string      `json:"message,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
 }
 
 // NewSuccessResponse 创建成功响应
@@ -20,3 +21,12 @@ func NewErrorResponse() *APIResponse {
 		Code: 500,
 	}
 }
+
+// NewBusinessErrorResponse 创建带错误码和说明的业务错误响应
+func NewBusinessErrorResponse(code int, message string, data interface{}) *APIResponse {
+	return &APIResponse{
+		Code:    code,
+		Message: message,
+		Data:    data,
+	}
+}
@@ -0,0 +1,26 @@
+package model
+
+// RoyaltyEarningsResponse 用户鱼创建者的版税收益
+type RoyaltyEarningsResponse struct {
+	UserID       string                 `json:"user_id"`
+	TotalPoints  int64                  `json:"total_points"`  // 累计版税积分
+	TotalCatches int64                  `json:"total_catches"` // 累计产生版税的被钓次数
+	Today        *RoyaltyDailyUsage     `json:"today"`
+	Items        []*RoyaltyItemEarnings `json:"items"` // 按累计版税积分倒序
+}
+
+// RoyaltyDailyUsage 当日版税额度使用情况
+type RoyaltyDailyUsage struct {
+	Date      string `json:"date"` // YYYYMMDD
+	Points    int    `json:"points"`
+	Cap       int    `json:"cap,omitempty"`       // 每日上限，0为不限
+	Remaining int    `json:"remaining,omitempty"` // 当日剩余额度（不限时为0）
+}
+
+// RoyaltyItemEarnings 单条用户鱼的版税收益
+type RoyaltyItemEarnings struct {
+	ItemID   string `json:"item_id"`
+	ItemName string `json:"item_name,omitempty"` // 已不在奖池中的鱼为空
+	Points   int64  `json:"points"`
+	Catches  int64  `json:"catches"`
+}
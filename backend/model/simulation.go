@@ -0,0 +1,61 @@
+package model
+
+// SimulationReport 离线模拟报告
+type SimulationReport struct {
+	Players              int                    `json:"players"`
+	DrawsPerPlayer       int                    `json:"draws_per_player"`
+	TotalDraws           int64                  `json:"total_draws"`
+	Strategy             string                 `json:"strategy"`
+	BatchSize            int                    `json:"batch_size"`
+	Guarantee            bool                   `json:"guarantee"`
+	ExpectedValuePerDraw float64                `json:"expected_value_per_draw"` // 按配置概率计算的每抽期望积分（不含保底）
+	ObservedValuePerDraw float64                `json:"observed_value_per_draw"` // 模拟得到的每抽平均积分
+	Items                []*SimulatedItemStats  `json:"items"`
+	Points               *DistributionSummary   `json:"points"`      // 玩家最终积分分布
+	FirstRare            *FirstRareSummary      `json:"first_rare"`  // 首次钓到稀有鱼所需抽数
+	Leaderboard          *LeaderboardProjection `json:"leaderboard"` // 榜单分布预测
+}
+
+// SimulatedItemStats 单个鱼类的模拟结果
+type SimulatedItemStats struct {
+	ItemID                string  `json:"item_id"`
+	ItemName              string  `json:"item_name"`
+	Points                int     `json:"points"`
+	ConfiguredProbability float64 `json:"configured_probability"`
+	Count                 int64   `json:"count"`
+	ObservedFrequency     float64 `json:"observed_frequency"`
+}
+
+// DistributionSummary 数值分布摘要
+type DistributionSummary struct {
+	Min    float64 `json:"min"`
+	P10    float64 `json:"p10"`
+	P25    float64 `json:"p25"`
+	P50    float64 `json:"p50"`
+	P75    float64 `json:"p75"`
+	P90    float64 `json:"p90"`
+	P99    float64 `json:"p99"`
+	Max    float64 `json:"max"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"std_dev"`
+}
+
+// FirstRareSummary 首次钓到稀有鱼所需抽数分布
+type FirstRareSummary struct {
+	Tag          string               `json:"tag"`           // 视为稀有的鱼类标签
+	Reached      int                  `json:"reached"`       // 模拟期间钓到过的玩家数
+	NeverReached int                  `json:"never_reached"` // 模拟期间未钓到的玩家数
+	Draws        *DistributionSummary `json:"draws,omitempty"`
+}
+
+// LeaderboardProjection 榜单分布预测
+type LeaderboardProjection struct {
+	Top1             float64 `json:"top1"`
+	Top10Cutoff      float64 `json:"top10_cutoff"` // 第10名的积分
+	Median           float64 `json:"median"`
+	Bottom           float64 `json:"bottom"`
+	Top1MedianRatio  float64 `json:"top1_median_ratio"`
+	Top10Share       float64 `json:"top10_share"` // 前10名积分占总积分的比例
+	GiniCoefficient  float64 `json:"gini_coefficient"`
+	InterDecileRange float64 `json:"inter_decile_range"` // P90 - P10
+}
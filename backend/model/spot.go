@@ -0,0 +1,40 @@
+package model
+
+// SpotConfig 钓点定义（configs/spots.json）
+type SpotConfig struct {
+	DefaultSpot string            `json:"default_spot"` // 未指定钓点时使用的钓点，为空时为第一个钓点
+	Spots       []*SpotDefinition `json:"spots"`
+}
+
+// SpotDefinition 钓点定义，每个钓点有独立的奖池。初始化时仅写入尚不存在的钓点奖池
+type SpotDefinition struct {
+	ID          string           `json:"id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Strategy    string           `json:"strategy,omitempty"` // 奖池默认抽奖策略
+	Items       []*LotteryItem   `json:"items"`              // 系统鱼类只填id时使用内置定义
+	Weights     map[string]int   `json:"weights"`            // 初始权重，总和必须为1000000
+	BorrowOrder []SpotBorrowRule `json:"borrow_order"`       // 添加用户鱼时借用权重的顺序及各鱼类的权重下限
+}
+
+// SpotBorrowRule 借用权重规则
+type SpotBorrowRule struct {
+	ItemID    string `json:"item_id"`
+	MinWeight int    `json:"min_weight"` // 借用后不低于该权重
+}
+
+// SpotInfo 钓点信息
+type SpotInfo struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	TotalItems  int    `json:"total_items"`
+	Strategy    string `json:"strategy"`
+	Default     bool   `json:"default"`
+}
+
+// SpotListResponse 钓点列表
+type SpotListResponse struct {
+	DefaultSpot string      `json:"default_spot"`
+	Spots       []*SpotInfo `json:"spots"`
+}
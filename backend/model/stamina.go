@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// StaminaStateResponse 用户体力状态
+type StaminaStateResponse struct {
+	UserID         string     `json:"user_id"`
+	Stamina        int        `json:"stamina"`                  // 当前体力
+	MaxStamina     int        `json:"max_stamina"`              // 体力上限
+	DrawCost       int        `json:"draw_cost"`                // 每次抽奖消耗的体力
+	NextRefillAt   *time.Time `json:"next_refill_at,omitempty"` // 下一点体力恢复时间（体力已满时为空）
+	FullAt         *time.Time `json:"full_at,omitempty"`        // 体力回满时间（体力已满时为空）
+	DailyQuota     int        `json:"daily_quota"`              // 每日抽奖次数上限
+	QuotaUsed      int        `json:"quota_used"`               // 今日已抽奖次数
+	QuotaRemaining int        `json:"quota_remaining"`          // 今日剩余抽奖次数
+	QuotaResetAt   time.Time  `json:"quota_reset_at"`           // 每日次数重置时间
+	BonusDraws     int        `json:"bonus_draws,omitempty"`    // 额外抽奖次数（签到等奖励获得，不消耗体力和每日次数）
+}
+
+// OutOfStaminaResponse 体力或每日次数不足的错误详情
+type OutOfStaminaResponse struct {
+	Reason       string                `json:"reason"`         // stamina: 体力不足 quota: 今日次数已用完
+	NextRefillAt time.Time             `json:"next_refill_at"` // 可以再次抽奖的时间
+	State        *StaminaStateResponse `json:"state"`
+}
@@ -0,0 +1,28 @@
+package model
+
+// DrawStatsResponse 实际出鱼频率与配置概率对比
+type DrawStatsResponse struct {
+	Scope            string           `json:"scope"`             // global / daily / user
+	Date             string           `json:"date,omitempty"`    // daily时的日期（YYYYMMDD）
+	UserID           string           `json:"user_id,omitempty"` // user时的用户ID
+	TotalDraws       int64            `json:"total_draws"`
+	AdjustedDraws    int64            `json:"adjusted_draws"`  // 实际生效概率与配置概率不同的抽奖次数（活动、加成、装备、保底和策略的调整）
+	Spots            map[string]int64 `json:"spots,omitempty"` // 各钓点的抽奖次数，未记录钓点的历史抽奖计入默认钓点
+	Items            []*ItemDrawStats `json:"items"`
+	ChiSquare        float64          `json:"chi_square"`         // 卡方拟合优度统计量
+	DegreesOfFreedom int              `json:"degrees_of_freedom"` // 自由度
+	PValue           float64          `json:"p_value"`            // 越小说明实际分布与配置偏离越显著
+}
+
+// ItemDrawStats 单个鱼类的出鱼统计
+type ItemDrawStats struct {
+	ItemID                string  `json:"item_id"`
+	ItemName              string  `json:"item_name"`
+	Observed              int64   `json:"observed"`               // 实际次数
+	ObservedFrequency     float64 `json:"observed_frequency"`     // 实际频率
+	ConfiguredWeight      int     `json:"configured_weight"`      // 当前配置权重（多个钓点时为按抽奖次数混合后的等效权重）
+	ConfiguredProbability float64 `json:"configured_probability"` // 当前配置概率（多个钓点时按抽奖次数混合）
+	EffectiveProbability  float64 `json:"effective_probability"`  // 各次抽奖实际生效概率的平均值（含活动、加成、装备、保底和策略的调整，用户范围不含）
+	Expected              float64 `json:"expected"`               // 期望次数：配置概率乘以抽奖次数，加上各次抽奖实际生效概率与配置概率之差的累计
+	ChiSquare             float64 `json:"chi_square"`             // 该鱼类对卡方统计量的贡献
+}
@@ -0,0 +1,95 @@
+package model
+
+import "time"
+
+// 比赛状态
+const (
+	TournamentStatusRegistration = "registration" // 报名中
+	TournamentStatusRunning      = "running"      // 进行中
+	TournamentStatusSettled      = "settled"      // 已结算
+	TournamentStatusCancelled    = "cancelled"    // 已取消
+)
+
+// TournamentPrizeConfig 比赛奖励表配置（configs/tournament_prizes.json）
+type TournamentPrizeConfig struct {
+	Tables []*TournamentPrizeTable `json:"tables"`
+}
+
+// TournamentPrizeTable 奖励表，按名次区间发放奖励
+type TournamentPrizeTable struct {
+	ID     string             `json:"id"`
+	Name   string             `json:"name"`
+	Prizes []*TournamentPrize `json:"prizes"`
+}
+
+// TournamentPrize 名次区间 [rank_from, rank_to] 的奖励（分组比赛时为组内名次）
+type TournamentPrize struct {
+	RankFrom int   `json:"rank_from"`
+	RankTo   int   `json:"rank_to"`
+	Coins    int64 `json:"coins,omitempty"`  // 金币
+	Points   int   `json:"points,omitempty"` // 全局榜单积分
+}
+
+// Tournament 钓鱼比赛。报名窗口内报名，开赛后到结束时间前的渔获积分计入比赛榜单（与全局榜单分开）
+type Tournament struct {
+	ID            string             `json:"id"`
+	Name          string             `json:"name"`
+	Description   string             `json:"description,omitempty"`
+	Spot          string             `json:"spot,omitempty"` // 只统计该钓点的渔获，为空时统计所有钓点
+	EntryOpensAt  time.Time          `json:"entry_opens_at"`
+	EntryClosesAt time.Time          `json:"entry_closes_at"`
+	EndAt         time.Time          `json:"end_at"`                 // 比赛结束时间，之后的渔获不再计分
+	MaxPlayers    int                `json:"max_players,omitempty"`  // 报名人数上限，0为不限
+	BracketSize   int                `json:"bracket_size,omitempty"` // 开赛时按报名顺序每N人分为一组，各组单独排名和发奖，0为不分组
+	PrizeTable    string             `json:"prize_table,omitempty"`
+	Prizes        []*TournamentPrize `json:"prizes,omitempty"` // 创建时从奖励表复制
+	Status        string             `json:"status"`
+	PlayerCount   int                `json:"player_count"`
+	BracketCount  int                `json:"bracket_count,omitempty"`
+	CreatedAt     time.Time          `json:"created_at"`
+	StartedAt     *time.Time         `json:"started_at,omitempty"`
+	EndedAt       *time.Time         `json:"ended_at,omitempty"` // 结算或取消时间
+}
+
+// CreateTournamentRequest 创建比赛请求
+type CreateTournamentRequest struct {
+	Name          string     `json:"name" binding:"required"`
+	Description   string     `json:"description,omitempty"`
+	Spot          string     `json:"spot,omitempty"`
+	EntryOpensAt  *time.Time `json:"entry_opens_at,omitempty"` // 默认立即开放报名
+	EntryClosesAt time.Time  `json:"entry_closes_at" binding:"required"`
+	EndAt         time.Time  `json:"end_at" binding:"required"`
+	MaxPlayers    int        `json:"max_players" binding:"min=0"`
+	BracketSize   int        `json:"bracket_size" binding:"min=0"`
+	PrizeTable    string     `json:"prize_table,omitempty"` // 为空时不发奖
+}
+
+// TournamentEntryRequest 报名请求
+type TournamentEntryRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+}
+
+// TournamentListResponse 比赛列表（按创建时间倒序）
+type TournamentListResponse struct {
+	Tournaments []*Tournament `json:"tournaments"`
+}
+
+// TournamentStanding 比赛排名
+type TournamentStanding struct {
+	Rank   int              `json:"rank"`
+	UserID string           `json:"user_id"`
+	Score  int              `json:"score"`
+	Prize  *TournamentPrize `json:"prize,omitempty"` // 已结算或按当前名次可获得的奖励
+}
+
+// TournamentBracket 一个分组的排名
+type TournamentBracket struct {
+	Bracket   int                   `json:"bracket"`
+	Standings []*TournamentStanding `json:"standings"`
+}
+
+// TournamentLeaderboardResponse 比赛榜单。进行中为实时排名，结算后为冻结的最终排名
+type TournamentLeaderboardResponse struct {
+	Tournament *Tournament          `json:"tournament"`
+	Brackets   []*TournamentBracket `json:"brackets"`
+}
@@ -0,0 +1,57 @@
+package model
+
+import "time"
+
+// 交易状态
+const (
+	TradeStatusPending   = "pending"
+	TradeStatusAccepted  = "accepted"
+	TradeStatusRejected  = "rejected"
+	TradeStatusCancelled = "cancelled"
+	TradeStatusExpired   = "expired"
+)
+
+// TradeItem 交易中的一种鱼
+type TradeItem struct {
+	ItemID   string `json:"item_id" binding:"required"`
+	Quantity int    `json:"quantity" binding:"required,min=1"`
+}
+
+// TradeOffer 交易报价。发起方的鱼和金币在报价期间托管，成交、拒绝、取消或过期时结算
+type TradeOffer struct {
+	ID           string      `json:"id"`
+	FromUserID   string      `json:"from_user_id"`
+	ToUserID     string      `json:"to_user_id"`
+	OfferItems   []TradeItem `json:"offer_items"`   // 发起方给出的鱼
+	OfferCoins   int64       `json:"offer_coins"`   // 发起方给出的金币
+	RequestItems []TradeItem `json:"request_items"` // 向对方索要的鱼
+	RequestCoins int64       `json:"request_coins"` // 向对方索要的金币
+	Status       string      `json:"status"`
+	CreatedAt    time.Time   `json:"created_at"`
+	ExpiresAt    time.Time   `json:"expires_at"`
+	ResolvedAt   *time.Time  `json:"resolved_at,omitempty"`
+}
+
+// CreateTradeRequest 发起交易请求
+type CreateTradeRequest struct {
+	UserID       string      `json:"user_id" binding:"required"`
+	ToUserID     string      `json:"to_user_id" binding:"required"`
+	OfferItems   []TradeItem `json:"offer_items" binding:"dive"`
+	OfferCoins   int64       `json:"offer_coins" binding:"min=0"`
+	RequestItems []TradeItem `json:"request_items" binding:"dive"`
+	RequestCoins int64       `json:"request_coins" binding:"min=0"`
+	TraceID      string      `json:"trace_id,omitempty"`
+}
+
+// TradeActionRequest 接受、拒绝或取消交易请求
+type TradeActionRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+}
+
+// TradeHistoryResponse 用户交易记录（按发起时间倒序）
+type TradeHistoryResponse struct {
+	UserID     string        `json:"user_id"`
+	Trades     []*TradeOffer `json:"trades"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+	HasMore    bool          `json:"has_more"`
+}
@@ -0,0 +1,62 @@
+package model
+
+import "time"
+
+// 账本交易类型
+const (
+	LedgerTypeSell    = "sell"    // 卖鱼获得金币
+	LedgerTypeRelease = "release" // 放生获得榜单积分
+
+	LedgerTypeTradeEscrow = "trade_escrow" // 发起交易时托管金币
+	LedgerTypeTradeRefund = "trade_refund" // 交易取消、拒绝或过期时退还托管金币
+	LedgerTypeTrade       = "trade"        // 交易成交
+
+	LedgerTypeGearPurchase = "gear_purchase" // 购买装备
+
+	LedgerTypeTournamentPrize = "tournament_prize" // 比赛奖励
+
+	LedgerTypeRoyalty = "royalty" // 创建的用户鱼被他人钓到获得的版税积分
+)
+
+// LedgerEntry 钱包账本中的一条交易
+type LedgerEntry struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Type      string    `json:"type"`
+	ItemID    string    `json:"item_id,omitempty"`
+	Quantity  int       `json:"quantity,omitempty"`
+	Coins     int64     `json:"coins"`         // 金币变动（正为收入，负为支出）
+	Points    int       `json:"points"`        // 榜单积分变动
+	Balance   int64     `json:"balance"`       // 交易后的金币余额
+	Ref       string    `json:"ref,omitempty"` // 关联ID（抽奖ID、交易ID等）
+	TraceID   string    `json:"trace_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WalletResponse 用户钱包
+type WalletResponse struct {
+	UserID string `json:"user_id"`
+	Coins  int64  `json:"coins"`
+}
+
+// LedgerResponse 账本分页响应（按时间倒序）
+type LedgerResponse struct {
+	UserID     string         `json:"user_id"`
+	Entries    []*LedgerEntry `json:"entries"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	HasMore    bool           `json:"has_more"`
+}
+
+// FishTransactionRequest 卖鱼或放生请求
+type FishTransactionRequest struct {
+	UserID   string `json:"user_id" binding:"required"`
+	ItemID   string `json:"item_id" binding:"required"`
+	Quantity int    `json:"quantity" binding:"required,min=1"`
+	TraceID  string `json:"trace_id,omitempty"`
+}
+
+// FishTransactionResponse 卖鱼或放生响应
+type FishTransactionResponse struct {
+	Entry     *LedgerEntry `json:"entry"`
+	Remaining int          `json:"remaining"` // 背包中该鱼剩余数量
+}
@@ -0,0 +1,448 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"fishing-game/config"
+	"fishing-game/model"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// AchievementsConfigFile 成就配置文件
+const AchievementsConfigFile = "achievements.json"
+
+// 成就类型
+const (
+	AchievementCatchCount = "catch_count" // 累计钓到匹配的鱼达到目标数量
+	AchievementCollection = "collection"  // 钓到过所有匹配的鱼
+	AchievementStreak     = "streak"      // 连续钓到匹配的鱼达到目标次数
+	AchievementScore      = "score"       // 榜单积分达到目标值
+)
+
+// advanceStreakScript 按抽奖顺序推进连续计数，返回本次过程中达到的最高连续次数
+// KEYS[1]: 连续计数hash
+// ARGV: 成对的 (成就ID, 每次抽奖是否匹配的标记串，如 "1101")
+var advanceStreakScript = redis.NewScript(`
+local peaks = {}
+for i = 1, #ARGV, 2 do
+	local streak = tonumber(redis.call('HGET', KEYS[1], ARGV[i]) or '0')
+	local peak = streak
+	local flags = ARGV[i + 1]
+	for j = 1, #flags do
+		if string.sub(flags, j, j) == '1' then
+			streak = streak + 1
+		else
+			streak = 0
+		end
+		if streak > peak then
+			peak = streak
+		end
+	end
+	redis.call('HSET', KEYS[1], ARGV[i], streak)
+	peaks[#peaks + 1] = peak
+end
+return peaks
+`)
+
+// achievementUnlockScript 记录成就解锁时间并发放积分奖励，HSETNX保证每个成就只解锁、奖励一次
+//
+// KEYS[1] 用户已解锁成就 KEYS[2] 全局榜单
+// ARGV[1] 成就ID ARGV[2] 解锁时间（毫秒） ARGV[3] 奖励积分 ARGV[4] 榜单成员
+//
+// 返回 {是否本次解锁, 新的榜单积分}，未发放积分时积分为0
+var achievementUnlockScript = redis.NewScript(`
+if redis.call('HSETNX', KEYS[1], ARGV[1], ARGV[2]) == 0 then
+	return {0, 0}
+end
+local points = tonumber(ARGV[3])
+if points <= 0 then
+	return {1, 0}
+end
+return {1, tonumber(redis.call('ZINCRBY', KEYS[2], points, ARGV[4]))}
+`)
+
+// AchievementService 成就服务，作为抽奖事件和积分变更监听器按配置规则解锁成就
+type AchievementService struct {
+	redisClient      *redis.Client
+	rankingService   *RankingService
+	poolService      *PoolService
+	inventoryService *InventoryService
+	rules            []*model.AchievementRule
+}
+
+// NewAchievementService 创建成就服务，配置文件不存在时不启用任何成就
+func NewAchievementService(rankingService *RankingService, poolService *PoolService, inventoryService *InventoryService) (*AchievementService, error) {
+	var cfg model.AchievementConfig
+	found, err := config.LoadJSON(AchievementsConfigFile, &cfg)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		log.Printf("%s not found, achievements disabled", AchievementsConfigFile)
+	}
+	if err := validateAchievementRules(cfg.Achievements); err != nil {
+		return nil, err
+	}
+
+	return &AchievementService{
+		redisClient:      config.GetRedisClient(),
+		rankingService:   rankingService,
+		poolService:      poolService,
+		inventoryService: inventoryService,
+		rules:            cfg.Achievements,
+	}, nil
+}
+
+// validateAchievementRules 校验成就规则
+func validateAchievementRules(rules []*model.AchievementRule) error {
+	seen := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		if rule.ID == "" {
+			return fmt.Errorf("achievement requires id")
+		}
+		if seen[rule.ID] {
+			return fmt.Errorf("duplicate achievement id %s", rule.ID)
+		}
+		seen[rule.ID] = true
+
+		switch rule.Type {
+		case AchievementCatchCount, AchievementStreak, AchievementScore:
+			if rule.Target <= 0 {
+				return fmt.Errorf("achievement %s: target must be positive", rule.ID)
+			}
+		case AchievementCollection:
+			if rule.Filter == nil {
+				return fmt.Errorf("achievement %s: collection requires filter", rule.ID)
+			}
+		default:
+			return fmt.Errorf("achievement %s: unknown type %q", rule.ID, rule.Type)
+		}
+		if rule.Points < 0 {
+			return fmt.Errorf("achievement %s: points must not be negative", rule.ID)
+		}
+	}
+	return nil
+}
+
+// achievementsKey 用户已解锁成就（成就ID -> 解锁时间毫秒）
+func achievementsKey(userID string) string {
+	return fmt.Sprintf("achievements:%s", userID)
+}
+
+// achievementStreakKey 用户各连续类成就的当前连续次数
+func achievementStreakKey(userID string) string {
+	return fmt.Sprintf("achievements:streak:%s", userID)
+}
+
+// achievementMatches 鱼类是否满足筛选条件。item为空（已不在奖池中）时只能按item_id匹配
+func achievementMatches(filter *model.AchievementFilter, itemID string, item *model.LotteryItem) bool {
+	if filter == nil {
+		return true
+	}
+	if filter.ItemID != "" && filter.ItemID != itemID {
+		return false
+	}
+	if filter.Tag == "" && filter.ExcludeTag == "" && filter.WxID == "" {
+		return true
+	}
+	if item == nil {
+		return false
+	}
+	if filter.Tag != "" && !ItemHasTag(item, filter.Tag) {
+		return false
+	}
+	if filter.ExcludeTag != "" && ItemHasTag(item, filter.ExcludeTag) {
+		return false
+	}
+	return filter.WxID == "" || (item.IsUserFish && item.WxID == filter.WxID)
+}
+
+// achievementProgress 计算成就进度所需的用户数据，按规则类型按需加载
+type achievementProgress struct {
+	inventory map[string]*inventoryEntry
+	items     map[string]*model.LotteryItem
+	streaks   map[string]int
+	score     int
+}
+
+// of 返回规则的当前进度与目标值
+func (p *achievementProgress) of(rule *model.AchievementRule) (int, int) {
+	switch rule.Type {
+	case AchievementCatchCount:
+		caught := 0
+		for itemID, entry := range p.inventory {
+			if achievementMatches(rule.Filter, itemID, p.items[itemID]) {
+				caught += entry.totalCaught
+			}
+		}
+		return caught, rule.Target
+	case AchievementCollection:
+		collected, total := 0, 0
+		for itemID, item := range p.items {
+			if itemID == EmptyFishID || !achievementMatches(rule.Filter, itemID, item) {
+				continue
+			}
+			total++
+			if entry, exists := p.inventory[itemID]; exists && entry.totalCaught > 0 {
+				collected++
+			}
+		}
+		return collected, total
+	case AchievementStreak:
+		return p.streaks[rule.ID], rule.Target
+	case AchievementScore:
+		return p.score, rule.Target
+	}
+	return 0, rule.Target
+}
+
+// reached 规则是否达成。匹配不到任何鱼的收集类成就不会达成
+func (p *achievementProgress) reached(rule *model.AchievementRule) bool {
+	progress, target := p.of(rule)
+	return target > 0 && progress >= target
+}
+
+// loadProgress 加载规则所需的背包、奖池、连续计数与积分。streaks不为空时直接使用，不再读取
+func (as *AchievementService) loadProgress(ctx context.Context, userID string, rules []*model.AchievementRule, streaks map[string]int) (*achievementProgress, error) {
+	var needInventory, needStreaks, needScore bool
+	for _, rule := range rules {
+		switch rule.Type {
+		case AchievementCatchCount, AchievementCollection:
+			needInventory = true
+		case AchievementStreak:
+			needStreaks = streaks == nil
+		case AchievementScore:
+			needScore = true
+		}
+	}
+
+	progress := &achievementProgress{streaks: streaks}
+	if needInventory {
+		entries, err := as.inventoryService.getInventoryEntries(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		items, err := as.poolService.GetItems(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get pool: %w", err)
+		}
+		progress.inventory = entries
+		progress.items = items
+	}
+	if needStreaks {
+		fields, err := as.redisClient.HGetAll(ctx, achievementStreakKey(userID)).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get achievement streaks: %w", err)
+		}
+		progress.streaks = make(map[string]int, len(fields))
+		for id, value := range fields {
+			progress.streaks[id], _ = strconv.Atoi(value)
+		}
+	}
+	if needScore {
+		score, err := as.redisClient.ZScore(ctx, GlobalRankingKey, rankingMember(userID)).Result()
+		if err != nil && err != redis.Nil {
+			return nil, fmt.Errorf("failed to get user score: %w", err)
+		}
+		progress.score = int(score)
+	}
+
+	return progress, nil
+}
+
+// getUnlocked 获取用户已解锁的成就及解锁时间
+func (as *AchievementService) getUnlocked(ctx context.Context, userID string) (map[string]time.Time, error) {
+	fields, err := as.redisClient.HGetAll(ctx, achievementsKey(userID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get achievements: %w", err)
+	}
+
+	unlocked := make(map[string]time.Time, len(fields))
+	for id, value := range fields {
+		ms, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		unlocked[id] = time.UnixMilli(ms)
+	}
+	return unlocked, nil
+}
+
+// pendingRules 尚未解锁且类型在types中的规则（types为空时不限类型）
+func (as *AchievementService) pendingRules(unlocked map[string]time.Time, types ...string) []*model.AchievementRule {
+	pending := make([]*model.AchievementRule, 0, len(as.rules))
+	for _, rule := range as.rules {
+		if _, done := unlocked[rule.ID]; done {
+			continue
+		}
+		if len(types) > 0 {
+			matched := false
+			for _, t := range types {
+				matched = matched || rule.Type == t
+			}
+			if !matched {
+				continue
+			}
+		}
+		pending = append(pending, rule)
+	}
+	return pending
+}
+
+// advanceStreaks 按本次抽奖结果推进未解锁的连续类成就，返回各成就本次达到的最高连续次数
+func (as *AchievementService) advanceStreaks(ctx context.Context, event *DrawEvent, rules []*model.AchievementRule) (map[string]int, error) {
+	args := make([]interface{}, 0)
+	ids := make([]string, 0)
+	for _, rule := range rules {
+		if rule.Type != AchievementStreak {
+			continue
+		}
+		var flags strings.Builder
+		for _, record := range event.Records {
+			if achievementMatches(rule.Filter, record.ItemID, event.Items[record.ItemID]) {
+				flags.WriteByte('1')
+			} else {
+				flags.WriteByte('0')
+			}
+		}
+		args = append(args, rule.ID, flags.String())
+		ids = append(ids, rule.ID)
+	}
+
+	peaks := make(map[string]int, len(ids))
+	if len(ids) == 0 {
+		return peaks, nil
+	}
+
+	result, err := advanceStreakScript.Run(ctx, as.redisClient, []string{achievementStreakKey(event.UserID)}, args...).Int64Slice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to advance achievement streaks: %w", err)
+	}
+	for i, id := range ids {
+		if i < len(result) {
+			peaks[id] = int(result[i])
+		}
+	}
+	return peaks, nil
+}
+
+// OnDraw 推进连续计数并检查所有未解锁的成就
+func (as *AchievementService) OnDraw(ctx context.Context, event *DrawEvent) error {
+	unlocked, err := as.getUnlocked(ctx, event.UserID)
+	if err != nil {
+		return err
+	}
+	rules := as.pendingRules(unlocked)
+	if len(rules) == 0 {
+		return nil
+	}
+
+	streaks, err := as.advanceStreaks(ctx, event, rules)
+	if err != nil {
+		return err
+	}
+	return as.evaluate(ctx, event.UserID, rules, streaks)
+}
+
+// OnScoreChange 积分变更后检查积分类成就
+func (as *AchievementService) OnScoreChange(ctx context.Context, userID string, score int) error {
+	unlocked, err := as.getUnlocked(ctx, userID)
+	if err != nil {
+		return err
+	}
+	rules := as.pendingRules(unlocked, AchievementScore)
+	if len(rules) == 0 {
+		return nil
+	}
+
+	return as.evaluate(ctx, userID, rules, nil)
+}
+
+// evaluate 解锁已达成的规则
+func (as *AchievementService) evaluate(ctx context.Context, userID string, rules []*model.AchievementRule, streaks map[string]int) error {
+	progress, err := as.loadProgress(ctx, userID, rules, streaks)
+	if err != nil {
+		return err
+	}
+
+	for _, rule := range rules {
+		if !progress.reached(rule) {
+			continue
+		}
+		if err := as.unlock(ctx, userID, rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unlock 在同一个脚本中记录解锁时间并发放积分奖励，每个成就只解锁、奖励一次
+func (as *AchievementService) unlock(ctx context.Context, userID string, rule *model.AchievementRule) error {
+	result, err := achievementUnlockScript.Run(ctx, as.redisClient,
+		[]string{achievementsKey(userID), GlobalRankingKey},
+		rule.ID, time.Now().UnixMilli(), rule.Points, rankingMember(userID),
+	).Int64Slice()
+	if err != nil {
+		return fmt.Errorf("failed to unlock achievement: %w", err)
+	}
+	if len(result) != 2 {
+		return fmt.Errorf("unexpected unlock result")
+	}
+	if result[0] == 0 {
+		return nil
+	}
+	log.Printf("User %s unlocked achievement %s", userID, rule.ID)
+
+	if rule.Points > 0 {
+		as.rankingService.notifyScoreListeners(ctx, userID, int(result[1]))
+	}
+	return nil
+}
+
+// GetAchievements 获取用户所有成就的进度与解锁状态（按配置顺序）
+func (as *AchievementService) GetAchievements(ctx context.Context, userID string) (*model.AchievementsResponse, error) {
+	unlocked, err := as.getUnlocked(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	progress, err := as.loadProgress(ctx, userID, as.rules, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &model.AchievementsResponse{
+		UserID:       userID,
+		TotalCount:   len(as.rules),
+		Achievements: make([]*model.UserAchievement, 0, len(as.rules)),
+	}
+	for _, rule := range as.rules {
+		achievement := &model.UserAchievement{
+			ID:          rule.ID,
+			Name:        rule.Name,
+			Description: rule.Description,
+			Type:        rule.Type,
+			Points:      rule.Points,
+		}
+
+		achievement.Progress, achievement.Target = progress.of(rule)
+		if unlockedAt, done := unlocked[rule.ID]; done {
+			// 已解锁的成就不再推进连续计数，收集类的目标也可能随奖池变化，进度统一显示为已完成
+			achievement.Unlocked = true
+			achievement.UnlockedAt = &unlockedAt
+			achievement.Progress = achievement.Target
+			response.UnlockedCount++
+		} else if achievement.Progress > achievement.Target {
+			achievement.Progress = achievement.Target
+		}
+
+		response.Achievements = append(response.Achievements, achievement)
+	}
+
+	return response, nil
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"fishing-game/model"
+)
+
+func TestAchievementUnlockGrantsPointsOnce(t *testing.T) {
+	mr := setupTestRedis(t)
+	ctx := context.Background()
+	idempotencyService := NewIdempotencyService(time.Hour)
+	rankingService := NewRankingService(NewUserService(), idempotencyService)
+	as := &AchievementService{redisClient: rankingService.redisClient, rankingService: rankingService}
+	rule := &model.AchievementRule{ID: "first_catch", Type: AchievementCatchCount, Target: 1, Points: 10}
+
+	for i := 0; i < 2; i++ {
+		if err := as.unlock(ctx, "u1", rule); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if score, _ := mr.ZScore(GlobalRankingKey, rankingMember("u1")); score != 10 {
+		t.Fatalf("score %v, expected 10", score)
+	}
+	if !mr.Exists(achievementsKey("u1")) {
+		t.Fatal("unlock not recorded")
+	}
+
+	// 成就奖励不占用客户端的幂等trace_id
+	_, err := rankingService.IncrementScore(ctx, &model.RankingIncrementRequest{UserID: "u1", Delta: 5, TraceID: "achievement:first_catch"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if score, _ := mr.ZScore(GlobalRankingKey, rankingMember("u1")); score != 15 {
+		t.Fatalf("score %v, expected 15", score)
+	}
+}
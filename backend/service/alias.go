@@ -0,0 +1,109 @@
+package service
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// AliasTable Vose别名表，预处理O(n)后每次采样O(1)。
+// 使用整数运算构建，采样概率与权重严格成正比。
+type AliasTable struct {
+	ids   []string // 按鱼类ID排序
+	prob  []int64  // 第i列保留自身的阈值，范围 [0, total]
+	alias []int    // 第i列未保留时落到的列
+	total int64    // 总权重
+}
+
+// NewAliasTable 根据权重构建别名表，权重为0的鱼类不参与采样
+func NewAliasTable(weights map[string]int) (*AliasTable, error) {
+	ids := make([]string, 0, len(weights))
+	var total int64
+	for fishID, weight := range weights {
+		if weight <= 0 {
+			continue
+		}
+		ids = append(ids, fishID)
+		total += int64(weight)
+	}
+	if total <= 0 {
+		return nil, fmt.Errorf("no valid item found in pool")
+	}
+	sort.Strings(ids)
+
+	n := int64(len(ids))
+	at := &AliasTable{
+		ids:   ids,
+		prob:  make([]int64, n),
+		alias: make([]int, n),
+		total: total,
+	}
+
+	// 每列的缩放权重 = weight * n，平均值为total
+	scaled := make([]int64, n)
+	small := make([]int, 0, n)
+	large := make([]int, 0, n)
+	for i, fishID := range ids {
+		scaled[i] = int64(weights[fishID]) * n
+		if scaled[i] < total {
+			small = append(small, i)
+		} else {
+			large = append(large, i)
+		}
+	}
+
+	for len(small) > 0 && len(large) > 0 {
+		s := small[len(small)-1]
+		small = small[:len(small)-1]
+		l := large[len(large)-1]
+		large = large[:len(large)-1]
+
+		at.prob[s] = scaled[s]
+		at.alias[s] = l
+
+		// 大列补足小列后剩余的部分
+		scaled[l] = scaled[l] + scaled[s] - total
+		if scaled[l] < total {
+			small = append(small, l)
+		} else {
+			large = append(large, l)
+		}
+	}
+
+	// 剩余的列都恰好满列
+	for _, i := range large {
+		at.prob[i] = total
+	}
+	for _, i := range small {
+		at.prob[i] = total
+	}
+
+	return at, nil
+}
+
+// Len 参与采样的鱼类数量
+func (at *AliasTable) Len() int {
+	return len(at.ids)
+}
+
+// Pick 根据 [0, Len()*total) 范围内的随机数选出鱼类ID
+func (at *AliasTable) Pick(roll int64) string {
+	column := roll / at.total
+	if column >= int64(len(at.ids)) {
+		column = int64(len(at.ids)) - 1
+	}
+	if roll%at.total < at.prob[column] {
+		return at.ids[column]
+	}
+	return at.ids[at.alias[column]]
+}
+
+// Sample 随机选出一个鱼类ID
+func (at *AliasTable) Sample() (string, error) {
+	randomBig, err := rand.Int(rand.Reader, big.NewInt(int64(len(at.ids))*at.total))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate random number: %w", err)
+	}
+	return at.Pick(randomBig.Int64()), nil
+}
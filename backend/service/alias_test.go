@@ -0,0 +1,124 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+
+	"fishing-game/model"
+)
+
+// benchmarkPoolSizes 基准测试使用的奖池鱼类数量
+var benchmarkPoolSizes = []int{10, 1000, 100000}
+
+// benchmarkPool 构造size种鱼、权重各不相同的奖池
+func benchmarkPool(size int) (map[string]*model.LotteryItem, map[string]int) {
+	items := make(map[string]*model.LotteryItem, size)
+	weights := make(map[string]int, size)
+	for i := 0; i < size; i++ {
+		id := fmt.Sprintf("fish_%06d", i)
+		items[id] = &model.LotteryItem{ID: id, Name: id}
+		weights[id] = i%100 + 1
+	}
+	return items, weights
+}
+
+// aliasMass 枚举别名表每一列保留自身与落到别名列的所有结果，返回每种鱼类的总质量（单位为 1/(Len()*total)）
+func aliasMass(table *AliasTable) map[string]int64 {
+	mass := make(map[string]int64, table.Len())
+	for column, fishID := range table.ids {
+		mass[fishID] += table.prob[column]
+		if rest := table.total - table.prob[column]; rest > 0 {
+			mass[table.ids[table.alias[column]]] += rest
+		}
+	}
+	return mass
+}
+
+func TestAliasTableMatchesWeightsExactly(t *testing.T) {
+	_, large := benchmarkPool(1000)
+	for name, weights := range map[string]map[string]int{
+		"single":      {"a": 1000000},
+		"all weight":  {"a": 0, "b": 1000000, "c": 0},
+		"zero weight": {"a": 3, "b": 0, "c": 7},
+		"equal":       {"a": 5, "b": 5, "c": 5, "d": 5},
+		"skewed":      {"a": 1, "b": 2, "c": 999997},
+		"uneven":      {"a": 13, "b": 29, "c": 101, "d": 7, "e": 1},
+		"large":       large,
+	} {
+		t.Run(name, func(t *testing.T) {
+			table, err := NewAliasTable(weights)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			n := int64(table.Len())
+			mass := aliasMass(table)
+			for fishID, weight := range weights {
+				if got, want := mass[fishID], int64(weight)*n; got != want {
+					t.Errorf("%s mass %d, expected %d", fishID, got, want)
+				}
+			}
+			for fishID := range mass {
+				if _, exists := weights[fishID]; !exists {
+					t.Errorf("unknown fish %s sampled", fishID)
+				}
+			}
+
+			// 总权重较小时逐个枚举 Pick 的所有随机数
+			if n*table.total > 1000 {
+				return
+			}
+			picks := make(map[string]int64, len(weights))
+			for roll := int64(0); roll < n*table.total; roll++ {
+				picks[table.Pick(roll)]++
+			}
+			for fishID, weight := range weights {
+				if got, want := picks[fishID], int64(weight)*n; got != want {
+					t.Errorf("%s picked %d times, expected %d", fishID, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestAliasTableRejectsZeroTotal(t *testing.T) {
+	if _, err := NewAliasTable(map[string]int{"a": 0, "b": 0}); err == nil {
+		t.Error("expected pool without weight to be rejected")
+	}
+}
+
+// BenchmarkAliasSelect 别名表选鱼，每次O(1)，不包含建表
+func BenchmarkAliasSelect(b *testing.B) {
+	for _, size := range benchmarkPoolSizes {
+		b.Run(fmt.Sprintf("items=%d", size), func(b *testing.B) {
+			_, weights := benchmarkPool(size)
+			table, err := NewAliasTable(weights)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := table.Sample(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkWeightedRandomSelect 按累计权重线性选鱼，每次需要排序鱼类ID
+func BenchmarkWeightedRandomSelect(b *testing.B) {
+	for _, size := range benchmarkPoolSizes {
+		b.Run(fmt.Sprintf("items=%d", size), func(b *testing.B) {
+			items, weights := benchmarkPool(size)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := weightedRandomSelect(items, weights); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"fishing-game/model"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DrawBoostContextKey 下一次抽奖加成在生效修正列表中使用的context_key
+const DrawBoostContextKey = "boost"
+
+// drawBoostKey 用户下一次抽奖的权重加成（STRING，DrawBoost JSON）
+func drawBoostKey(userID string) string {
+	return fmt.Sprintf("lottery:boost:%s", userID)
+}
+
+// getDrawBoost 读取用户的下一次抽奖加成及其原始JSON，没有加成时返回nil。
+// 加成由抽奖提交脚本在校验未被修改后删除
+func (ls *LotteryService) getDrawBoost(ctx context.Context, userID string) (*model.DrawBoost, string, error) {
+	data, err := ls.redisClient.Get(ctx, drawBoostKey(userID)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, "", nil
+		}
+		return nil, "", fmt.Errorf("failed to get draw boost: %w", err)
+	}
+
+	var boost model.DrawBoost
+	if err := json.Unmarshal([]byte(data), &boost); err != nil {
+		return nil, "", fmt.Errorf("failed to unmarshal draw boost: %w", err)
+	}
+	return &boost, data, nil
+}
+
+// applyDrawBoost 在已修正的权重上叠加加成，返回新的权重与生效修正列表，不修改传入的权重
+func applyDrawBoost(items map[string]*model.LotteryItem, weights map[string]int, applied []model.AppliedModifier, boost *model.DrawBoost) (map[string]int, []model.AppliedModifier) {
+	adjusted := make(map[string]int, len(weights))
+	for fishID, weight := range weights {
+		adjusted[fishID] = weight
+	}
+	for _, effect := range boost.Effects {
+		applyEffect(items, adjusted, effect)
+	}
+
+	return adjusted, append(applied, model.AppliedModifier{
+		ID:         boost.ID,
+		Name:       boost.Name,
+		ContextKey: DrawBoostContextKey,
+		Value:      boost.Source,
+	})
+}
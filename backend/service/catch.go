@@ -0,0 +1,96 @@
+package service
+
+import (
+	"math"
+	"math/rand"
+
+	"fishing-game/model"
+)
+
+// 尺寸采样：超出范围时重新采样的最大次数，之后截断到范围内
+const sizeResampleLimit = 10
+
+// 系统鱼类的默认尺寸分布（兼容未保存尺寸分布的历史数据）
+var systemFishSizes = map[string]*model.SizeDistribution{
+	SmallFishID: {
+		LengthCM: model.SizeRange{Mean: 15, StdDev: 4, Min: 8, Max: 30},
+		WeightKG: model.SizeRange{Mean: 0.1, StdDev: 0.04, Min: 0.03, Max: 0.3},
+	},
+	MediumFishID: {
+		LengthCM: model.SizeRange{Mean: 35, StdDev: 8, Min: 20, Max: 60},
+		WeightKG: model.SizeRange{Mean: 0.8, StdDev: 0.3, Min: 0.3, Max: 2.5},
+	},
+	LargeFishID: {
+		LengthCM: model.SizeRange{Mean: 80, StdDev: 15, Min: 50, Max: 150},
+		WeightKG: model.SizeRange{Mean: 6, StdDev: 2.5, Min: 2, Max: 25},
+	},
+	RareFishID: {
+		LengthCM: model.SizeRange{Mean: 120, StdDev: 25, Min: 70, Max: 220},
+		WeightKG: model.SizeRange{Mean: 20, StdDev: 8, Min: 6, Max: 80},
+	},
+}
+
+// DefaultUserFishSize 用户鱼的默认尺寸分布
+var DefaultUserFishSize = &model.SizeDistribution{
+	LengthCM: model.SizeRange{Mean: 60, StdDev: 15, Min: 25, Max: 120},
+	WeightKG: model.SizeRange{Mean: 3, StdDev: 1.2, Min: 0.5, Max: 12},
+}
+
+// catchSize 一次渔获的尺寸及按尺寸计算后的积分
+type catchSize struct {
+	lengthCM float64
+	weightKG float64
+	points   int
+}
+
+// sizeDistribution 鱼类的尺寸分布，未配置时系统鱼和用户鱼使用默认分布
+func sizeDistribution(item *model.LotteryItem) *model.SizeDistribution {
+	if item.Size != nil {
+		return item.Size
+	}
+	if item.IsUserFish {
+		return DefaultUserFishSize
+	}
+	return systemFishSizes[item.ID]
+}
+
+// rollCatchSize 按鱼类的尺寸分布生成体长和体重，没有尺寸分布时返回空
+func rollCatchSize(item *model.LotteryItem) *catchSize {
+	dist := sizeDistribution(item)
+	if dist == nil {
+		return nil
+	}
+
+	// 体长与体重共用同一个标准正态偏移，超出任一范围时重新采样
+	z := rand.NormFloat64()
+	for i := 0; i < sizeResampleLimit && !(sizeInRange(dist.LengthCM, z) && sizeInRange(dist.WeightKG, z)); i++ {
+		z = rand.NormFloat64()
+	}
+
+	size := &catchSize{
+		lengthCM: roundSize(sizeAt(dist.LengthCM, z)),
+		weightKG: roundSize(sizeAt(dist.WeightKG, z)),
+		points:   item.Points,
+	}
+	if dist.ScalePoints && dist.WeightKG.Mean > 0 {
+		size.points = int(math.Round(float64(item.Points) * size.weightKG / dist.WeightKG.Mean))
+	}
+
+	return size
+}
+
+// sizeAt 标准正态偏移z对应的尺寸，截断到范围内
+func sizeAt(r model.SizeRange, z float64) float64 {
+	return math.Max(r.Min, math.Min(r.Max, r.Mean+z*r.StdDev))
+}
+
+// sizeInRange 标准正态偏移z对应的尺寸是否在范围内
+func sizeInRange(r model.SizeRange, z float64) bool {
+	v := r.Mean + z*r.StdDev
+	return v >= r.Min && v <= r.Max
+}
+
+// roundSize 尺寸保留两位小数
+func roundSize(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,385 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"fishing-game/config"
+	"fishing-game/model"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// CheckinRewardsConfigFile 签到奖励表配置文件
+	CheckinRewardsConfigFile = "checkin_rewards.json"
+
+	// DefaultCheckinMaxRepairDays 默认最多可补签的连续漏签天数
+	DefaultCheckinMaxRepairDays = 3
+
+	// checkinMaxRetries 并发签到冲突时的最大重试次数
+	checkinMaxRetries = 3
+
+	// DrawBoostSourceCheckin 签到奖励的抽奖加成来源
+	DrawBoostSourceCheckin = "checkin"
+)
+
+var (
+	// ErrAlreadyCheckedIn 今日已签到
+	ErrAlreadyCheckedIn = errors.New("already checked in today")
+	// ErrCheckinRepair 漏签天数无法补签（超过可补签天数或补签卡不足）
+	ErrCheckinRepair = errors.New("missed days cannot be repaired")
+)
+
+// CheckinConfig 签到配置
+type CheckinConfig struct {
+	Location      *time.Location // 签到日所在时区
+	MaxRepairDays int            // 最多可补签的连续漏签天数
+}
+
+// LoadCheckinConfig 从环境变量加载签到配置，时区未单独配置时与每日次数重置时区一致
+func LoadCheckinConfig() CheckinConfig {
+	return CheckinConfig{
+		Location:      config.GetEnvLocation("CHECKIN_TIMEZONE", config.GetEnvString("GAME_TIMEZONE", DefaultGameTimezone)),
+		MaxRepairDays: config.GetEnvInt("CHECKIN_MAX_REPAIR_DAYS", DefaultCheckinMaxRepairDays),
+	}
+}
+
+// checkinScript 校验上次签到日期未被并发修改后，原子写入签到状态并发放积分、补签卡、额外抽奖次数和抽奖加成
+//
+// KEYS[1] 用户签到状态 KEYS[2] 用户体力 KEYS[3] 用户下一次抽奖加成 KEYS[4] 全局榜单
+// ARGV[1] 读取时的上次签到日（无记录时为空） ARGV[2] 今天 ARGV[3] 签到后的连续天数
+// ARGV[4] 消耗补签卡 ARGV[5] 奖励补签卡 ARGV[6] 奖励额外抽奖次数 ARGV[7] 抽奖加成JSON（无加成时为空）
+// ARGV[8] 奖励积分 ARGV[9] 榜单成员
+//
+// 返回 {'ok', 新的榜单积分（未发放积分时为0）} / {'conflict'}
+var checkinScript = redis.NewScript(`
+local last = redis.call('HGET', KEYS[1], 'last_day') or ''
+if last ~= ARGV[1] then
+	return {'conflict'}
+end
+
+local repair = tonumber(redis.call('HGET', KEYS[1], 'repair_items')) or 0
+local used = tonumber(ARGV[4])
+if repair < used then
+	return {'conflict'}
+end
+
+local streak = tonumber(ARGV[3])
+local best = tonumber(redis.call('HGET', KEYS[1], 'best_streak')) or 0
+if streak > best then
+	best = streak
+end
+redis.call('HSET', KEYS[1], 'last_day', ARGV[2], 'streak', streak, 'best_streak', best,
+	'repair_items', repair - used + tonumber(ARGV[5]))
+redis.call('HINCRBY', KEYS[1], 'total_days', 1)
+
+if tonumber(ARGV[6]) > 0 then
+	redis.call('HINCRBY', KEYS[2], 'bonus_draws', tonumber(ARGV[6]))
+end
+if ARGV[7] ~= '' then
+	redis.call('SET', KEYS[3], ARGV[7])
+end
+local score = 0
+if tonumber(ARGV[8]) > 0 then
+	score = tonumber(redis.call('ZINCRBY', KEYS[4], tonumber(ARGV[8]), ARGV[9]))
+end
+return {'ok', score}
+`)
+
+// CheckinService 每日签到服务
+type CheckinService struct {
+	redisClient    *redis.Client
+	rankingService *RankingService
+	config         CheckinConfig
+	rewards        model.CheckinRewardConfig
+}
+
+// NewCheckinService 创建签到服务，奖励表不存在时签到不发放奖励
+func NewCheckinService(rankingService *RankingService) (*CheckinService, error) {
+	var rewards model.CheckinRewardConfig
+	found, err := config.LoadJSON(CheckinRewardsConfigFile, &rewards)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		log.Printf("%s not found, check-in rewards disabled", CheckinRewardsConfigFile)
+	}
+	if err := validateCheckinRewards(&rewards); err != nil {
+		return nil, err
+	}
+
+	return &CheckinService{
+		redisClient:    config.GetRedisClient(),
+		rankingService: rankingService,
+		config:         LoadCheckinConfig(),
+		rewards:        rewards,
+	}, nil
+}
+
+// validateCheckinRewards 校验奖励表并按天数排序
+func validateCheckinRewards(rewards *model.CheckinRewardConfig) error {
+	if rewards.Cycle < 0 {
+		return fmt.Errorf("check-in cycle must not be negative")
+	}
+
+	seen := make(map[int]bool, len(rewards.Rewards))
+	for _, reward := range rewards.Rewards {
+		if reward.Day <= 0 {
+			return fmt.Errorf("check-in reward day must be positive")
+		}
+		if seen[reward.Day] {
+			return fmt.Errorf("duplicate check-in reward for day %d", reward.Day)
+		}
+		seen[reward.Day] = true
+
+		if reward.Points < 0 || reward.ExtraDraws < 0 || reward.RepairItems < 0 {
+			return fmt.Errorf("check-in reward for day %d must not be negative", reward.Day)
+		}
+		if reward.Boost != nil {
+			if err := validateModifierEffects(reward.Boost.Effects); err != nil {
+				return fmt.Errorf("check-in reward for day %d: %w", reward.Day, err)
+			}
+		}
+	}
+
+	sort.Slice(rewards.Rewards, func(i, j int) bool {
+		return rewards.Rewards[i].Day < rewards.Rewards[j].Day
+	})
+	return nil
+}
+
+// checkinKey 用户签到状态
+func checkinKey(userID string) string {
+	return fmt.Sprintf("checkin:%s", userID)
+}
+
+// checkinDay 时间在签到时区对应的日序号（自1970-01-01起的天数），便于计算漏签天数
+func (cs *CheckinService) checkinDay(t time.Time) int64 {
+	local := t.In(cs.config.Location)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400
+}
+
+// checkinDate 日序号对应的日期（YYYY-MM-DD）
+func checkinDate(day int64) string {
+	return time.Unix(day*86400, 0).UTC().Format("2006-01-02")
+}
+
+// nextResetAt 下一个签到日的开始时间
+func (cs *CheckinService) nextResetAt(now time.Time) time.Time {
+	local := now.In(cs.config.Location)
+	return time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, cs.config.Location)
+}
+
+// rewardFor 连续签到第streak天的奖励：循环后取天数不超过当前天数的最近一档
+func (cs *CheckinService) rewardFor(streak int) *model.CheckinReward {
+	day := streak
+	if cs.rewards.Cycle > 0 {
+		day = (streak-1)%cs.rewards.Cycle + 1
+	}
+
+	var reward *model.CheckinReward
+	for _, r := range cs.rewards.Rewards {
+		if r.Day > day {
+			break
+		}
+		reward = r
+	}
+	return reward
+}
+
+// checkinRecord 签到状态原始数据
+type checkinRecord struct {
+	lastDay     int64 // 上次签到日序号，未签到过为0
+	hasCheckin  bool
+	streak      int
+	bestStreak  int
+	totalDays   int
+	repairItems int
+}
+
+// getRecord 读取用户签到状态
+func (cs *CheckinService) getRecord(ctx context.Context, userID string) (*checkinRecord, error) {
+	fields, err := cs.redisClient.HGetAll(ctx, checkinKey(userID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get check-in state: %w", err)
+	}
+
+	record := &checkinRecord{}
+	if value, ok := fields["last_day"]; ok {
+		record.lastDay, _ = strconv.ParseInt(value, 10, 64)
+		record.hasCheckin = true
+	}
+	record.streak, _ = strconv.Atoi(fields["streak"])
+	record.bestStreak, _ = strconv.Atoi(fields["best_streak"])
+	record.totalDays, _ = strconv.Atoi(fields["total_days"])
+	record.repairItems, _ = strconv.Atoi(fields["repair_items"])
+	return record, nil
+}
+
+// missedDays 上次签到到today之间漏签的天数
+func (r *checkinRecord) missedDays(today int64) int {
+	if !r.hasCheckin || today-r.lastDay <= 1 {
+		return 0
+	}
+	return int(today - r.lastDay - 1)
+}
+
+// Checkin 签到。漏签时可使用补签卡延续连续天数，否则连续天数从1重新开始
+func (cs *CheckinService) Checkin(ctx context.Context, req *model.CheckinRequest) (*model.CheckinResponse, error) {
+	for attempt := 0; attempt < checkinMaxRetries; attempt++ {
+		now := time.Now()
+		today := cs.checkinDay(now)
+
+		record, err := cs.getRecord(ctx, req.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if record.hasCheckin && record.lastDay >= today {
+			return nil, ErrAlreadyCheckedIn
+		}
+
+		streak := record.streak + 1
+		missed := record.missedDays(today)
+		repaired := 0
+		if !record.hasCheckin {
+			streak = 1
+		} else if missed > 0 {
+			if req.UseRepair {
+				if missed > cs.config.MaxRepairDays {
+					return nil, fmt.Errorf("%w: missed %d days, at most %d can be repaired", ErrCheckinRepair, missed, cs.config.MaxRepairDays)
+				}
+				if record.repairItems < missed {
+					return nil, fmt.Errorf("%w: need %d repair items, have %d", ErrCheckinRepair, missed, record.repairItems)
+				}
+				// 补签的天数计入连续天数
+				repaired = missed
+				streak = record.streak + missed + 1
+			} else {
+				streak = 1
+			}
+		}
+
+		reward := cs.rewardFor(streak)
+		status, score, err := cs.commit(ctx, req.UserID, record, today, streak, repaired, reward)
+		if err != nil {
+			return nil, err
+		}
+		if status == "conflict" {
+			continue
+		}
+		if reward != nil && reward.Points > 0 {
+			cs.rankingService.notifyScoreListeners(ctx, req.UserID, score)
+		}
+
+		date := checkinDate(today)
+
+		state, err := cs.GetState(ctx, req.UserID)
+		if err != nil {
+			return nil, err
+		}
+		return &model.CheckinResponse{
+			UserID:       req.UserID,
+			Date:         date,
+			Streak:       streak,
+			RepairedDays: repaired,
+			Reward:       reward,
+			State:        state,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("failed to check in: concurrent update")
+}
+
+// commit 执行签到脚本，返回脚本状态和发放积分后的榜单积分
+func (cs *CheckinService) commit(ctx context.Context, userID string, record *checkinRecord, today int64, streak, repaired int, reward *model.CheckinReward) (string, int, error) {
+	expectedLastDay := ""
+	if record.hasCheckin {
+		expectedLastDay = strconv.FormatInt(record.lastDay, 10)
+	}
+
+	points, rewardRepair, extraDraws, boostJSON := 0, 0, 0, ""
+	if reward != nil {
+		points = reward.Points
+		rewardRepair = reward.RepairItems
+		extraDraws = reward.ExtraDraws
+		if reward.Boost != nil {
+			boost := *reward.Boost
+			boost.Source = DrawBoostSourceCheckin
+			if boost.ID == "" {
+				boost.ID = fmt.Sprintf("checkin_day_%d", reward.Day)
+			}
+			data, err := json.Marshal(&boost)
+			if err != nil {
+				return "", 0, fmt.Errorf("failed to marshal draw boost: %w", err)
+			}
+			boostJSON = string(data)
+		}
+	}
+
+	result, err := checkinScript.Run(ctx, cs.redisClient,
+		[]string{checkinKey(userID), staminaKey(userID), drawBoostKey(userID), GlobalRankingKey},
+		expectedLastDay,
+		today,
+		streak,
+		repaired,
+		rewardRepair,
+		extraDraws,
+		boostJSON,
+		points,
+		rankingMember(userID),
+	).Slice()
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to check in: %w", err)
+	}
+	status, _ := result[0].(string)
+	if status != "ok" {
+		return status, 0, nil
+	}
+	if len(result) != 2 {
+		return "", 0, fmt.Errorf("unexpected check-in result")
+	}
+	score, _ := result[1].(int64)
+	return status, int(score), nil
+}
+
+// GetState 获取用户签到状态
+func (cs *CheckinService) GetState(ctx context.Context, userID string) (*model.CheckinState, error) {
+	record, err := cs.getRecord(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	today := cs.checkinDay(now)
+	missed := record.missedDays(today)
+
+	state := &model.CheckinState{
+		Streak:         record.streak,
+		BestStreak:     record.bestStreak,
+		TotalDays:      record.totalDays,
+		CheckedInToday: record.hasCheckin && record.lastDay >= today,
+		MissedDays:     missed,
+		RepairItems:    record.repairItems,
+		Repairable:     missed > 0 && missed <= cs.config.MaxRepairDays && record.repairItems >= missed,
+		NextResetAt:    cs.nextResetAt(now),
+	}
+	if record.hasCheckin {
+		state.LastCheckinDate = checkinDate(record.lastDay)
+	}
+
+	// 今天已签到时为明天的奖励；漏签且不补签时从第1天重新开始
+	nextStreak := record.streak + 1
+	if !record.hasCheckin || missed > 0 {
+		nextStreak = 1
+	}
+	state.NextReward = cs.rewardFor(nextStreak)
+
+	return state, nil
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"fishing-game/model"
+)
+
+// newTestCheckinService 基于miniredis创建签到服务，奖励表固定为：第1天10分，第3天起30分并奖励1张补签卡和2次额外抽奖
+func newTestCheckinService(t *testing.T) (*CheckinService, context.Context) {
+	t.Helper()
+	ls, ctx := newTestLotteryService(t)
+	cs, err := NewCheckinService(ls.rankingService)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs.config = CheckinConfig{Location: time.UTC, MaxRepairDays: DefaultCheckinMaxRepairDays}
+	cs.rewards = model.CheckinRewardConfig{Rewards: []*model.CheckinReward{
+		{Day: 1, Points: 10},
+		{Day: 3, Points: 30, RepairItems: 1, ExtraDraws: 2},
+	}}
+	return cs, ctx
+}
+
+// seedCheckin 设置用户上次签到在daysAgo天前
+func seedCheckin(t *testing.T, cs *CheckinService, ctx context.Context, daysAgo int64, streak, repairItems int) {
+	t.Helper()
+	today := cs.checkinDay(time.Now())
+	if err := cs.redisClient.HSet(ctx, checkinKey(testUserID),
+		"last_day", today-daysAgo, "streak", streak, "best_streak", streak, "total_days", streak, "repair_items", repairItems,
+	).Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckinRepairExtendsStreak(t *testing.T) {
+	cs, ctx := newTestCheckinService(t)
+	seedCheckin(t, cs, ctx, 3, 1, 2)
+
+	resp, err := cs.Checkin(ctx, &model.CheckinRequest{UserID: testUserID, UseRepair: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 补签2天，连续天数1+2+1
+	if resp.Streak != 4 || resp.RepairedDays != 2 || resp.Reward == nil || resp.Reward.Day != 3 {
+		t.Fatalf("checked in with streak %d, repaired %d, reward %+v", resp.Streak, resp.RepairedDays, resp.Reward)
+	}
+	if state := resp.State; state.RepairItems != 1 || state.BestStreak != 4 || state.TotalDays != 2 || !state.CheckedInToday {
+		t.Errorf("state after repair %+v", state)
+	}
+	if draws, _ := cs.redisClient.HGet(ctx, staminaKey(testUserID), "bonus_draws").Int(); draws != 2 {
+		t.Errorf("bonus draws %d, expected 2", draws)
+	}
+
+	// 当日重复签到不再发放奖励
+	if _, err := cs.Checkin(ctx, &model.CheckinRequest{UserID: testUserID}); !errors.Is(err, ErrAlreadyCheckedIn) {
+		t.Fatalf("expected ErrAlreadyCheckedIn, got %v", err)
+	}
+	if score, _ := cs.redisClient.ZScore(ctx, GlobalRankingKey, rankingMember(testUserID)).Result(); score != 30 {
+		t.Errorf("ranking score %v, expected 30", score)
+	}
+}
+
+func TestCheckinRepairRejectedWithoutEnoughItems(t *testing.T) {
+	cs, ctx := newTestCheckinService(t)
+	seedCheckin(t, cs, ctx, 3, 5, 1)
+
+	if _, err := cs.Checkin(ctx, &model.CheckinRequest{UserID: testUserID, UseRepair: true}); !errors.Is(err, ErrCheckinRepair) {
+		t.Fatalf("expected ErrCheckinRepair, got %v", err)
+	}
+	state, err := cs.GetState(ctx, testUserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.CheckedInToday || state.Streak != 5 || state.RepairItems != 1 || state.MissedDays != 2 || state.Repairable {
+		t.Errorf("state after rejected repair %+v", state)
+	}
+
+	// 不补签时连续天数重新开始，补签卡保留
+	resp, err := cs.Checkin(ctx, &model.CheckinRequest{UserID: testUserID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Streak != 1 || resp.RepairedDays != 0 || resp.State.RepairItems != 1 || resp.State.BestStreak != 5 {
+		t.Errorf("checked in with streak %d, state %+v", resp.Streak, resp.State)
+	}
+}
+
+func TestCheckinConflictRewardsOnce(t *testing.T) {
+	cs, ctx := newTestCheckinService(t)
+	seedCheckin(t, cs, ctx, 1, 1, 0)
+	if err := checkinScript.Load(ctx, cs.redisClient).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 读取签到状态后，同一用户的另一个请求先完成了签到
+	hook := &faultHook{match: scriptCall(checkinScript)}
+	hook.onMatch = func() {
+		if hook.hits == 1 {
+			if _, err := cs.Checkin(ctx, &model.CheckinRequest{UserID: testUserID}); err != nil {
+				t.Error(err)
+			}
+		}
+	}
+	cs.redisClient.AddHook(hook)
+
+	if _, err := cs.Checkin(ctx, &model.CheckinRequest{UserID: testUserID}); !errors.Is(err, ErrAlreadyCheckedIn) {
+		t.Fatalf("expected ErrAlreadyCheckedIn, got %v", err)
+	}
+	state, err := cs.GetState(ctx, testUserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Streak != 2 || state.TotalDays != 2 {
+		t.Errorf("state after concurrent check-in %+v", state)
+	}
+	if score, _ := cs.redisClient.ZScore(ctx, GlobalRankingKey, rankingMember(testUserID)).Result(); score != 10 {
+		t.Errorf("ranking score %v, expected 10", score)
+	}
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"fishing-game/config"
+	"fishing-game/model"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// DefaultCreatorSeriesDays 用户鱼每日被钓次数序列的默认天数
+	DefaultCreatorSeriesDays = 30
+	// MaxCreatorSeriesDays 序列最多天数，与每日统计的保留时长一致
+	MaxCreatorSeriesDays = int(DailyStatsTTL / (24 * time.Hour))
+)
+
+// CreatorService 用户鱼创建者服务：查看自己创建的鱼的权重、概率及被钓情况
+type CreatorService struct {
+	redisClient  *redis.Client
+	poolService  *PoolService
+	eventService *PoolEventService
+	location     *time.Location
+}
+
+// NewCreatorService 创建创建者服务
+func NewCreatorService(poolService *PoolService, eventService *PoolEventService) *CreatorService {
+	return &CreatorService{
+		redisClient:  config.GetRedisClient(),
+		poolService:  poolService,
+		eventService: eventService,
+		location:     config.GetEnvLocation("GAME_TIMEZONE", DefaultGameTimezone),
+	}
+}
+
+// GetCreatorFish 获取创建者在所有钓点的用户鱼，概率按当前生效的活动计算。
+// 被钓累计和去重玩家由抽奖提交脚本与抽奖记录一同写入，每日序列读取每日出鱼统计
+func (cs *CreatorService) GetCreatorFish(ctx context.Context, wxID string, days int) (*model.CreatorFishResponse, error) {
+	now := time.Now()
+	response := &model.CreatorFishResponse{
+		WxID: wxID,
+		Days: days,
+		Fish: make([]*model.CreatorFish, 0),
+	}
+
+	for _, def := range cs.poolService.spotOrder {
+		pool, err := cs.eventService.GetEffectivePool(ctx, def.ID, now)
+		if err != nil {
+			return nil, err
+		}
+
+		fish := make([]*model.CreatorFish, 0)
+		for itemID, item := range pool.Items {
+			if !item.IsUserFish || item.WxID != wxID {
+				continue
+			}
+			entry := &model.CreatorFish{
+				ItemID:          itemID,
+				Name:            item.Name,
+				Description:     item.Description,
+				ImageURL:        item.ImageURL,
+				Points:          item.Points,
+				Spot:            pool.Spot,
+				Weight:          pool.StoredWeights[itemID],
+				EffectiveWeight: pool.Weights[itemID],
+			}
+			if pool.TotalWeight > 0 {
+				entry.Probability = float64(entry.EffectiveWeight) / float64(pool.TotalWeight)
+			}
+			fish = append(fish, entry)
+		}
+		sort.Slice(fish, func(i, j int) bool {
+			if fish[i].Name != fish[j].Name {
+				return fish[i].Name < fish[j].Name
+			}
+			return fish[i].ItemID < fish[j].ItemID
+		})
+		response.Fish = append(response.Fish, fish...)
+	}
+	if len(response.Fish) == 0 {
+		return response, nil
+	}
+
+	if err := cs.loadCatches(ctx, response.Fish, now, days); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// loadCatches 读取用户鱼的被钓累计、去重玩家数及最近days天的每日被钓次数
+func (cs *CreatorService) loadCatches(ctx context.Context, fish []*model.CreatorFish, now time.Time, days int) error {
+	itemIDs := make([]string, len(fish))
+	for i, entry := range fish {
+		itemIDs[i] = entry.ItemID
+	}
+
+	dates := make([]string, days)
+	today := now.In(cs.location)
+	for i := range dates {
+		dates[i] = today.AddDate(0, 0, i-days+1).Format("20060102")
+	}
+
+	pipe := cs.redisClient.Pipeline()
+	totals := make([]*redis.MapStringStringCmd, len(fish))
+	catchers := make([]*redis.IntCmd, len(fish))
+	for i, itemID := range itemIDs {
+		totals[i] = pipe.HGetAll(ctx, itemCatchesKey(itemID))
+		catchers[i] = pipe.SCard(ctx, itemCatchersKey(itemID))
+	}
+	daily := make([]*redis.SliceCmd, len(dates))
+	for i, date := range dates {
+		daily[i] = pipe.HMGet(ctx, statsDailyKey(date), itemIDs...)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to get fish catches: %w", err)
+	}
+
+	for i, entry := range fish {
+		fields := totals[i].Val()
+		entry.TotalCaught, _ = strconv.ParseInt(fields["total"], 10, 64)
+		entry.UniqueCatchers = catchers[i].Val()
+		if millis, err := strconv.ParseInt(fields["first_caught_at"], 10, 64); err == nil {
+			firstCaughtAt := time.UnixMilli(millis)
+			entry.FirstCaughtAt = &firstCaughtAt
+		}
+		if millis, err := strconv.ParseInt(fields["last_caught_at"], 10, 64); err == nil {
+			lastCaughtAt := time.UnixMilli(millis)
+			entry.LastCaughtAt = &lastCaughtAt
+		}
+
+		entry.DailyCatches = make([]*model.DailyCatchCount, len(dates))
+		for j, date := range dates {
+			count := &model.DailyCatchCount{Date: date}
+			if value, ok := daily[j].Val()[i].(string); ok {
+				count.Count, _ = strconv.ParseInt(value, 10, 64)
+			}
+			entry.DailyCatches[j] = count
+		}
+	}
+	return nil
+}
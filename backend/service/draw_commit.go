@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"fishing-game/model"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// drawCommit 抽奖提交时与幂等key一同保存的信息。请求在提交后失败或进程退出、响应未能保存时，
+// 重复请求按此从抽奖记录重建响应，不会再次抽奖
+type drawCommit struct {
+	BatchID   string                  `json:"batch_id,omitempty"` // 连抽的批次ID
+	DrawIDs   []string                `json:"draw_ids"`
+	Spot      string                  `json:"spot"`
+	Modifiers []model.AppliedModifier `json:"modifiers"`
+	Gear      []model.AppliedGear     `json:"gear"`
+	CreatedAt time.Time               `json:"created_at"`
+	Broken    []string                `json:"broken,omitempty"` // 各次抽奖打破的纪录（逗号分隔），由提交脚本追加
+}
+
+// idempotencyCharge 抽奖提交脚本在写入前校验幂等key仍由本次请求占用，并在提交时保存提交信息
+type idempotencyCharge struct {
+	Owner  string `json:"owner"`
+	Window int64  `json:"window"` // 去重窗口（毫秒）
+	Commit string `json:"commit"` // drawCommit JSON（不含 broken）
+}
+
+// idempotencyClaimKey 抽奖提交脚本的幂等key，未携带trace_id时为空
+func idempotencyClaimKey(claim *idempotencyClaim) string {
+	if claim == nil {
+		return ""
+	}
+	return claim.key
+}
+
+// newIdempotencyCharge 构造与抽奖一同提交的幂等信息
+func newIdempotencyCharge(params *drawParams, selections []*drawSelection, modifiers []model.AppliedModifier, gear []model.AppliedGear) (*idempotencyCharge, error) {
+	commit := &drawCommit{
+		BatchID:   params.batchID,
+		DrawIDs:   make([]string, 0, len(selections)),
+		Spot:      params.spot,
+		Modifiers: modifiers,
+		Gear:      gear,
+		CreatedAt: params.now,
+	}
+	for _, selection := range selections {
+		commit.DrawIDs = append(commit.DrawIDs, selection.drawID)
+	}
+
+	commitJSON, err := json.Marshal(commit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal draw commit: %w", err)
+	}
+
+	return &idempotencyCharge{
+		Owner:  params.claim.owner,
+		Window: params.claim.window.Milliseconds(),
+		Commit: string(commitJSON),
+	}, nil
+}
+
+// decodeDrawCommit 解析幂等key中保存的提交信息
+func decodeDrawCommit(commitJSON []byte) (*drawCommit, error) {
+	var commit drawCommit
+	if err := json.Unmarshal(commitJSON, &commit); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal draw commit: %w", err)
+	}
+	if len(commit.DrawIDs) == 0 || len(commit.Broken) != len(commit.DrawIDs) {
+		return nil, fmt.Errorf("invalid draw commit")
+	}
+	return &commit, nil
+}
+
+// replayDraw 从已提交的抽奖记录重建单抽响应
+func (ls *LotteryService) replayDraw(ctx context.Context, userID string, commitJSON []byte) (*model.LotteryDrawResponse, error) {
+	commit, err := decodeDrawCommit(commitJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := ls.committedResults(ctx, commit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.LotteryDrawResponse{
+		DrawID:    commit.DrawIDs[0],
+		UserID:    userID,
+		Spot:      commit.Spot,
+		Result:    results[0],
+		Modifiers: commit.Modifiers,
+		Gear:      commit.Gear,
+		CreatedAt: commit.CreatedAt,
+	}, nil
+}
+
+// replayBatchDraw 从已提交的抽奖记录重建连抽响应
+func (ls *LotteryService) replayBatchDraw(ctx context.Context, userID string, commitJSON []byte) (*model.LotteryBatchDrawResponse, error) {
+	commit, err := decodeDrawCommit(commitJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := ls.committedResults(ctx, commit)
+	if err != nil {
+		return nil, err
+	}
+	totalPoints := 0
+	for _, result := range results {
+		totalPoints += result.Points
+	}
+
+	return &model.LotteryBatchDrawResponse{
+		DrawID:      commit.BatchID,
+		UserID:      userID,
+		Spot:        commit.Spot,
+		Results:     results,
+		TotalPoints: totalPoints,
+		Modifiers:   commit.Modifiers,
+		Gear:        commit.Gear,
+		CreatedAt:   commit.CreatedAt,
+	}, nil
+}
+
+// committedResults 按提交信息读取抽奖记录和证明，重建按抽奖顺序排列的抽奖结果
+func (ls *LotteryService) committedResults(ctx context.Context, commit *drawCommit) ([]model.LotteryResult, error) {
+	items, err := ls.poolService.GetItems(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool: %w", err)
+	}
+
+	results := make([]model.LotteryResult, 0, len(commit.DrawIDs))
+	for i, drawID := range commit.DrawIDs {
+		record, err := ls.GetDraw(ctx, drawID)
+		if err != nil {
+			return nil, err
+		}
+
+		result := model.LotteryResult{
+			DrawID:      record.DrawID,
+			Win:         record.Points > 0,
+			ItemID:      record.ItemID,
+			ItemName:    record.ItemName,
+			Description: record.Description,
+			Points:      record.Points,
+			WxID:        record.WxID,
+			LengthCM:    record.LengthCM,
+			WeightKG:    record.WeightKG,
+		}
+		if item, exists := items[record.ItemID]; exists {
+			result.ImageURL = item.ImageURL
+		}
+		if commit.Broken[i] != "" {
+			result.Records = strings.Split(commit.Broken[i], ",")
+		}
+
+		// 最后一条证明为本次抽奖的最终结果，响应中不返回完整权重
+		proofJSON, err := ls.redisClient.Get(ctx, fairProofKey(drawID)).Result()
+		if err != nil && err != redis.Nil {
+			return nil, fmt.Errorf("failed to get proof: %w", err)
+		}
+		if proofJSON != "" {
+			var proofs []*model.FairProof
+			if err := json.Unmarshal([]byte(proofJSON), &proofs); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal proof: %w", err)
+			}
+			if len(proofs) > 0 {
+				proof := proofs[len(proofs)-1]
+				proof.Weights = nil
+				result.Fair = proof
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
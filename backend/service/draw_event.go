@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"fishing-game/model"
+)
+
+// DrawEvent 一次抽奖请求（单抽或连抽）提交成功后的事件
+type DrawEvent struct {
+	UserID    string
+	Spot      string // 抽奖的钓点
+	TraceID   string
+	Records   []*model.LotteryRecord        // 按抽奖顺序排列的抽奖记录
+	Items     map[string]*model.LotteryItem // 本次抽中的鱼类（item_id -> LotteryItem）
+	Deviation map[string]int64              // 各次选鱼实际概率与钓点配置概率之差的累计（按 StatsDeviationScale 取整），只包含概率被调整的鱼类
+	Adjusted  int64                         // 实际概率与配置概率不同的选鱼次数
+	CreatedAt time.Time
+}
+
+// DrawListener 抽奖事件监听器，在抽奖记录和积分原子提交之后同步调用
+type DrawListener interface {
+	OnDraw(ctx context.Context, event *DrawEvent) error
+}
+
+// AddDrawListener 注册抽奖事件监听器
+func (ls *LotteryService) AddDrawListener(listener DrawListener) {
+	ls.listeners = append(ls.listeners, listener)
+}
+
+// newDrawEvent 根据已提交的选鱼结果构造抽奖事件，configured 为钓点奖池的配置权重
+func newDrawEvent(params *drawParams, configured map[string]int, selections []*drawSelection) *DrawEvent {
+	event := &DrawEvent{
+		UserID:    params.userID,
+		Spot:      params.spot,
+		TraceID:   params.traceID,
+		Records:   make([]*model.LotteryRecord, 0, len(selections)),
+		Items:     make(map[string]*model.LotteryItem, len(selections)),
+		Deviation: make(map[string]int64),
+		CreatedAt: params.now,
+	}
+	configuredProbabilities := weightProbabilities(configured)
+
+	for _, selection := range selections {
+		item := selection.item
+		record := &model.LotteryRecord{
+			DrawID:      selection.drawID,
+			UserID:      params.userID,
+			TraceID:     params.traceID,
+			Spot:        params.spot,
+			ItemID:      item.ID,
+			ItemName:    item.Name,
+			Description: item.Description,
+			WxID:        item.WxID,
+			Points:      selection.points(),
+			Strategy:    selection.strategy,
+			Timestamp:   params.now,
+		}
+		if selection.size != nil {
+			record.LengthCM = selection.size.lengthCM
+			record.WeightKG = selection.size.weightKG
+		}
+		event.Records = append(event.Records, record)
+		event.Items[item.ID] = item
+		deviation := oddsDeviation(selection.probabilities(), configuredProbabilities)
+		if len(deviation) > 0 {
+			event.Adjusted++
+		}
+		for itemID, d := range deviation {
+			event.Deviation[itemID] += d
+		}
+	}
+
+	return event
+}
+
+// notifyDrawListeners 通知所有监听器。抽奖已经提交，监听器失败只记录日志
+func (ls *LotteryService) notifyDrawListeners(ctx context.Context, event *DrawEvent) {
+	for _, listener := range ls.listeners {
+		if err := listener.OnDraw(ctx, event); err != nil {
+			log.Printf("Draw listener %T failed for %s: %v", listener, event.UserID, err)
+		}
+	}
+}
@@ -0,0 +1,358 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"fishing-game/config"
+	"fishing-game/model"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// StrategyFair 可验证抽奖策略
+	StrategyFair = "fair"
+
+	// FairClientSeedContextKey 抽奖请求Context中客户端种子的key，携带时启用可验证模式
+	FairClientSeedContextKey = "client_seed"
+
+	// FairReplacedGuarantee 连抽保底替换了空军结果
+	FairReplacedGuarantee = "guarantee"
+)
+
+// fairSeedScript 确保用户有当前周期的服务端种子，nonce由抽奖提交脚本推进
+//
+// KEYS[1] 用户种子
+// ARGV[1] 备用服务端种子 ARGV[2] 备用种子哈希
+//
+// 返回 {周期, 服务端种子, 种子哈希, 下一个nonce}
+var fairSeedScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	redis.call('HSET', KEYS[1], 'epoch', 1, 'server_seed', ARGV[1], 'server_seed_hash', ARGV[2], 'nonce', 0)
+end
+local state = redis.call('HMGET', KEYS[1], 'epoch', 'server_seed', 'server_seed_hash', 'nonce')
+return {tonumber(state[1]), state[2], state[3], tonumber(state[4])}
+`)
+
+// fairRotateScript 揭示当前服务端种子并开启新周期
+//
+// KEYS[1] 用户种子 KEYS[2] 已揭示的种子
+// ARGV[1] 新服务端种子 ARGV[2] 新种子哈希
+//
+// 返回 {被揭示的周期, 被揭示的种子, 被揭示的种子哈希, 新周期}，没有可揭示的种子时周期为0
+var fairRotateScript = redis.NewScript(`
+local state = redis.call('HMGET', KEYS[1], 'epoch', 'server_seed', 'server_seed_hash')
+local epoch = tonumber(state[1]) or 0
+if epoch > 0 then
+	redis.call('HSET', KEYS[2], epoch, state[2])
+end
+redis.call('HSET', KEYS[1], 'epoch', epoch + 1, 'server_seed', ARGV[1], 'server_seed_hash', ARGV[2], 'nonce', 0)
+return {epoch, state[2] or '', state[3] or '', epoch + 1}
+`)
+
+// FairnessService 可验证抽奖（commit-reveal）服务
+type FairnessService struct {
+	redisClient *redis.Client
+}
+
+// NewFairnessService 创建可验证抽奖服务
+func NewFairnessService() *FairnessService {
+	return &FairnessService{
+		redisClient: config.GetRedisClient(),
+	}
+}
+
+// fairSeedKey 用户当前周期种子key
+func fairSeedKey(userID string) string {
+	return fmt.Sprintf("fair:seed:%s", userID)
+}
+
+// fairRevealedKey 用户已揭示种子key（epoch -> server_seed）
+func fairRevealedKey(userID string) string {
+	return fmt.Sprintf("fair:revealed:%s", userID)
+}
+
+// fairProofKey 单次抽奖的证明key（JSON数组，按nonce顺序包含本次抽奖使用的所有nonce的证明），与抽奖记录保留相同时长
+func fairProofKey(drawID string) string {
+	return fmt.Sprintf("fair:proof:%s", drawID)
+}
+
+// fairDrawsKey 用户可验证抽奖索引（ZSET，score为周期，member为抽奖ID）
+func fairDrawsKey(userID string) string {
+	return fmt.Sprintf("fair:draws:%s", userID)
+}
+
+// newServerSeed 生成服务端种子及其SHA256
+func newServerSeed() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("failed to generate server seed: %w", err)
+	}
+	seed := hex.EncodeToString(buf)
+	return seed, HashServerSeed(seed), nil
+}
+
+// HashServerSeed 计算服务端种子的公开哈希
+func HashServerSeed(serverSeed string) string {
+	sum := sha256.Sum256([]byte(serverSeed))
+	return hex.EncodeToString(sum[:])
+}
+
+// FairRoll 由 HMAC-SHA256(server_seed, "client_seed:nonce") 的前8字节导出 [0, totalWeight) 的随机数
+func FairRoll(serverSeed, clientSeed string, nonce int64, totalWeight int64) int64 {
+	mac := hmac.New(sha256.New, []byte(serverSeed))
+	mac.Write([]byte(clientSeed + ":" + strconv.FormatInt(nonce, 10)))
+	sum := mac.Sum(nil)
+	return int64(binary.BigEndian.Uint64(sum[:8]) % uint64(totalWeight))
+}
+
+// FairPick 按排序后的累计权重确定随机数对应的鱼类
+func FairPick(weights []model.FairWeight, roll int64) string {
+	var cumulative int64
+	for _, w := range weights {
+		cumulative += int64(w.Weight)
+		if roll < cumulative {
+			return w.ItemID
+		}
+	}
+	return ""
+}
+
+// sortedFairWeights 将有效权重转换为按鱼类ID排序的列表，忽略非正权重
+func sortedFairWeights(weights map[string]int) ([]model.FairWeight, int64) {
+	list := make([]model.FairWeight, 0, len(weights))
+	var total int64
+	for fishID, weight := range weights {
+		if weight <= 0 {
+			continue
+		}
+		list = append(list, model.FairWeight{ItemID: fishID, Weight: weight})
+		total += int64(weight)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ItemID < list[j].ItemID })
+	return list, total
+}
+
+// VerifyFairDraw 独立验证一次可验证抽奖，不依赖任何服务端状态
+func VerifyFairDraw(req *model.FairVerifyRequest) *model.FairVerifyResponse {
+	weights := make([]model.FairWeight, 0, len(req.Weights))
+	var total int64
+	for _, w := range req.Weights {
+		if w.Weight > 0 {
+			weights = append(weights, w)
+			total += int64(w.Weight)
+		}
+	}
+	sort.Slice(weights, func(i, j int) bool { return weights[i].ItemID < weights[j].ItemID })
+
+	response := &model.FairVerifyResponse{
+		SeedHashMatch: HashServerSeed(req.ServerSeed) == req.ServerSeedHash,
+	}
+	if total <= 0 {
+		return response
+	}
+
+	response.Roll = FairRoll(req.ServerSeed, req.ClientSeed, req.Nonce, total)
+	response.ExpectedItemID = FairPick(weights, response.Roll)
+	response.ItemMatch = response.ExpectedItemID == req.ItemID
+	response.Valid = response.SeedHashMatch && response.ItemMatch
+	return response
+}
+
+// fairSession 一次抽奖请求的可验证抽奖参数
+type fairSession struct {
+	userID         string
+	epoch          int64
+	serverSeed     string
+	serverSeedHash string
+	clientSeed     string
+	startNonce     int64 // 读取时的下一个nonce
+	nextNonce      int64
+}
+
+// fairCharge 一次抽奖请求使用的nonce和抽奖证明，作为抽奖提交脚本的参数，提交时校验周期和nonce未被修改
+type fairCharge struct {
+	Epoch  int64    `json:"epoch"`
+	Nonce  int64    `json:"nonce"`  // 读取时的下一个nonce
+	Used   int64    `json:"used"`   // 本次使用的nonce个数
+	Proofs []string `json:"proofs"` // 按抽奖顺序排列的每次抽奖的证明JSON数组
+}
+
+// charge 本次请求已使用的nonce及需要与抽奖记录一同保存的证明，proofs 按抽奖顺序排列，
+// 每项为该次抽奖使用的所有nonce的证明（包括被替换的结果），每个nonce都有且只有一条证明
+func (fs *fairSession) charge(proofs [][]*model.FairProof) (*fairCharge, error) {
+	charge := &fairCharge{
+		Epoch:  fs.epoch,
+		Nonce:  fs.startNonce,
+		Used:   fs.nextNonce - fs.startNonce,
+		Proofs: make([]string, 0, len(proofs)),
+	}
+	var used int64
+	for _, drawProofs := range proofs {
+		used += int64(len(drawProofs))
+		proofJSON, err := json.Marshal(drawProofs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal proof: %w", err)
+		}
+		charge.Proofs = append(charge.Proofs, string(proofJSON))
+	}
+	if used != charge.Used {
+		return nil, fmt.Errorf("fair draw used %d nonces but has %d proofs", charge.Used, used)
+	}
+	return charge, nil
+}
+
+// pick 使用下一个nonce从有效权重中选鱼，返回证明
+func (fs *fairSession) pick(weights map[string]int) (*model.FairProof, error) {
+	list, total := sortedFairWeights(weights)
+	if total <= 0 {
+		return nil, fmt.Errorf("no valid item found in pool")
+	}
+
+	nonce := fs.nextNonce
+	fs.nextNonce++
+
+	roll := FairRoll(fs.serverSeed, fs.clientSeed, nonce, total)
+	return &model.FairProof{
+		UserID:         fs.userID,
+		Epoch:          fs.epoch,
+		ServerSeedHash: fs.serverSeedHash,
+		ClientSeed:     fs.clientSeed,
+		Nonce:          nonce,
+		Roll:           roll,
+		TotalWeight:    total,
+		ItemID:         FairPick(list, roll),
+		Weights:        list,
+	}, nil
+}
+
+// fairClientSeed 从抽奖请求Context中读取客户端种子
+func fairClientSeed(drawContext map[string]interface{}) string {
+	seed, _ := drawContext[FairClientSeedContextKey].(string)
+	return seed
+}
+
+// session 读取用户当前周期的种子和下一个nonce（首次调用时生成种子）
+func (fs *FairnessService) session(ctx context.Context, userID, clientSeed string) (*fairSession, error) {
+	seed, seedHash, err := newServerSeed()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := fairSeedScript.Run(ctx, fs.redisClient, []string{fairSeedKey(userID)}, seed, seedHash).Slice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server seed: %w", err)
+	}
+
+	epoch, _ := result[0].(int64)
+	serverSeed, _ := result[1].(string)
+	serverSeedHash, _ := result[2].(string)
+	nonce, _ := result[3].(int64)
+
+	return &fairSession{
+		userID:         userID,
+		epoch:          epoch,
+		serverSeed:     serverSeed,
+		serverSeedHash: serverSeedHash,
+		clientSeed:     clientSeed,
+		startNonce:     nonce,
+		nextNonce:      nonce,
+	}, nil
+}
+
+// GetCommitment 获取用户当前周期公布的服务端种子哈希（首次调用时生成种子）
+func (fs *FairnessService) GetCommitment(ctx context.Context, userID string) (*model.FairCommitmentResponse, error) {
+	session, err := fs.session(ctx, userID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.FairCommitmentResponse{
+		UserID:         userID,
+		Epoch:          session.epoch,
+		ServerSeedHash: session.serverSeedHash,
+		NextNonce:      session.nextNonce,
+	}, nil
+}
+
+// Rotate 揭示当前周期的服务端种子并生成新种子
+func (fs *FairnessService) Rotate(ctx context.Context, userID string) (*model.FairRotateResponse, error) {
+	seed, seedHash, err := newServerSeed()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{fairSeedKey(userID), fairRevealedKey(userID)}
+	result, err := fairRotateScript.Run(ctx, fs.redisClient, keys, seed, seedHash).Slice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate server seed: %w", err)
+	}
+
+	revealedEpoch, _ := result[0].(int64)
+	revealedSeed, _ := result[1].(string)
+	revealedHash, _ := result[2].(string)
+	epoch, _ := result[3].(int64)
+
+	return &model.FairRotateResponse{
+		UserID:             userID,
+		RevealedEpoch:      revealedEpoch,
+		RevealedServerSeed: revealedSeed,
+		RevealedSeedHash:   revealedHash,
+		Epoch:              epoch,
+		ServerSeedHash:     seedHash,
+	}, nil
+}
+
+// GetProofs 获取用户某周期的抽奖证明（按nonce排序，已超出保留策略的抽奖不再返回），周期已揭示时附带服务端种子
+func (fs *FairnessService) GetProofs(ctx context.Context, userID string, epoch int64) (*model.FairProofsResponse, error) {
+	score := strconv.FormatInt(epoch, 10)
+	drawIDs, err := fs.redisClient.ZRangeByScore(ctx, fairDrawsKey(userID), &redis.ZRangeBy{Min: score, Max: score}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get proofs: %w", err)
+	}
+
+	proofs := make([]*model.FairProof, 0, len(drawIDs))
+	if len(drawIDs) > 0 {
+		keys := make([]string, len(drawIDs))
+		for i, drawID := range drawIDs {
+			keys[i] = fairProofKey(drawID)
+		}
+		values, err := fs.redisClient.MGet(ctx, keys...).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get proofs: %w", err)
+		}
+		for _, value := range values {
+			proofJSON, ok := value.(string)
+			if !ok {
+				continue // 证明已随抽奖记录清理
+			}
+			var drawProofs []*model.FairProof
+			if err := json.Unmarshal([]byte(proofJSON), &drawProofs); err != nil {
+				continue // 跳过无法解析的证明
+			}
+			proofs = append(proofs, drawProofs...)
+		}
+	}
+	sort.Slice(proofs, func(i, j int) bool { return proofs[i].Nonce < proofs[j].Nonce })
+
+	serverSeed, err := fs.redisClient.HGet(ctx, fairRevealedKey(userID), score).Result()
+	if err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get revealed seed: %w", err)
+	}
+
+	return &model.FairProofsResponse{
+		UserID:     userID,
+		Epoch:      epoch,
+		ServerSeed: serverSeed,
+		Proofs:     proofs,
+	}, nil
+}
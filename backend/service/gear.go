@@ -0,0 +1,393 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"fishing-game/config"
+	"fishing-game/model"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// GearConfigFile 装备配置文件
+	GearConfigFile = "gear.json"
+
+	// IdempotencyScopeGearPurchase 购买装备的幂等作用域
+	IdempotencyScopeGearPurchase = "gear_purchase"
+)
+
+var (
+	// ErrGearNotFound 装备不存在
+	ErrGearNotFound = errors.New("gear not found")
+	// ErrGearNotForSale 装备不可购买
+	ErrGearNotForSale = errors.New("gear is not for sale")
+	// ErrGearNotOwned 未持有该装备
+	ErrGearNotOwned = errors.New("gear not owned")
+)
+
+// gearPurchaseScript 扣减金币并增加装备耐久，同时写入账本
+//
+// KEYS[1] 用户钱包 KEYS[2] 用户账本 KEYS[3] 用户装备
+// ARGV[1] 价格 ARGV[2] 装备ID ARGV[3] 增加的耐久 ARGV[4] 账本记录JSON
+//
+// 金币不足时返回错误 insufficient coins，成功返回 {交易后余额, 装备耐久}
+var gearPurchaseScript = redis.NewScript(`
+local price = tonumber(ARGV[1])
+local coins = tonumber(redis.call('HGET', KEYS[1], 'coins') or '0')
+if coins < price then
+	return redis.error_reply('insufficient coins')
+end
+local balance = redis.call('HINCRBY', KEYS[1], 'coins', -price)
+local durability = redis.call('HINCRBY', KEYS[3], ARGV[2], tonumber(ARGV[3]))
+
+local entry = cjson.decode(ARGV[4])
+entry['balance'] = balance
+redis.call('RPUSH', KEYS[2], cjson.encode(entry))
+return {balance, durability}
+`)
+
+// gearScriptFunctions 装备耐久的Lua函数，由抽奖提交脚本调用，与抽奖记录在同一脚本中写入
+//
+// gearUnchanged 校验已装备栏位和各装备耐久与读取时的快照一致；
+// wearGear 按快照扣减耐久，每次抽奖1点，耐久耗尽的装备移除并卸下
+const gearScriptFunctions = `
+local function gearUnchanged(gear, equipped, snapshot)
+	if redis.call('HLEN', equipped) ~= #snapshot then
+		return false
+	end
+	for _, entry in ipairs(snapshot) do
+		if redis.call('HGET', equipped, entry['slot']) ~= entry['gear_id'] then
+			return false
+		end
+		if (tonumber(redis.call('HGET', gear, entry['gear_id'])) or 0) ~= entry['durability'] then
+			return false
+		end
+	end
+	return true
+end
+
+local function wearGear(gear, equipped, snapshot, draws)
+	for _, entry in ipairs(snapshot) do
+		local durability = entry['durability']
+		if durability <= 0 then
+			redis.call('HDEL', equipped, entry['slot'])
+		else
+			local left = durability - math.min(durability, draws)
+			if left == 0 then
+				redis.call('HDEL', gear, entry['gear_id'])
+				redis.call('HDEL', equipped, entry['slot'])
+			else
+				redis.call('HSET', gear, entry['gear_id'], left)
+			end
+		end
+	end
+end
+`
+
+// GearService 装备服务：装备定义来自配置，用户通过金币购买装备，装备后修正抽奖权重并随抽奖损耗
+type GearService struct {
+	redisClient        *redis.Client
+	idempotencyService *IdempotencyService
+	definitions        []*model.GearDefinition
+	byID               map[string]*model.GearDefinition
+}
+
+// NewGearService 创建装备服务，配置文件不存在时没有可用装备
+func NewGearService(idempotencyService *IdempotencyService) (*GearService, error) {
+	var cfg model.GearConfig
+	found, err := config.LoadJSON(GearConfigFile, &cfg)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		log.Printf("%s not found, gear disabled", GearConfigFile)
+	}
+
+	byID := make(map[string]*model.GearDefinition, len(cfg.Gear))
+	for _, def := range cfg.Gear {
+		if def.ID == "" || def.Slot == "" {
+			return nil, fmt.Errorf("gear requires id and slot")
+		}
+		if _, exists := byID[def.ID]; exists {
+			return nil, fmt.Errorf("duplicate gear id %s", def.ID)
+		}
+		if def.Durability <= 0 {
+			return nil, fmt.Errorf("gear %s: durability must be positive", def.ID)
+		}
+		if def.Price < 0 {
+			return nil, fmt.Errorf("gear %s: price must not be negative", def.ID)
+		}
+		if err := validateModifierEffects(def.Effects); err != nil {
+			return nil, fmt.Errorf("gear %s: %w", def.ID, err)
+		}
+		byID[def.ID] = def
+	}
+
+	return &GearService{
+		redisClient:        config.GetRedisClient(),
+		idempotencyService: idempotencyService,
+		definitions:        cfg.Gear,
+		byID:               byID,
+	}, nil
+}
+
+// gearKey 用户持有的装备（装备ID -> 剩余耐久）
+func gearKey(userID string) string {
+	return fmt.Sprintf("gear:%s", userID)
+}
+
+// gearEquippedKey 用户已装备的栏位（栏位 -> 装备ID）
+func gearEquippedKey(userID string) string {
+	return fmt.Sprintf("gear:equipped:%s", userID)
+}
+
+// ListGear 获取所有装备定义
+func (gs *GearService) ListGear() *model.GearListResponse {
+	return &model.GearListResponse{Gear: gs.definitions}
+}
+
+// toUserGear 构造用户装备信息
+func toUserGear(def *model.GearDefinition, durability int, equipped bool) *model.UserGear {
+	return &model.UserGear{
+		GearID:        def.ID,
+		Name:          def.Name,
+		Slot:          def.Slot,
+		Durability:    durability,
+		MaxDurability: def.Durability,
+		Equipped:      equipped,
+		Effects:       def.Effects,
+	}
+}
+
+// GetUserGear 获取用户持有和已装备的装备（按配置顺序）
+func (gs *GearService) GetUserGear(ctx context.Context, userID string) (*model.UserGearResponse, error) {
+	owned, err := gs.redisClient.HGetAll(ctx, gearKey(userID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gear: %w", err)
+	}
+	equipped, err := gs.redisClient.HGetAll(ctx, gearEquippedKey(userID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get equipped gear: %w", err)
+	}
+
+	response := &model.UserGearResponse{
+		UserID:   userID,
+		Gear:     make([]*model.UserGear, 0, len(owned)),
+		Equipped: equipped,
+	}
+	for _, def := range gs.definitions {
+		durability, _ := strconv.Atoi(owned[def.ID])
+		if durability <= 0 {
+			continue
+		}
+		response.Gear = append(response.Gear, toUserGear(def, durability, equipped[def.Slot] == def.ID))
+	}
+
+	return response, nil
+}
+
+// Purchase 用金币购买装备（携带trace_id时幂等）
+func (gs *GearService) Purchase(ctx context.Context, req *model.GearPurchaseRequest) (*model.GearPurchaseResponse, error) {
+	return withIdempotency(ctx, gs.idempotencyService, IdempotencyScopeGearPurchase, req.UserID, req.TraceID, req, func() (*model.GearPurchaseResponse, error) {
+		return gs.purchase(ctx, req)
+	})
+}
+
+// purchase 执行一次购买
+func (gs *GearService) purchase(ctx context.Context, req *model.GearPurchaseRequest) (*model.GearPurchaseResponse, error) {
+	def, exists := gs.byID[req.GearID]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrGearNotFound, req.GearID)
+	}
+	if def.Price <= 0 {
+		return nil, fmt.Errorf("%w: %s", ErrGearNotForSale, req.GearID)
+	}
+
+	entry := newLedgerEntry(req.UserID, model.LedgerTypeGearPurchase, req.TraceID, time.Now())
+	entry.Coins = -def.Price
+	entry.Ref = def.ID
+
+	entryJSON, err := json.Marshal(entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ledger entry: %w", err)
+	}
+
+	result, err := gearPurchaseScript.Run(ctx, gs.redisClient,
+		[]string{walletKey(req.UserID), ledgerKey(req.UserID), gearKey(req.UserID)},
+		def.Price, def.ID, def.Durability, entryJSON,
+	).Int64Slice()
+	if err != nil {
+		if strings.Contains(err.Error(), "insufficient coins") {
+			return nil, ErrInsufficientCoins
+		}
+		return nil, fmt.Errorf("failed to purchase gear: %w", err)
+	}
+	if len(result) != 2 {
+		return nil, fmt.Errorf("unexpected purchase result")
+	}
+
+	equipped, err := gs.redisClient.HGet(ctx, gearEquippedKey(req.UserID), def.Slot).Result()
+	if err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get equipped gear: %w", err)
+	}
+
+	entry.Balance = result[0]
+	return &model.GearPurchaseResponse{
+		Entry: entry,
+		Gear:  toUserGear(def, int(result[1]), equipped == def.ID),
+	}, nil
+}
+
+// Equip 装备持有的装备，替换同栏位已装备的装备
+func (gs *GearService) Equip(ctx context.Context, req *model.GearEquipRequest) (*model.UserGearResponse, error) {
+	def, exists := gs.byID[req.GearID]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrGearNotFound, req.GearID)
+	}
+
+	durability, err := gs.redisClient.HGet(ctx, gearKey(req.UserID), def.ID).Int()
+	if err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get gear: %w", err)
+	}
+	if durability <= 0 {
+		return nil, fmt.Errorf("%w: %s", ErrGearNotOwned, def.ID)
+	}
+
+	if err := gs.redisClient.HSet(ctx, gearEquippedKey(req.UserID), def.Slot, def.ID).Err(); err != nil {
+		return nil, fmt.Errorf("failed to equip gear: %w", err)
+	}
+	return gs.GetUserGear(ctx, req.UserID)
+}
+
+// Unequip 卸下栏位上的装备
+func (gs *GearService) Unequip(ctx context.Context, req *model.GearUnequipRequest) (*model.UserGearResponse, error) {
+	if err := gs.redisClient.HDel(ctx, gearEquippedKey(req.UserID), req.Slot).Err(); err != nil {
+		return nil, fmt.Errorf("failed to unequip gear: %w", err)
+	}
+	return gs.GetUserGear(ctx, req.UserID)
+}
+
+// gearUse 一件装备在本次抽奖请求中的生效情况
+type gearUse struct {
+	def  *model.GearDefinition
+	slot string
+	uses int // 生效的抽奖次数，作用于本次请求的前uses次抽奖
+	left int // 抽奖后的剩余耐久
+}
+
+// gearSnapshot 读取时一个栏位上的装备及其耐久
+type gearSnapshot struct {
+	Slot       string `json:"slot"`
+	GearID     string `json:"gear_id"`
+	Durability int    `json:"durability"`
+}
+
+// gearCharge 一次抽奖请求需要扣减的装备耐久，作为抽奖提交脚本的参数，提交时校验快照未被修改
+type gearCharge struct {
+	Equipped []*gearSnapshot `json:"equipped"` // 读取时所有已装备的栏位
+	Draws    int             `json:"draws"`
+}
+
+// plan 读取已装备装备的耐久，返回提交时需要扣减的耐久和本次生效的装备（按栏位排序），不做任何写入。
+// 已不在配置中的装备照常损耗但不生效
+func (gs *GearService) plan(ctx context.Context, userID string, draws int) (*gearCharge, []*gearUse, error) {
+	equipped, err := gs.redisClient.HGetAll(ctx, gearEquippedKey(userID)).Result()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get equipped gear: %w", err)
+	}
+
+	charge := &gearCharge{Equipped: make([]*gearSnapshot, 0, len(equipped)), Draws: draws}
+	if len(equipped) == 0 {
+		return charge, nil, nil
+	}
+
+	slots := make([]string, 0, len(equipped))
+	gearIDs := make([]string, 0, len(equipped))
+	for slot := range equipped {
+		slots = append(slots, slot)
+	}
+	sort.Strings(slots)
+	for _, slot := range slots {
+		gearIDs = append(gearIDs, equipped[slot])
+	}
+
+	durabilities, err := gs.redisClient.HMGet(ctx, gearKey(userID), gearIDs...).Result()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get gear: %w", err)
+	}
+
+	uses := make([]*gearUse, 0, len(slots))
+	for i, slot := range slots {
+		value, _ := durabilities[i].(string)
+		durability, _ := strconv.Atoi(value)
+		charge.Equipped = append(charge.Equipped, &gearSnapshot{Slot: slot, GearID: gearIDs[i], Durability: durability})
+		if durability <= 0 {
+			continue
+		}
+
+		def, exists := gs.byID[gearIDs[i]]
+		if !exists {
+			continue
+		}
+		n := draws
+		if durability < n {
+			n = durability
+		}
+		uses = append(uses, &gearUse{def: def, slot: slot, uses: n, left: durability - n})
+	}
+
+	return charge, uses, nil
+}
+
+// appliedGear 转换为响应中的生效装备
+func appliedGear(uses []*gearUse) []model.AppliedGear {
+	if len(uses) == 0 {
+		return nil
+	}
+
+	applied := make([]model.AppliedGear, 0, len(uses))
+	for _, use := range uses {
+		applied = append(applied, model.AppliedGear{
+			GearID:         use.def.ID,
+			Name:           use.def.Name,
+			Slot:           use.slot,
+			Draws:          use.uses,
+			DurabilityLeft: use.left,
+			Broken:         use.left == 0,
+		})
+	}
+	return applied
+}
+
+// gearWeights 第index次抽奖叠加装备修正后的权重，没有装备生效时原样返回传入的权重
+func gearWeights(items map[string]*model.LotteryItem, weights map[string]int, uses []*gearUse, index int) (map[string]int, bool) {
+	var adjusted map[string]int
+	for _, use := range uses {
+		if index >= use.uses {
+			continue
+		}
+		if adjusted == nil {
+			adjusted = make(map[string]int, len(weights))
+			for fishID, weight := range weights {
+				adjusted[fishID] = weight
+			}
+		}
+		for _, effect := range use.def.Effects {
+			applyEffect(items, adjusted, effect)
+		}
+	}
+
+	if adjusted == nil {
+		return weights, false
+	}
+	return adjusted, true
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"fishing-game/model"
+)
+
+// newTestGearService 基于miniredis创建装备服务，用户持有coins金币
+func newTestGearService(t *testing.T, coins int64) (*GearService, context.Context) {
+	t.Helper()
+	setupTestRedis(t)
+	ctx := context.Background()
+	gs, err := NewGearService(NewIdempotencyService(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gs.redisClient.HSet(ctx, walletKey(testUserID), "coins", coins).Err(); err != nil {
+		t.Fatal(err)
+	}
+	return gs, ctx
+}
+
+func TestGearPurchaseStacksDurability(t *testing.T) {
+	gs, ctx := newTestGearService(t, 120)
+	worm := gs.byID["bait_worm"]
+
+	for i := 1; i <= 2; i++ {
+		resp, err := gs.Purchase(ctx, &model.GearPurchaseRequest{UserID: testUserID, GearID: worm.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Entry.Balance != 120-int64(i)*worm.Price || resp.Gear.Durability != i*worm.Durability {
+			t.Errorf("purchase %d: balance %d, durability %d", i, resp.Entry.Balance, resp.Gear.Durability)
+		}
+	}
+
+	// 金币不足时不扣款、不增加耐久、不记账
+	if _, err := gs.Purchase(ctx, &model.GearPurchaseRequest{UserID: testUserID, GearID: worm.ID}); !errors.Is(err, ErrInsufficientCoins) {
+		t.Fatalf("expected ErrInsufficientCoins, got %v", err)
+	}
+	if coins, _ := gs.redisClient.HGet(ctx, walletKey(testUserID), "coins").Int64(); coins != 120-2*worm.Price {
+		t.Errorf("coins %d after rejected purchase", coins)
+	}
+	if durability, _ := gs.redisClient.HGet(ctx, gearKey(testUserID), worm.ID).Int(); durability != 2*worm.Durability {
+		t.Errorf("durability %d after rejected purchase", durability)
+	}
+	if n, _ := gs.redisClient.LLen(ctx, ledgerKey(testUserID)).Result(); n != 2 {
+		t.Errorf("%d ledger entries, expected 2", n)
+	}
+}
+
+func TestGearEquipRequiresOwnership(t *testing.T) {
+	gs, ctx := newTestGearService(t, 1000)
+
+	if _, err := gs.Purchase(ctx, &model.GearPurchaseRequest{UserID: testUserID, GearID: "missing"}); !errors.Is(err, ErrGearNotFound) {
+		t.Fatalf("expected ErrGearNotFound, got %v", err)
+	}
+	if _, err := gs.Equip(ctx, &model.GearEquipRequest{UserID: testUserID, GearID: "bait_worm"}); !errors.Is(err, ErrGearNotOwned) {
+		t.Fatalf("expected ErrGearNotOwned, got %v", err)
+	}
+
+	if _, err := gs.Purchase(ctx, &model.GearPurchaseRequest{UserID: testUserID, GearID: "bait_worm"}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := gs.Equip(ctx, &model.GearEquipRequest{UserID: testUserID, GearID: "bait_worm"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Equipped["bait"] != "bait_worm" || len(resp.Gear) != 1 || !resp.Gear[0].Equipped {
+		t.Errorf("equipped %v, gear %+v", resp.Equipped, resp.Gear)
+	}
+}
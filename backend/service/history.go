@@ -0,0 +1,356 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"fishing-game/config"
+	"fishing-game/model"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// 历史记录保留策略
+	HistoryRetentionCount = "count" // 保留最近N条
+	HistoryRetentionAge   = "age"   // 保留最近一段时间
+
+	DefaultHistoryRetentionMode  = HistoryRetentionCount
+	DefaultHistoryRetentionCount = 1000
+	DefaultHistoryRetentionAge   = 90 * 24 * time.Hour
+
+	// 查询历史记录时每次从索引中扫描的条数和最多扫描的轮数
+	historyScanChunk    = 100
+	historyScanMaxRound = 10
+
+	// historyPurgeBatch 每次抽奖后最多清理的过期记录条数
+	historyPurgeBatch = 100
+
+	// 历史记录结果筛选
+	HistoryResultWin  = "win"
+	HistoryResultLoss = "loss"
+)
+
+// ErrInvalidCursor 历史记录游标无法解析
+var ErrInvalidCursor = errors.New("invalid history cursor")
+
+// HistoryRetention 历史记录保留策略
+type HistoryRetention struct {
+	Mode  string        // count 或 age
+	Count int           // count 模式下保留的条数
+	Age   time.Duration // age 模式下保留的时长
+}
+
+// LoadHistoryRetention 从环境变量加载历史记录保留策略
+func LoadHistoryRetention() HistoryRetention {
+	retention := HistoryRetention{
+		Mode:  config.GetEnvString("HISTORY_RETENTION_MODE", DefaultHistoryRetentionMode),
+		Count: config.GetEnvInt("HISTORY_RETENTION_COUNT", DefaultHistoryRetentionCount),
+		Age:   config.GetEnvDuration("HISTORY_RETENTION_AGE", DefaultHistoryRetentionAge),
+	}
+
+	if retention.Mode != HistoryRetentionCount && retention.Mode != HistoryRetentionAge {
+		log.Printf("Invalid HISTORY_RETENTION_MODE %q, using %s", retention.Mode, DefaultHistoryRetentionMode)
+		retention.Mode = DefaultHistoryRetentionMode
+	}
+	if retention.Count <= 0 {
+		retention.Count = DefaultHistoryRetentionCount
+	}
+
+	return retention
+}
+
+// scriptArgs 传给抽奖脚本的保留策略参数：模式及保留条数或时长（毫秒）
+func (hr HistoryRetention) scriptArgs() (string, int64) {
+	if hr.Mode == HistoryRetentionAge {
+		return HistoryRetentionAge, hr.Age.Milliseconds()
+	}
+	return HistoryRetentionCount, int64(hr.Count)
+}
+
+// drawHistoryKey 用户抽奖历史索引（ZSET，score为抽奖时间毫秒，member为抽奖ID）
+func drawHistoryKey(userID string) string {
+	return fmt.Sprintf("lottery:history:%s", userID)
+}
+
+// legacyDrawHistoryKey 旧版用户抽奖记录列表（LIST，最多100条）
+func legacyDrawHistoryKey(userID string) string {
+	return fmt.Sprintf("lottery:draws:%s", userID)
+}
+
+// drawPurgeKey 用户待清理的抽奖记录（ZSET，score为抽奖时间毫秒，member为抽奖ID），超出保留策略的记录先移出历史索引再分批删除
+func drawPurgeKey(userID string) string {
+	return fmt.Sprintf("lottery:purge:%s", userID)
+}
+
+// purgeHistoryScript 删除一批待清理的抽奖记录及其可验证抽奖证明
+//
+// KEYS[1] 用户待清理抽奖记录 KEYS[2] 用户可验证抽奖索引 KEYS[3...] 与抽奖ID一一对应的 (单条抽奖记录key, 抽奖证明key)
+// ARGV[1...] 抽奖ID
+//
+// 返回删除的记录条数
+var purgeHistoryScript = redis.NewScript(`
+local purged = 0
+for i, drawID in ipairs(ARGV) do
+	purged = purged + redis.call('DEL', KEYS[i * 2 + 1])
+	redis.call('DEL', KEYS[i * 2 + 2])
+	redis.call('ZREM', KEYS[2], drawID)
+	redis.call('ZREM', KEYS[1], drawID)
+end
+return purged
+`)
+
+// migrateHistoryScript 将旧版列表中的记录写入新的历史索引并删除旧列表，旧列表已被其他请求迁移时不做任何写入
+//
+// KEYS[1] 旧版抽奖记录列表 KEYS[2] 用户抽奖历史索引 KEYS[3...] 单条抽奖记录key
+// ARGV[1...] 与记录key一一对应的 (抽奖时间毫秒, 抽奖ID, 记录JSON)
+//
+// 返回 1 表示已迁移，0 表示旧列表不存在
+var migrateHistoryScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+local n = 2
+for i = 1, #ARGV, 3 do
+	n = n + 1
+	redis.call('SET', KEYS[n], ARGV[i + 2])
+	redis.call('ZADD', KEYS[2], ARGV[i], ARGV[i + 1])
+end
+redis.call('DEL', KEYS[1])
+return 1
+`)
+
+// historyCursor 历史记录游标：上一页最后一条记录的时间和抽奖ID
+type historyCursor struct {
+	score  int64
+	drawID string
+}
+
+// encode 编码为不透明的游标字符串
+func (hc historyCursor) encode() string {
+	raw := fmt.Sprintf("%d:%s", hc.score, hc.drawID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeHistoryCursor 解析游标字符串
+func decodeHistoryCursor(cursor string) (*historyCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	parts := strings.SplitN(string(raw), ":", 2)
+	if len(parts) != 2 {
+		return nil, ErrInvalidCursor
+	}
+	score, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &historyCursor{score: score, drawID: parts[1]}, nil
+}
+
+// matchesHistoryQuery 记录是否满足鱼类和输赢筛选
+func matchesHistoryQuery(record *model.LotteryRecord, query *model.DrawHistoryQuery) bool {
+	if query.ItemID != "" && record.ItemID != query.ItemID {
+		return false
+	}
+	switch query.Result {
+	case HistoryResultWin:
+		return record.Points > 0
+	case HistoryResultLoss:
+		return record.Points <= 0
+	}
+	return true
+}
+
+// GetUserDrawHistory 按时间倒序分页查询用户抽奖历史，支持时间范围、鱼类和输赢筛选
+func (ls *LotteryService) GetUserDrawHistory(ctx context.Context, query *model.DrawHistoryQuery) (*model.DrawHistoryResponse, error) {
+	if err := ls.migrateLegacyHistory(ctx, query.UserID); err != nil {
+		return nil, err
+	}
+
+	maxScore := "+inf"
+	if query.To != nil {
+		maxScore = strconv.FormatInt(query.To.UnixMilli(), 10)
+	}
+	minScore := "-inf"
+	if query.From != nil {
+		minScore = strconv.FormatInt(query.From.UnixMilli(), 10)
+	}
+
+	var cursor *historyCursor
+	if query.Cursor != "" {
+		c, err := decodeHistoryCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		cursor = c
+		if query.To == nil || c.score < query.To.UnixMilli() {
+			maxScore = strconv.FormatInt(c.score, 10)
+		}
+	}
+
+	key := drawHistoryKey(query.UserID)
+	records := make([]*model.LotteryRecord, 0, query.Limit)
+	var position *historyCursor // 最后一条已检查的记录
+	limitReached := false
+	exhausted := false
+
+	for round := 0; round < historyScanMaxRound && !limitReached; round++ {
+		// Rev+ByScore时go-redis会交换Start和Stop，这里按 min/max 顺序传入
+		entries, err := ls.redisClient.ZRangeArgsWithScores(ctx, redis.ZRangeArgs{
+			Key:     key,
+			Start:   minScore,
+			Stop:    maxScore,
+			ByScore: true,
+			Rev:     true,
+			Count:   historyScanChunk,
+		}).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get history: %w", err)
+		}
+
+		// 同一毫秒可能有多条记录（连抽），按member倒序跳过游标及之前的记录
+		drawIDs := make([]string, 0, len(entries))
+		scores := make([]int64, 0, len(entries))
+		for _, entry := range entries {
+			drawID := entry.Member.(string)
+			score := int64(entry.Score)
+			if cursor != nil && score == cursor.score && drawID >= cursor.drawID {
+				continue
+			}
+			drawIDs = append(drawIDs, drawID)
+			scores = append(scores, score)
+		}
+
+		processed := 0
+		if len(drawIDs) > 0 {
+			keys := make([]string, len(drawIDs))
+			for i, drawID := range drawIDs {
+				keys[i] = drawRecordKey(drawID)
+			}
+			values, err := ls.redisClient.MGet(ctx, keys...).Result()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get history records: %w", err)
+			}
+
+			for i, value := range values {
+				processed++
+				position = &historyCursor{score: scores[i], drawID: drawIDs[i]}
+
+				recordJSON, ok := value.(string)
+				if !ok {
+					continue // 记录已被清理
+				}
+				var record model.LotteryRecord
+				if err := json.Unmarshal([]byte(recordJSON), &record); err != nil {
+					continue // 跳过无法解析的记录
+				}
+				if !matchesHistoryQuery(&record, query) {
+					continue
+				}
+
+				records = append(records, &record)
+				if len(records) >= query.Limit {
+					limitReached = true
+					break
+				}
+			}
+		}
+
+		if len(entries) < historyScanChunk {
+			exhausted = processed == len(drawIDs)
+			break
+		}
+
+		// 下一轮从本轮最后一条之后开始
+		lastEntry := entries[len(entries)-1]
+		cursor = &historyCursor{score: int64(lastEntry.Score), drawID: lastEntry.Member.(string)}
+		maxScore = strconv.FormatInt(cursor.score, 10)
+	}
+
+	response := &model.DrawHistoryResponse{
+		UserID:  query.UserID,
+		Records: records,
+	}
+	if !exhausted && position != nil {
+		response.HasMore = true
+		response.NextCursor = position.encode()
+	}
+
+	return response, nil
+}
+
+// migrateLegacyHistory 将旧版列表中的抽奖记录迁移到新的历史索引（每个用户只执行一次，并发迁移时只有一次生效）
+func (ls *LotteryService) migrateLegacyHistory(ctx context.Context, userID string) error {
+	legacyKey := legacyDrawHistoryKey(userID)
+
+	results, err := ls.redisClient.LRange(ctx, legacyKey, 0, -1).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get legacy history: %w", err)
+	}
+	if len(results) == 0 {
+		return nil
+	}
+
+	keys := []string{legacyKey, drawHistoryKey(userID)}
+	args := make([]interface{}, 0, len(results)*3)
+	for _, result := range results {
+		var record model.LotteryRecord
+		if err := json.Unmarshal([]byte(result), &record); err != nil {
+			continue // 跳过无法解析的记录
+		}
+
+		if record.DrawID == "" {
+			record.DrawID = newDrawID(record.Timestamp)
+		}
+		record.UserID = userID
+
+		recordJSON, err := json.Marshal(&record)
+		if err != nil {
+			return fmt.Errorf("failed to marshal record: %w", err)
+		}
+		keys = append(keys, drawRecordKey(record.DrawID))
+		args = append(args, record.Timestamp.UnixMilli(), record.DrawID, recordJSON)
+	}
+
+	if err := migrateHistoryScript.Run(ctx, ls.redisClient, keys, args...).Err(); err != nil {
+		return fmt.Errorf("failed to migrate legacy history: %w", err)
+	}
+
+	return nil
+}
+
+// purgeHistory 删除一批已移出历史索引的抽奖记录及其证明，每次最多 historyPurgeBatch 条，未删完的留给下一次抽奖
+func (ls *LotteryService) purgeHistory(ctx context.Context, userID string) error {
+	purgeKey := drawPurgeKey(userID)
+	drawIDs, err := ls.redisClient.ZRange(ctx, purgeKey, 0, historyPurgeBatch-1).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get expired draws: %w", err)
+	}
+	if len(drawIDs) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(drawIDs)*2+2)
+	keys = append(keys, purgeKey, fairDrawsKey(userID))
+	args := make([]interface{}, 0, len(drawIDs))
+	for _, drawID := range drawIDs {
+		keys = append(keys, drawRecordKey(drawID), fairProofKey(drawID))
+		args = append(args, drawID)
+	}
+
+	if err := purgeHistoryScript.Run(ctx, ls.redisClient, keys, args...).Err(); err != nil {
+		return fmt.Errorf("failed to purge expired draws: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"fishing-game/model"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestDrawPurgesRecordsBeyondRetention(t *testing.T) {
+	ls, ctx := newTestLotteryService(t)
+	ls.historyRetention = HistoryRetention{Mode: HistoryRetentionCount, Count: 2}
+
+	drawIDs := make([]string, 0, 3)
+	for i := 0; i < 3; i++ {
+		resp, err := ls.Draw(ctx, &model.LotteryDrawRequest{UserID: testUserID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		drawIDs = append(drawIDs, resp.DrawID)
+	}
+
+	history, err := ls.redisClient.ZRange(ctx, drawHistoryKey(testUserID), 0, -1).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("expected 2 history entries, got %v", history)
+	}
+	for _, drawID := range history {
+		if drawID == drawIDs[0] {
+			t.Fatalf("oldest draw %s still in history", drawID)
+		}
+	}
+	if _, err := ls.GetDraw(ctx, drawIDs[0]); !errors.Is(err, ErrDrawNotFound) {
+		t.Errorf("expected purged draw to be not found, got %v", err)
+	}
+	if _, err := ls.GetDraw(ctx, drawIDs[2]); err != nil {
+		t.Errorf("latest draw not found: %v", err)
+	}
+	if n, _ := ls.redisClient.ZCard(ctx, drawPurgeKey(testUserID)).Result(); n != 0 {
+		t.Errorf("purge queue has %d entries, expected 0", n)
+	}
+}
+
+func TestPurgeHistoryIsBounded(t *testing.T) {
+	ls, ctx := newTestLotteryService(t)
+
+	for i := 0; i < historyPurgeBatch+5; i++ {
+		drawID := newDrawID(time.Now())
+		if err := ls.redisClient.Set(ctx, drawRecordKey(drawID), "{}", 0).Err(); err != nil {
+			t.Fatal(err)
+		}
+		if err := ls.redisClient.ZAdd(ctx, drawPurgeKey(testUserID), redis.Z{Score: float64(i), Member: drawID}).Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := ls.purgeHistory(ctx, testUserID); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := ls.redisClient.ZCard(ctx, drawPurgeKey(testUserID)).Result(); n != 5 {
+		t.Errorf("purge queue has %d entries, expected 5", n)
+	}
+	if err := ls.purgeHistory(ctx, testUserID); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := ls.redisClient.ZCard(ctx, drawPurgeKey(testUserID)).Result(); n != 0 {
+		t.Errorf("purge queue has %d entries, expected 0", n)
+	}
+}
+
+func TestMigrateLegacyHistoryRunsOnce(t *testing.T) {
+	ls, ctx := newTestLotteryService(t)
+	if err := migrateHistoryScript.Load(ctx, ls.redisClient).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 旧版记录没有抽奖ID，每次迁移都会生成新的ID
+	for i := 0; i < 3; i++ {
+		recordJSON, err := json.Marshal(&model.LotteryRecord{ItemID: SmallFishID, Timestamp: time.Now()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ls.redisClient.RPush(ctx, legacyDrawHistoryKey(testUserID), recordJSON).Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 第一次迁移读取旧列表后、写入前，另一个请求完成了迁移
+	hook := &faultHook{match: scriptCall(migrateHistoryScript)}
+	hook.onMatch = func() {
+		if hook.hits == 1 {
+			if err := ls.migrateLegacyHistory(ctx, testUserID); err != nil {
+				t.Error(err)
+			}
+		}
+	}
+	ls.redisClient.AddHook(hook)
+	if err := ls.migrateLegacyHistory(ctx, testUserID); err != nil {
+		t.Fatal(err)
+	}
+	if err := ls.migrateLegacyHistory(ctx, testUserID); err != nil {
+		t.Fatal(err)
+	}
+
+	if hook.hits != 2 {
+		t.Errorf("expected 2 migration attempts, got %d", hook.hits)
+	}
+	history, err := ls.redisClient.ZRange(ctx, drawHistoryKey(testUserID), 0, -1).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("expected 3 migrated records, got %d", len(history))
+	}
+	for _, drawID := range history {
+		record, err := ls.GetDraw(ctx, drawID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record.UserID != testUserID {
+			t.Errorf("migrated record user %q, expected %q", record.UserID, testUserID)
+		}
+	}
+	if exists, _ := ls.redisClient.Exists(ctx, legacyDrawHistoryKey(testUserID)).Result(); exists != 0 {
+		t.Error("legacy history not deleted")
+	}
+}
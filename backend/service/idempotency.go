@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"fishing-game/config"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// DefaultIdempotencyWindow 默认去重窗口
+	DefaultIdempotencyWindow = 24 * time.Hour
+
+	// idempotencyPendingLease 处理中的幂等key的租约，请求完成后才延长到去重窗口，
+	// 进程在处理中退出时租约到期即可重试，不必等待整个去重窗口
+	idempotencyPendingLease = 30 * time.Second
+
+	// idempotencyFinishTimeout 保存响应或释放幂等key的超时时间
+	idempotencyFinishTimeout = 5 * time.Second
+
+	// 幂等作用域
+	IdempotencyScopeDraw      = "lottery_draw"
+	IdempotencyScopeBatchDraw = "lottery_batch_draw"
+	IdempotencyScopeIncrement = "ranking_increment"
+)
+
+var (
+	// ErrIdempotencyConflict 同一trace_id携带了不同的请求内容
+	ErrIdempotencyConflict = errors.New("trace_id already used with a different payload")
+	// ErrIdempotencyInProgress 同一trace_id的请求仍在处理中
+	ErrIdempotencyInProgress = errors.New("request with the same trace_id is in progress")
+	// ErrIdempotencyExpired 请求提交前处理中的占用已过期，结果未提交，可由客户端重试
+	ErrIdempotencyExpired = errors.New("request with the same trace_id expired before it committed")
+)
+
+// idempotencyScriptFunctions 业务脚本与幂等key一同提交时使用的Lua函数，拼接在脚本前面
+const idempotencyScriptFunctions = `
+-- idempotencyOwned 幂等key是否仍处于处理中且由本次请求占用
+local function idempotencyOwned(key, owner)
+	local state = redis.call('HMGET', key, 'state', 'owner')
+	return state[1] == 'pending' and state[2] == owner
+end
+
+-- commitIdempotency 与业务写入一同记录请求已提交及重建响应所需的提交信息，并保留到去重窗口结束
+local function commitIdempotency(key, commit, window)
+	redis.call('HSET', key, 'state', 'committed', 'commit', commit)
+	redis.call('PEXPIRE', key, window)
+end
+`
+
+// idempotencyBeginScript 占用幂等key
+//
+// KEYS[1] 幂等key
+// ARGV[1] 请求指纹 ARGV[2] 处理中租约（毫秒） ARGV[3] 本次请求的占用标识
+//
+// 返回 {"new"} / {"conflict"} / {"pending"} / {"done", response} / {"committed", 提交信息}
+var idempotencyBeginScript = redis.NewScript(`
+local fingerprint = redis.call('HGET', KEYS[1], 'fingerprint')
+if not fingerprint then
+	redis.call('HSET', KEYS[1], 'fingerprint', ARGV[1], 'state', 'pending', 'owner', ARGV[3])
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return {'new'}
+end
+if fingerprint ~= ARGV[1] then
+	return {'conflict'}
+end
+local state = redis.call('HGET', KEYS[1], 'state')
+if state == 'committed' then
+	return {'committed', redis.call('HGET', KEYS[1], 'commit')}
+end
+if state ~= 'done' then
+	return {'pending'}
+end
+return {'done', redis.call('HGET', KEYS[1], 'response')}
+`)
+
+// idempotencyCompleteScript 保存响应并将幂等key的过期时间延长到去重窗口
+//
+// KEYS[1] 幂等key
+// ARGV[1] 响应JSON ARGV[2] 去重窗口（毫秒）
+var idempotencyCompleteScript = redis.NewScript(`
+redis.call('HSET', KEYS[1], 'state', 'done', 'response', ARGV[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[2])
+return 1
+`)
+
+// idempotencyAbortScript 请求失败时释放幂等key，仅当key仍处于处理中且由本次请求占用时删除，
+// 已由业务脚本提交的key或已被其他请求占用的key保持不变
+//
+// KEYS[1] 幂等key
+// ARGV[1] 本次请求的占用标识
+//
+// 返回 1 表示已释放，0 表示未释放
+var idempotencyAbortScript = redis.NewScript(idempotencyScriptFunctions + `
+if idempotencyOwned(KEYS[1], ARGV[1]) then
+	redis.call('DEL', KEYS[1])
+	return 1
+end
+return 0
+`)
+
+// IdempotencyService 基于trace_id的幂等服务
+type IdempotencyService struct {
+	redisClient *redis.Client
+	window      time.Duration
+}
+
+// NewIdempotencyService 创建幂等服务
+func NewIdempotencyService(window time.Duration) *IdempotencyService {
+	if window <= 0 {
+		window = DefaultIdempotencyWindow
+	}
+
+	return &IdempotencyService{
+		redisClient: config.GetRedisClient(),
+		window:      window,
+	}
+}
+
+// idempotencyKey 幂等key：idempotency:{scope}:{user_id}:{trace_id}
+func idempotencyKey(scope, userID, traceID string) string {
+	return fmt.Sprintf("idempotency:%s:%s:%s", scope, userID, traceID)
+}
+
+// fingerprint 计算请求内容指纹
+func fingerprint(payload interface{}) (string, error) {
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	sum := sha256.Sum256(payloadJSON)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// idempotencyClaim 首次请求对幂等key的占用，owner 用于在提交和释放时确认key仍由本次请求占用
+type idempotencyClaim struct {
+	key    string
+	owner  string
+	window time.Duration
+}
+
+// idempotencyBegin 占用幂等key的结果：首次请求时 claim 不为空；重复请求时 response 为已保存的响应，
+// 或 commit 为业务脚本已提交但响应尚未保存时记录的提交信息
+type idempotencyBegin struct {
+	claim    *idempotencyClaim
+	response []byte
+	commit   []byte
+}
+
+// begin 占用幂等key
+func (is *IdempotencyService) begin(ctx context.Context, scope, userID, traceID string, payload interface{}) (*idempotencyBegin, error) {
+	fp, err := fingerprint(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	key := idempotencyKey(scope, userID, traceID)
+	owner := uuid.New().String()
+	result, err := idempotencyBeginScript.Run(ctx, is.redisClient, []string{key}, fp, idempotencyPendingLease.Milliseconds(), owner).StringSlice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire idempotency key: %w", err)
+	}
+
+	switch result[0] {
+	case "new":
+		return &idempotencyBegin{claim: &idempotencyClaim{key: key, owner: owner, window: is.window}}, nil
+	case "conflict":
+		return nil, ErrIdempotencyConflict
+	case "pending":
+		return nil, ErrIdempotencyInProgress
+	case "committed":
+		return &idempotencyBegin{commit: []byte(result[1])}, nil
+	default:
+		return &idempotencyBegin{response: []byte(result[1])}, nil
+	}
+}
+
+// Complete 保存响应并保留到去重窗口结束，之后的重复请求直接返回该响应
+func (is *IdempotencyService) Complete(ctx context.Context, scope, userID, traceID string, response interface{}) error {
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Errorf("failed to marshal response: %w", err)
+	}
+
+	key := idempotencyKey(scope, userID, traceID)
+	if err := idempotencyCompleteScript.Run(ctx, is.redisClient, []string{key}, responseJSON, is.window.Milliseconds()).Err(); err != nil {
+		return fmt.Errorf("failed to save idempotent response: %w", err)
+	}
+
+	return nil
+}
+
+// abort 请求失败时释放幂等key，允许客户端重试；业务脚本可能已提交时key已标记为已提交，不会被释放
+func (is *IdempotencyService) abort(ctx context.Context, claim *idempotencyClaim) {
+	if err := idempotencyAbortScript.Run(ctx, is.redisClient, []string{claim.key}, claim.owner).Err(); err != nil {
+		log.Printf("Failed to release idempotency key %s: %v", claim.key, err)
+	}
+}
+
+// withIdempotency 以幂等方式执行fn：traceID为空时直接执行，
+// 重复请求返回首次保存的响应而不会再次执行fn
+func withIdempotency[T any](ctx context.Context, is *IdempotencyService, scope, userID, traceID string, payload interface{}, fn func() (*T, error)) (*T, error) {
+	return withCommittedIdempotency(ctx, is, scope, userID, traceID, payload, func(*idempotencyClaim) (*T, error) {
+		return fn()
+	}, nil)
+}
+
+// withCommittedIdempotency 以幂等方式执行fn，fn 的业务脚本通过 claim 与幂等key一同提交（claim 为空时不做幂等）。
+// 业务脚本已提交但响应未保存（请求在提交后失败或进程退出）时，重复请求通过 replay 从提交信息重建响应而不会再次执行fn
+func withCommittedIdempotency[T any](ctx context.Context, is *IdempotencyService, scope, userID, traceID string, payload interface{}, fn func(claim *idempotencyClaim) (*T, error), replay func(commit []byte) (*T, error)) (*T, error) {
+	if is == nil || traceID == "" {
+		return fn(nil)
+	}
+
+	begin, err := is.begin(ctx, scope, userID, traceID, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if begin.response != nil {
+		var response T
+		if err := json.Unmarshal(begin.response, &response); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal idempotent response: %w", err)
+		}
+		return &response, nil
+	}
+
+	var response *T
+	if begin.commit != nil {
+		if replay == nil {
+			return nil, ErrIdempotencyInProgress
+		}
+		response, err = replay(begin.commit)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		response, err = fn(begin.claim)
+	}
+
+	// 请求的ctx可能已被取消（如客户端断开），保存响应或释放幂等key使用独立的ctx
+	finishCtx, cancel := context.WithTimeout(context.Background(), idempotencyFinishTimeout)
+	defer cancel()
+
+	if err != nil {
+		is.abort(finishCtx, begin.claim)
+		return nil, err
+	}
+
+	if err := is.Complete(finishCtx, scope, userID, traceID, response); err != nil {
+		// 请求已经提交，保存响应失败只记录日志，仍返回已提交的结果
+		log.Printf("Failed to complete idempotency key %s: %v", idempotencyKey(scope, userID, traceID), err)
+	}
+
+	return response, nil
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyPendingLeaseExtendedOnComplete(t *testing.T) {
+	mr := setupTestRedis(t)
+	ctx := context.Background()
+	is := NewIdempotencyService(time.Hour)
+	key := idempotencyKey(IdempotencyScopeDraw, "u1", "t1")
+	payload := map[string]string{"user_id": "u1"}
+
+	if begin, err := is.begin(ctx, IdempotencyScopeDraw, "u1", "t1", payload); err != nil || begin.claim == nil {
+		t.Fatalf("expected new request, got %+v, %v", begin, err)
+	}
+	if ttl := mr.TTL(key); ttl != idempotencyPendingLease {
+		t.Fatalf("pending ttl %v, expected %v", ttl, idempotencyPendingLease)
+	}
+	if _, err := is.begin(ctx, IdempotencyScopeDraw, "u1", "t1", payload); !errors.Is(err, ErrIdempotencyInProgress) {
+		t.Fatalf("expected in progress, got %v", err)
+	}
+
+	if err := is.Complete(ctx, IdempotencyScopeDraw, "u1", "t1", map[string]int{"points": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if ttl := mr.TTL(key); ttl != time.Hour {
+		t.Fatalf("completed ttl %v, expected %v", ttl, time.Hour)
+	}
+	begin, err := is.begin(ctx, IdempotencyScopeDraw, "u1", "t1", payload)
+	if err != nil || string(begin.response) != `{"points":1}` {
+		t.Fatalf("expected stored response, got %+v, %v", begin, err)
+	}
+}
+
+func TestIdempotencyPendingLeaseExpires(t *testing.T) {
+	mr := setupTestRedis(t)
+	ctx := context.Background()
+	is := NewIdempotencyService(time.Hour)
+	payload := map[string]string{"user_id": "u1"}
+
+	if _, err := is.begin(ctx, IdempotencyScopeDraw, "u1", "t1", payload); err != nil {
+		t.Fatal(err)
+	}
+
+	// 处理中的进程退出后，租约到期即可重试
+	mr.FastForward(idempotencyPendingLease)
+	if begin, err := is.begin(ctx, IdempotencyScopeDraw, "u1", "t1", payload); err != nil || begin.claim == nil {
+		t.Fatalf("expected retry to start a new request, got %+v, %v", begin, err)
+	}
+}
+
+func TestWithIdempotencyCompletesAfterRequestCanceled(t *testing.T) {
+	setupTestRedis(t)
+	is := NewIdempotencyService(time.Hour)
+	payload := map[string]string{"user_id": "u1"}
+
+	// 客户端在请求提交后断开，响应仍应被保存
+	ctx, cancel := context.WithCancel(context.Background())
+	response, err := withIdempotency(ctx, is, IdempotencyScopeDraw, "u1", "t1", payload, func() (*map[string]int, error) {
+		cancel()
+		return &map[string]int{"points": 1}, nil
+	})
+	if err != nil || (*response)["points"] != 1 {
+		t.Fatalf("expected committed response, got %v, %v", response, err)
+	}
+
+	begin, err := is.begin(context.Background(), IdempotencyScopeDraw, "u1", "t1", payload)
+	if err != nil || string(begin.response) != `{"points":1}` {
+		t.Fatalf("expected stored response, got %+v, %v", begin, err)
+	}
+}
+
+func TestWithIdempotencyAbortsAfterRequestCanceled(t *testing.T) {
+	setupTestRedis(t)
+	is := NewIdempotencyService(time.Hour)
+	payload := map[string]string{"user_id": "u1"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := withIdempotency(ctx, is, IdempotencyScopeDraw, "u1", "t1", payload, func() (*map[string]int, error) {
+		cancel()
+		return nil, ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+
+	// 失败的请求释放了幂等key，可立即重试
+	if begin, err := is.begin(context.Background(), IdempotencyScopeDraw, "u1", "t1", payload); err != nil || begin.claim == nil {
+		t.Fatalf("expected retry to start a new request, got %+v, %v", begin, err)
+	}
+}
+
+func TestAbortKeepsKeyOwnedByAnotherRequest(t *testing.T) {
+	mr := setupTestRedis(t)
+	ctx := context.Background()
+	is := NewIdempotencyService(time.Hour)
+	payload := map[string]string{"user_id": "u1"}
+
+	first, err := is.begin(ctx, IdempotencyScopeDraw, "u1", "t1", payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 第一个请求的租约过期后被重试占用，第一个请求失败时不应释放重试的占用
+	mr.FastForward(idempotencyPendingLease)
+	if _, err := is.begin(ctx, IdempotencyScopeDraw, "u1", "t1", payload); err != nil {
+		t.Fatal(err)
+	}
+	is.abort(ctx, first.claim)
+	if _, err := is.begin(ctx, IdempotencyScopeDraw, "u1", "t1", payload); !errors.Is(err, ErrIdempotencyInProgress) {
+		t.Fatalf("expected in progress, got %v", err)
+	}
+}
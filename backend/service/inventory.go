@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"fishing-game/config"
+	"fishing-game/model"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// HiddenFishName 图鉴中未发现鱼类显示的名称
+const HiddenFishName = "???"
+
+// 背包hash字段前缀，字段为 {前缀}{鱼类ID}
+const (
+	inventoryCountField  = "count:"  // 当前持有数量
+	inventoryCaughtField = "caught:" // 累计钓到数量
+	inventoryFirstField  = "first:"  // 首次钓到时间（毫秒）
+	inventoryLastField   = "last:"   // 最近钓到时间（毫秒）
+)
+
+// InventoryService 用户背包与图鉴服务。背包在抽奖提交脚本中原子更新
+type InventoryService struct {
+	redisClient *redis.Client
+	poolService *PoolService
+}
+
+// NewInventoryService 创建背包服务
+func NewInventoryService(poolService *PoolService) *InventoryService {
+	return &InventoryService{
+		redisClient: config.GetRedisClient(),
+		poolService: poolService,
+	}
+}
+
+// inventoryKey 用户背包key
+func inventoryKey(userID string) string {
+	return fmt.Sprintf("inventory:%s", userID)
+}
+
+// inventoryEntry 背包中一种鱼的原始数据
+type inventoryEntry struct {
+	count       int
+	totalCaught int
+	firstCaught time.Time
+	lastCaught  time.Time
+}
+
+// getInventoryEntries 读取并解析用户背包
+func (is *InventoryService) getInventoryEntries(ctx context.Context, userID string) (map[string]*inventoryEntry, error) {
+	fields, err := is.redisClient.HGetAll(ctx, inventoryKey(userID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inventory: %w", err)
+	}
+
+	entries := make(map[string]*inventoryEntry)
+	entry := func(itemID string) *inventoryEntry {
+		if e, exists := entries[itemID]; exists {
+			return e
+		}
+		e := &inventoryEntry{}
+		entries[itemID] = e
+		return e
+	}
+
+	for field, value := range fields {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(field, inventoryCountField):
+			entry(strings.TrimPrefix(field, inventoryCountField)).count = int(n)
+		case strings.HasPrefix(field, inventoryCaughtField):
+			entry(strings.TrimPrefix(field, inventoryCaughtField)).totalCaught = int(n)
+		case strings.HasPrefix(field, inventoryFirstField):
+			entry(strings.TrimPrefix(field, inventoryFirstField)).firstCaught = time.UnixMilli(n)
+		case strings.HasPrefix(field, inventoryLastField):
+			entry(strings.TrimPrefix(field, inventoryLastField)).lastCaught = time.UnixMilli(n)
+		}
+	}
+
+	return entries, nil
+}
+
+// GetInventory 获取用户背包（当前持有的鱼）
+func (is *InventoryService) GetInventory(ctx context.Context, userID string) (*model.InventoryResponse, error) {
+	entries, err := is.getInventoryEntries(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	poolItems, err := is.poolService.GetItems(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool: %w", err)
+	}
+
+	response := &model.InventoryResponse{
+		UserID: userID,
+		Items:  make([]*model.InventoryItem, 0, len(entries)),
+	}
+	for itemID, entry := range entries {
+		if entry.count <= 0 {
+			continue
+		}
+
+		inventoryItem := &model.InventoryItem{
+			ItemID:      itemID,
+			Count:       entry.count,
+			TotalCaught: entry.totalCaught,
+		}
+		if !entry.firstCaught.IsZero() {
+			firstCaught, lastCaught := entry.firstCaught, entry.lastCaught
+			inventoryItem.FirstCaughtAt = &firstCaught
+			inventoryItem.LastCaughtAt = &lastCaught
+		}
+		if item, exists := poolItems[itemID]; exists {
+			inventoryItem.ItemName = item.Name
+			inventoryItem.Description = item.Description
+			inventoryItem.Points = item.Points
+			inventoryItem.ImageURL = item.ImageURL
+			inventoryItem.IsUserFish = item.IsUserFish
+		}
+
+		response.Items = append(response.Items, inventoryItem)
+		response.TotalCount += entry.count
+	}
+
+	sort.Slice(response.Items, func(i, j int) bool {
+		a, b := entries[response.Items[i].ItemID], entries[response.Items[j].ItemID]
+		if !a.lastCaught.Equal(b.lastCaught) {
+			return a.lastCaught.After(b.lastCaught)
+		}
+		return response.Items[i].ItemID < response.Items[j].ItemID
+	})
+
+	return response, nil
+}
+
+// GetFishdex 获取用户图鉴：奖池中除空军外的所有鱼类，钓到过或当前持有（如交易获得）即视为已发现
+func (is *InventoryService) GetFishdex(ctx context.Context, userID string) (*model.FishdexResponse, error) {
+	entries, err := is.getInventoryEntries(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	poolItems, err := is.poolService.GetItems(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool: %w", err)
+	}
+
+	items := make([]*model.LotteryItem, 0, len(poolItems))
+	for _, item := range poolItems {
+		if item.ID == EmptyFishID {
+			continue
+		}
+		items = append(items, item)
+	}
+	// 系统鱼在前，按积分升序；用户鱼按ID排序
+	sort.Slice(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if a.IsUserFish != b.IsUserFish {
+			return !a.IsUserFish
+		}
+		if a.Points != b.Points {
+			return a.Points < b.Points
+		}
+		return a.ID < b.ID
+	})
+
+	response := &model.FishdexResponse{
+		UserID:       userID,
+		TotalSpecies: len(items),
+		Species:      make([]*model.FishdexEntry, 0, len(items)),
+	}
+	for _, item := range items {
+		entry := &model.FishdexEntry{
+			ItemID:     item.ID,
+			ItemName:   HiddenFishName,
+			IsUserFish: item.IsUserFish,
+		}
+
+		if inv, exists := entries[item.ID]; exists && (inv.totalCaught > 0 || inv.count > 0) {
+			entry.Discovered = true
+			entry.ItemName = item.Name
+			entry.Description = item.Description
+			entry.ImageURL = item.ImageURL
+			entry.Points = item.Points
+			entry.TotalCaught = inv.totalCaught
+			if !inv.firstCaught.IsZero() {
+				firstCaught := inv.firstCaught
+				entry.FirstCaughtAt = &firstCaught
+			}
+			response.DiscoveredSpecies++
+		}
+
+		response.Species = append(response.Species, entry)
+	}
+
+	if response.TotalSpecies > 0 {
+		percent := float64(response.DiscoveredSpecies) / float64(response.TotalSpecies) * 100
+		response.CompletionPercent = math.Round(percent*100) / 100
+	}
+
+	return response, nil
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"testing"
+
+	"fishing-game/model"
+)
+
+func TestDrawUpdatesInventoryAndFishdex(t *testing.T) {
+	ls, ctx := newTestLotteryService(t)
+	is := NewInventoryService(ls.poolService)
+
+	// 第一次提交前模拟并发抽奖修改了保底计数，重新选鱼后背包只按最终结果计入一次
+	hook := &faultHook{match: scriptCall(drawScript)}
+	hook.onMatch = func() {
+		if hook.hits == 1 {
+			if err := ls.redisClient.Set(ctx, pityKey(testUserID), 3, 0).Err(); err != nil {
+				t.Error(err)
+			}
+		}
+	}
+	ls.redisClient.AddHook(hook)
+
+	resp, err := ls.BatchDraw(ctx, &model.LotteryBatchDrawRequest{UserID: testUserID, Count: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hook.hits != 2 {
+		t.Fatalf("expected 2 commit attempts, got %d", hook.hits)
+	}
+
+	caught := make(map[string]int)
+	total := 0
+	for _, result := range resp.Results {
+		if result.ItemID != EmptyFishID {
+			caught[result.ItemID]++
+			total++
+		}
+	}
+
+	inventory, err := is.GetInventory(ctx, testUserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inventory.TotalCount != total || len(inventory.Items) != len(caught) {
+		t.Fatalf("inventory %d fish in %d species, expected %d in %d", inventory.TotalCount, len(inventory.Items), total, len(caught))
+	}
+	for _, item := range inventory.Items {
+		if item.Count != caught[item.ItemID] || item.TotalCaught != caught[item.ItemID] || item.FirstCaughtAt == nil {
+			t.Errorf("inventory item %+v, expected %d caught", item, caught[item.ItemID])
+		}
+	}
+
+	fishdex, err := is.GetFishdex(ctx, testUserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fishdex.DiscoveredSpecies != len(caught) {
+		t.Errorf("discovered %d species, expected %d", fishdex.DiscoveredSpecies, len(caught))
+	}
+	for _, entry := range fishdex.Species {
+		if entry.ItemID == EmptyFishID {
+			t.Error("empty catch listed in fishdex")
+		}
+		if _, found := caught[entry.ItemID]; found != entry.Discovered {
+			t.Errorf("fishdex entry %s discovered %v, expected %v", entry.ItemID, entry.Discovered, found)
+		}
+		if !entry.Discovered && entry.ItemName != HiddenFishName {
+			t.Errorf("undiscovered fish %s shows name %q", entry.ItemID, entry.ItemName)
+		}
+	}
+}
@@ -4,175 +4,1033 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"math/big"
+	"sort"
+	"strings"
 	"time"
 
 	"fishing-game/config"
 	"fishing-game/model"
 
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 )
 
 type LotteryService struct {
-	redisClient    *redis.Client
-	rankingService *RankingService
-	poolService    *PoolService
+	redisClient        *redis.Client
+	rankingService     *RankingService
+	poolService        *PoolService
+	idempotencyService *IdempotencyService
+	staminaService     *StaminaService
+	fairnessService    *FairnessService
+	gearService        *GearService
+	eventService       *PoolEventService
+	tournamentService  *TournamentService
+	modifiers          *ModifierPipeline
+	pityConfig         PityConfig
+	historyRetention   HistoryRetention
+	listeners          []DrawListener
 }
 
 // NewLotteryService 创建抽奖服务
-func NewLotteryService(rankingService *RankingService, poolService *PoolService) (*LotteryService, error) {
+func NewLotteryService(rankingService *RankingService, poolService *PoolService, idempotencyService *IdempotencyService, staminaService *StaminaService, fairnessService *FairnessService, gearService *GearService, eventService *PoolEventService, tournamentService *TournamentService) (*LotteryService, error) {
+	modifiers, err := LoadModifierPipeline()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load draw modifiers: %w", err)
+	}
+
 	ls := &LotteryService{
-		redisClient:    config.GetRedisClient(),
-		rankingService: rankingService,
-		poolService:    poolService,
+		redisClient:        config.GetRedisClient(),
+		rankingService:     rankingService,
+		poolService:        poolService,
+		idempotencyService: idempotencyService,
+		staminaService:     staminaService,
+		fairnessService:    fairnessService,
+		gearService:        gearService,
+		eventService:       eventService,
+		tournamentService:  tournamentService,
+		modifiers:          modifiers,
+		pityConfig:         LoadPityConfig(),
+		historyRetention:   LoadHistoryRetention(),
 	}
 
 	return ls, nil
 }
 
-// Draw 执行抽奖
+const (
+	// MaxBatchDrawCount 单次连抽的最大次数
+	MaxBatchDrawCount = 10
+
+	// 抽奖策略名称
+	StrategyDefault   = "default"
+	StrategyGuarantee = "guarantee"
+
+	// drawMaxRetries 选鱼所依据的状态被并发修改时的最大重试次数
+	drawMaxRetries = 3
+)
+
+var (
+	// ErrDrawNotFound 抽奖记录不存在
+	ErrDrawNotFound = errors.New("draw not found")
+	// ErrDrawConflict 多次重试后选鱼所依据的状态（体力、加成、装备、nonce等）仍被并发修改
+	ErrDrawConflict = errors.New("draw conflicted with a concurrent update")
+)
+
+// drawScript 原子抽奖提交脚本：选鱼在服务端完成后，由脚本校验选鱼所依据的状态未被并发修改，
+// 再一次性扣减体力、加成、装备耐久和nonce，写入抽奖记录和可验证抽奖证明、增加榜单和比赛积分、更新保底计数、背包、渔获纪录和鱼类被钓累计，
+// 抽奖要么全部提交要么不做任何写入，避免进程在中途退出时出现"有记录无积分"或"扣了体力没有记录"的不一致。
+// 连抽时所有记录一次写入，积分合并为一次ZINCRBY。
+//
+// KEYS[1] 奖池鱼类 KEYS[2] 用户抽奖历史索引 KEYS[3] 全局榜单 KEYS[4] 用户保底计数 KEYS[5] 用户抽奖概况
+// KEYS[6] 用户背包 KEYS[7] 用户个人纪录 KEYS[8] 全服纪录 KEYS[9] 用户体力 KEYS[10] 用户下一次抽奖加成
+// KEYS[11] 用户装备 KEYS[12] 用户已装备栏位 KEYS[13] 用户可验证抽奖种子 KEYS[14] 用户可验证抽奖索引
+// KEYS[15] 用户待清理抽奖记录 KEYS[16] 幂等key（未携带trace_id时不使用） KEYS[17] 用户参加的比赛
+// KEYS[18...] 按抽奖顺序排列的 (单条抽奖记录key, 抽奖证明key, 鱼类被钓累计, 钓到过该鱼类的玩家)，
+// 之后为按比赛快照顺序排列的 (比赛, 玩家所在分组榜单（不计分时为空）)
+// ARGV[1] 榜单成员 ARGV[2] 记录时间戳 ARGV[3] 记录时间（毫秒）
+// ARGV[4] 历史保留模式（count/age） ARGV[5] 保留条数或保留时长（毫秒）
+// ARGV[6] 稀有鱼ID ARGV[7] 用户ID ARGV[8] trace_id ARGV[9] 空军ID（不入背包）
+// ARGV[10] 钓点ID ARGV[11] drawGuard JSON ARGV[12...] 按抽奖顺序排列的 (抽奖ID, 鱼类ID, 策略名称, 积分, 体长, 体重)
+//
+// 按体重比较纪录：个人纪录仅在超过已有纪录时视为打破，全服纪录首次产生即视为打破。
+// 返回 {'ok', 按抽奖顺序排列的选中鱼类JSON列表, 按抽奖顺序排列的打破纪录列表（逗号分隔）}；
+// 读取的状态已被修改时返回 {'conflict'}；体力或每日次数不足时返回 {'stamina' / 'quota', 体力, 体力更新时间, 今日已用次数}；
+// 幂等key已不属于本次请求（处理中的占用已过期）时返回 {'expired'}。
+// 携带trace_id时幂等key与抽奖一同标记为已提交，并保存重建响应所需的提交信息
+var drawScript = redis.NewScript(staminaScriptFunctions + gearScriptFunctions + idempotencyScriptFunctions + tournamentScriptFunctions + `
+local guard = cjson.decode(ARGV[11])
+local draws = (#ARGV - 11) / 6
+
+-- drawKey 第n次抽奖的第offset个key
+local function drawKey(n, offset)
+	return KEYS[17 + (n - 1) * 4 + offset]
+end
+
+-- tournamentKey 第i场比赛的第offset个key
+local function tournamentKey(i, offset)
+	return KEYS[17 + draws * 4 + (i - 1) * 2 + offset]
+end
+
+if guard['idempotency'] and not idempotencyOwned(KEYS[16], guard['idempotency']['owner']) then
+	return {'expired'}
+end
+
+-- 校验选鱼所依据的状态未被并发修改，保底计数和累计抽奖次数决定了本次的保底调整和策略输入
+if tonumber(redis.call('GET', KEYS[4]) or '0') ~= guard['streak'] then
+	return {'conflict'}
+end
+if (tonumber(redis.call('HGET', KEYS[5], 'total_draws')) or 0) ~= guard['total_draws'] then
+	return {'conflict'}
+end
+if guard['boost'] and redis.call('GET', KEYS[10]) ~= guard['boost'] then
+	return {'conflict'}
+end
+if guard['gear'] and not gearUnchanged(KEYS[11], KEYS[12], guard['gear']['equipped']) then
+	return {'conflict'}
+end
+if guard['fair'] then
+	local state = redis.call('HMGET', KEYS[13], 'epoch', 'nonce')
+	if tonumber(state[1]) ~= guard['fair']['epoch'] or tonumber(state[2]) ~= guard['fair']['nonce'] then
+		return {'conflict'}
+	end
+end
+if guard['tournaments'] and not tournamentsUnchanged(KEYS[17], guard['tournaments'], tournamentKey) then
+	return {'conflict'}
+end
+
+local items = {}
+for i = 12, #ARGV, 6 do
+	local itemJSON = redis.call('HGET', KEYS[1], ARGV[i + 1])
+	if not itemJSON then
+		return redis.error_reply('item ' .. ARGV[i + 1] .. ' not found in pool')
+	end
+	items[#items + 1] = itemJSON
+end
+if #items == 0 then
+	return redis.error_reply('no item selected')
+end
+
+-- 扣减体力是最后一项校验，不足时不做任何写入
+if guard['stamina'] then
+	local stamina = consumeStamina(KEYS[9], guard['stamina'])
+	if stamina[1] == 'stamina' or stamina[1] == 'quota' then
+		return stamina
+	end
+end
+if guard['boost'] then
+	redis.call('DEL', KEYS[10])
+end
+if guard['gear'] then
+	wearGear(KEYS[11], KEYS[12], guard['gear']['equipped'], guard['gear']['draws'])
+end
+if guard['fair'] then
+	redis.call('HINCRBY', KEYS[13], 'nonce', guard['fair']['used'])
+end
+
+local records = {}
+local totalPoints = 0
+local streak = guard['streak']
+local broken = {}
+for i = 12, #ARGV, 6 do
+	local drawID = ARGV[i]
+	local id = ARGV[i + 1]
+	local item = cjson.decode(items[#records + 1])
+	local points = tonumber(ARGV[i + 3]) or 0
+	local length = tonumber(ARGV[i + 4]) or 0
+	local weight = tonumber(ARGV[i + 5]) or 0
+	totalPoints = totalPoints + points
+
+	if id == ARGV[6] or item['is_user_fish'] == true then
+		streak = 0
+	else
+		streak = streak + 1
+	end
+
+	local record = cjson.encode({
+		draw_id = drawID,
+		user_id = ARGV[7],
+		trace_id = ARGV[8],
+		spot = ARGV[10],
+		item_id = item['id'],
+		item_name = item['name'],
+		description = item['description'],
+		wx_id = item['wx_id'],
+		points = points,
+		length_cm = length,
+		weight_kg = weight,
+		strategy = ARGV[i + 2],
+		timestamp = ARGV[2],
+	})
+	records[#records + 1] = record
+	local recordKey = drawKey(#records, 1)
+	redis.call('SET', recordKey, record)
+	if ARGV[4] == 'age' then
+		redis.call('PEXPIRE', recordKey, ARGV[5])
+	end
+	redis.call('ZADD', KEYS[2], ARGV[3], drawID)
+
+	-- 证明与抽奖记录同时写入并保留相同时长
+	if guard['fair'] then
+		local proofKey = drawKey(#records, 2)
+		redis.call('SET', proofKey, guard['fair']['proofs'][#records])
+		if ARGV[4] == 'age' then
+			redis.call('PEXPIRE', proofKey, ARGV[5])
+		end
+		redis.call('ZADD', KEYS[14], guard['fair']['epoch'], drawID)
+	end
+
+	-- 鱼类被钓累计及钓到过的玩家（精确去重）
+	redis.call('HINCRBY', drawKey(#records, 3), 'total', 1)
+	redis.call('HSETNX', drawKey(#records, 3), 'first_caught_at', ARGV[3])
+	redis.call('HSET', drawKey(#records, 3), 'last_caught_at', ARGV[3])
+	redis.call('SADD', drawKey(#records, 4), ARGV[7])
+
+	if id ~= ARGV[9] then
+		redis.call('HINCRBY', KEYS[6], 'count:' .. id, 1)
+		redis.call('HINCRBY', KEYS[6], 'caught:' .. id, 1)
+		redis.call('HSETNX', KEYS[6], 'first:' .. id, ARGV[3])
+		redis.call('HSET', KEYS[6], 'last:' .. id, ARGV[3])
+	end
+
+	local drawBroken = {}
+	if weight > 0 then
+		local catch = cjson.encode({
+			item_id = id,
+			item_name = item['name'],
+			user_id = ARGV[7],
+			draw_id = drawID,
+			length_cm = length,
+			weight_kg = weight,
+			points = points,
+			caught_at = ARGV[2],
+		})
+		local personal = redis.call('HGET', KEYS[7], id)
+		if not personal or weight > tonumber(cjson.decode(personal)['weight_kg']) then
+			redis.call('HSET', KEYS[7], id, catch)
+			if personal then
+				drawBroken[#drawBroken + 1] = 'personal'
+			end
+		end
+		local global = redis.call('HGET', KEYS[8], id)
+		if not global or weight > tonumber(cjson.decode(global)['weight_kg']) then
+			redis.call('HSET', KEYS[8], id, catch)
+			drawBroken[#drawBroken + 1] = 'global'
+		end
+	end
+	broken[#broken + 1] = table.concat(drawBroken, ',')
+end
+
+-- 按保留策略将超出的记录移出历史索引，放入待清理队列，记录由提交后的清理分批删除
+local expired = {}
+if ARGV[4] == 'age' then
+	local cutoff = tonumber(ARGV[3]) - tonumber(ARGV[5])
+	expired = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', '(' .. cutoff, 'WITHSCORES')
+	redis.call('ZREMRANGEBYSCORE', KEYS[2], '-inf', '(' .. cutoff)
+else
+	local keep = tonumber(ARGV[5])
+	expired = redis.call('ZRANGE', KEYS[2], 0, -keep - 1, 'WITHSCORES')
+	redis.call('ZREMRANGEBYRANK', KEYS[2], 0, -keep - 1)
+end
+for i = 1, #expired, 2 do
+	redis.call('ZADD', KEYS[15], expired[i + 1], expired[i])
+end
+
+if totalPoints > 0 then
+	redis.call('ZINCRBY', KEYS[3], totalPoints, ARGV[1])
+	if guard['tournaments'] then
+		scoreTournaments(guard['tournaments'], tournamentKey, ARGV[7], totalPoints)
+	end
+end
+
+redis.call('SET', KEYS[4], streak)
+redis.call('HSET', KEYS[5], 'last_item_id', ARGV[#ARGV - 4])
+redis.call('HINCRBY', KEYS[5], 'total_draws', #items)
+
+-- 提交信息由服务端序列化，这里追加各次抽奖打破的纪录
+if guard['idempotency'] then
+	local commit = guard['idempotency']['commit']
+	commit = string.sub(commit, 1, -2) .. ',"broken":' .. cjson.encode(broken) .. '}'
+	commitIdempotency(KEYS[16], commit, guard['idempotency']['window'])
+end
+
+return {'ok', items, broken}
+`)
+
+// Draw 执行抽奖（携带trace_id时幂等，重复请求返回首次抽奖结果）
 func (ls *LotteryService) Draw(ctx context.Context, req *model.LotteryDrawRequest) (*model.LotteryDrawResponse, error) {
-	// 从Redis获取奖池信息
-	pool, err := ls.poolService.GetPool(ctx)
+	return withCommittedIdempotency(ctx, ls.idempotencyService, IdempotencyScopeDraw, req.UserID, req.TraceID, req, func(claim *idempotencyClaim) (*model.LotteryDrawResponse, error) {
+		return ls.draw(ctx, req, claim)
+	}, func(commit []byte) (*model.LotteryDrawResponse, error) {
+		return ls.replayDraw(ctx, req.UserID, commit)
+	})
+}
+
+// draw 执行一次抽奖
+func (ls *LotteryService) draw(ctx context.Context, req *model.LotteryDrawRequest, claim *idempotencyClaim) (*model.LotteryDrawResponse, error) {
+	spot, err := ls.poolService.resolveSpot(req.Spot)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	outcome, err := ls.drawItems(ctx, &drawParams{
+		userID:   req.UserID,
+		spot:     spot,
+		traceID:  req.TraceID,
+		strategy: req.Strategy,
+		context:  req.Context,
+		count:    1,
+		now:      now,
+		claim:    claim,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 构造响应
+	response := &model.LotteryDrawResponse{
+		DrawID:    outcome.selections[0].drawID,
+		UserID:    req.UserID,
+		Spot:      spot,
+		Result:    outcome.selections[0].toLotteryResult(),
+		Modifiers: outcome.modifiers,
+		Gear:      outcome.gear,
+		CreatedAt: now,
+	}
+
+	return response, nil
+}
+
+// BatchDraw 执行连抽（携带trace_id时幂等）
+func (ls *LotteryService) BatchDraw(ctx context.Context, req *model.LotteryBatchDrawRequest) (*model.LotteryBatchDrawResponse, error) {
+	return withCommittedIdempotency(ctx, ls.idempotencyService, IdempotencyScopeBatchDraw, req.UserID, req.TraceID, req, func(claim *idempotencyClaim) (*model.LotteryBatchDrawResponse, error) {
+		return ls.batchDraw(ctx, req, claim)
+	}, func(commit []byte) (*model.LotteryBatchDrawResponse, error) {
+		return ls.replayBatchDraw(ctx, req.UserID, commit)
+	})
+}
+
+// batchDraw 执行一次连抽，所有结果在同一个脚本中原子写入
+func (ls *LotteryService) batchDraw(ctx context.Context, req *model.LotteryBatchDrawRequest, claim *idempotencyClaim) (*model.LotteryBatchDrawResponse, error) {
+	if req.Count <= 0 || req.Count > MaxBatchDrawCount {
+		return nil, fmt.Errorf("invalid draw count: %d, expected 1-%d", req.Count, MaxBatchDrawCount)
+	}
+	if ls.staminaService != nil {
+		if err := ls.staminaService.checkDrawCost(req.Count); err != nil {
+			return nil, err
+		}
+	}
+
+	spot, err := ls.poolService.resolveSpot(req.Spot)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	batchID := newDrawID(now) // 批次ID，每条结果另有自己的抽奖ID
+
+	outcome, err := ls.drawItems(ctx, &drawParams{
+		userID:    req.UserID,
+		spot:      spot,
+		traceID:   req.TraceID,
+		strategy:  req.Strategy,
+		context:   req.Context,
+		count:     req.Count,
+		guarantee: req.Guarantee,
+		now:       now,
+		batchID:   batchID,
+		claim:     claim,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]model.LotteryResult, 0, len(outcome.selections))
+	totalPoints := 0
+	for _, selection := range outcome.selections {
+		results = append(results, selection.toLotteryResult())
+		totalPoints += selection.points()
+	}
+
+	return &model.LotteryBatchDrawResponse{
+		DrawID:      batchID,
+		UserID:      req.UserID,
+		Spot:        spot,
+		Results:     results,
+		TotalPoints: totalPoints,
+		Modifiers:   outcome.modifiers,
+		Gear:        outcome.gear,
+		CreatedAt:   now,
+	}, nil
+}
+
+// drawSelection 一次选鱼结果
+type drawSelection struct {
+	drawID   string
+	item     *model.LotteryItem
+	strategy string
+	proof    *model.FairProof   // 可验证模式下的抽奖证明
+	replaced []*model.FairProof // 可验证模式下被替换的结果的证明，按nonce顺序排列
+	size     *catchSize         // 渔获尺寸，鱼类没有尺寸分布时为空
+	records  []string           // 本次打破的纪录
+	weights  map[string]int     // 本次选鱼实际使用的权重，出鱼统计按此累计期望次数
+	fallback map[string]int     // 保底替换空军时使用的权重，批次前面全是空军时空军的概率按此重新分配
+}
+
+// proofs 本次抽奖使用的所有nonce的证明（被替换的结果在前），均关联到本次抽奖ID
+func (s *drawSelection) proofs() []*model.FairProof {
+	proofs := make([]*model.FairProof, 0, len(s.replaced)+1)
+	proofs = append(proofs, s.replaced...)
+	if s.proof != nil {
+		proofs = append(proofs, s.proof)
+	}
+	for _, proof := range proofs {
+		proof.DrawID = s.drawID
+	}
+	return proofs
+}
+
+// probabilities 本次选鱼时各鱼类的实际概率
+func (s *drawSelection) probabilities() map[string]float64 {
+	probabilities := weightProbabilities(s.weights)
+	if s.fallback == nil {
+		return probabilities
+	}
+
+	fallback := weightProbabilities(s.fallback)
+	if len(fallback) == 0 {
+		return probabilities
+	}
+	emptyProbability := probabilities[EmptyFishID]
+	delete(probabilities, EmptyFishID)
+	for fishID, p := range fallback {
+		probabilities[fishID] += emptyProbability * p
+	}
+	return probabilities
+}
+
+// weightProbabilities 按权重计算各鱼类的概率，忽略权重不为正的鱼类
+func weightProbabilities(weights map[string]int) map[string]float64 {
+	total := 0
+	for _, weight := range weights {
+		if weight > 0 {
+			total += weight
+		}
+	}
+
+	probabilities := make(map[string]float64, len(weights))
+	if total <= 0 {
+		return probabilities
+	}
+	for fishID, weight := range weights {
+		if weight > 0 {
+			probabilities[fishID] = float64(weight) / float64(total)
+		}
+	}
+	return probabilities
+}
+
+// points 本次实际获得的积分（按尺寸缩放后）
+func (s *drawSelection) points() int {
+	if s.size != nil {
+		return s.size.points
+	}
+	return s.item.Points
+}
+
+// toLotteryResult 将选鱼结果转换为抽奖结果
+func (s *drawSelection) toLotteryResult() model.LotteryResult {
+	result := toLotteryResult(s.item)
+	result.DrawID = s.drawID
+	result.Points = s.points()
+	result.Records = s.records
+	if s.size != nil {
+		result.LengthCM = s.size.lengthCM
+		result.WeightKG = s.size.weightKG
+	}
+	if s.proof != nil {
+		// 响应中不返回完整权重，可通过证明接口查询
+		proof := *s.proof
+		proof.Weights = nil
+		result.Fair = &proof
+	}
+	return result
+}
+
+// drawOutcome 一次抽奖请求的结果
+type drawOutcome struct {
+	selections []*drawSelection        // 按抽奖顺序排列的选鱼结果
+	modifiers  []model.AppliedModifier // 生效的Context修正
+	gear       []model.AppliedGear     // 生效的装备
+}
+
+// drawParams 抽奖参数
+type drawParams struct {
+	userID    string
+	spot      string // 已解析的钓点ID
+	traceID   string
+	strategy  string // 指定的策略，为空时使用奖池默认策略
+	context   map[string]interface{}
+	count     int
+	guarantee bool
+	now       time.Time
+	batchID   string            // 连抽的批次ID，单抽时为空
+	claim     *idempotencyClaim // 幂等key的占用，与抽奖一同提交，未携带trace_id时为空
+}
+
+// drawItems 选鱼并提交，选鱼所依据的状态在提交前被并发修改时重新读取并选鱼
+func (ls *LotteryService) drawItems(ctx context.Context, params *drawParams) (*drawOutcome, error) {
+	var err error
+	for attempt := 0; attempt < drawMaxRetries; attempt++ {
+		var outcome *drawOutcome
+		outcome, err = ls.selectAndCommit(ctx, params)
+		if errors.Is(err, ErrDrawConflict) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return outcome, nil
+	}
+	return nil, err
+}
+
+// selectAndCommit 读取奖池和用户状态选出count条鱼，再由提交脚本原子扣减体力、加成、装备耐久和nonce并保存记录、增加积分。
+// 提交前不做任何写入，读取的状态被并发修改时返回 ErrDrawConflict
+func (ls *LotteryService) selectAndCommit(ctx context.Context, params *drawParams) (*drawOutcome, error) {
+	// 获取奖池快照（奖池未变更时不访问奖池数据）
+	snapshot, err := ls.poolService.GetSnapshot(ctx, params.spot)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get pool: %w", err)
 	}
+	pool := snapshot.Pool
+	if pool.TotalWeight != TotalWeight {
+		return nil, fmt.Errorf("invalid total weight: %d, expected %d", pool.TotalWeight, TotalWeight)
+	}
 
-	// 根据权重随机选择奖品
-	_, selectedItem, err := ls.weightedRandomSelect(pool)
+	// 请求指定的策略优先，其次为奖池默认策略
+	strategyName := params.strategy
+	if strategyName == "" {
+		strategyName = pool.Strategy
+	}
+	strategy, err := DrawStrategies.Get(strategyName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to select item: %w", err)
+		return nil, err
 	}
 
-	// 生成抽奖ID
-	drawID := fmt.Sprintf("d_%s_%d", time.Now().Format("20060102"), time.Now().UnixNano())
+	// 携带客户端种子时启用可验证模式，随机数由 HMAC(server_seed, client_seed:nonce) 导出
+	var fair *fairSession
+	if clientSeed := fairClientSeed(params.context); clientSeed != "" && ls.fairnessService != nil {
+		fair, err = ls.fairnessService.session(ctx, params.userID, clientSeed)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	// 创建抽奖记录
-	record := &model.LotteryRecord{
-		ItemID:      selectedItem.ID,
-		ItemName:    selectedItem.Name,
-		Description: selectedItem.Description,
-		Points:      selectedItem.Points,
-		Strategy:    "default", // 简化为单一策略
-		Timestamp:   time.Now(),
+	streak, err := ls.getPityStreak(ctx, params.userID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := ls.getDrawProfile(ctx, params.userID)
+	if err != nil {
+		return nil, err
 	}
 
-	// 保存抽奖记录到Redis
-	if err := ls.saveLotteryRecord(ctx, req.UserID, record); err != nil {
-		return nil, fmt.Errorf("failed to save lottery record: %w", err)
+	// 提交时校验保底计数和累计抽奖次数未变，避免并发抽奖按过期的保底计数选鱼
+	guard := &drawGuard{Streak: streak, TotalDraws: profile.TotalDraws}
+	if ls.staminaService != nil {
+		guard.Stamina = ls.staminaService.charge(params.now, params.count)
 	}
 
-	// 如果中奖且有积分，调用榜单服务增加积分
-	if selectedItem.Points > 0 {
-		incrementReq := &model.RankingIncrementRequest{
-			UserID:  req.UserID,
-			Delta:   selectedItem.Points,
-			Reason:  fmt.Sprintf("lottery_win_%s", selectedItem.Name),
-			TraceID: req.TraceID,
+	// 叠加当前生效的限时活动（归一到总权重），再根据服务端生成的Context（钓点、游戏时区的时段）修正本次权重（均不修改奖池）
+	weights := pool.Weights
+	var modifiers []model.AppliedModifier
+	if ls.eventService != nil {
+		weights, modifiers, err = ls.eventService.overlay(ctx, params.spot, pool.Items, pool.Weights, params.now)
+		if err != nil {
+			return nil, err
 		}
+	}
+	baseWeights, contextModifiers := ls.modifiers.Apply(pool.Items, weights, ls.modifiers.Context(params.spot, params.now))
+	modifiers = append(modifiers, contextModifiers...)
 
-		_, err := ls.rankingService.IncrementScore(ctx, incrementReq)
+	// 叠加用户下一次抽奖的加成（如签到奖励），提交时删除
+	boost, boostJSON, err := ls.getDrawBoost(ctx, params.userID)
+	if err != nil {
+		return nil, err
+	}
+	if boost != nil {
+		baseWeights, modifiers = applyDrawBoost(pool.Items, baseWeights, modifiers, boost)
+		guard.Boost = boostJSON
+	}
+
+	// 已装备装备的修正在选鱼时按次叠加，耐久在提交时扣减
+	var gear []*gearUse
+	if ls.gearService != nil {
+		guard.Gear, gear, err = ls.gearService.plan(ctx, params.userID, params.count)
 		if err != nil {
-			return nil, fmt.Errorf("failed to increment score: %w", err)
+			return nil, err
 		}
 	}
 
-	// 构造响应
-	response := &model.LotteryDrawResponse{
-		DrawID: drawID,
-		UserID: req.UserID,
-		Result: model.LotteryResult{
-			Win:         selectedItem.Points > 0,
-			ItemID:      selectedItem.ID,
-			ItemName:    selectedItem.Name,
-			Description: selectedItem.Description,
-			Points:      selectedItem.Points,
-			WxID:        selectedItem.WxID,     // 透出微信ID
-			ImageURL:    selectedItem.ImageURL, // 透出图片URL
-		},
-		CreatedAt: time.Now(),
+	// 用户参加的比赛在提交时校验状态未变，渔获积分与抽奖记录一同计入比赛榜单
+	if ls.tournamentService != nil {
+		guard.Tournaments, err = ls.tournamentService.plan(ctx, params.userID, params.spot, params.now)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return response, nil
+	selector := &drawSelector{
+		items:      pool.Items,
+		alias:      snapshot.Alias,
+		strategy:   strategy,
+		pityConfig: ls.pityConfig,
+		fair:       fair,
+		gear:       gear,
+	}
+	selections, err := selector.selectItems(params.userID, baseWeights, len(modifiers) == 0, &drawState{streak: streak, profile: profile}, params.count, params.guarantee)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, selection := range selections {
+		selection.drawID = newDrawID(params.now)
+	}
+	if fair != nil {
+		proofs := make([][]*model.FairProof, 0, len(selections))
+		for _, selection := range selections {
+			proofs = append(proofs, selection.proofs())
+		}
+		guard.Fair, err = fair.charge(proofs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if params.claim != nil {
+		guard.Idempotency, err = newIdempotencyCharge(params, selections, modifiers, appliedGear(gear))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := ls.commitDraws(ctx, params, guard, selections); err != nil {
+		return nil, err
+	}
+
+	// 清理超出保留策略的记录，失败时留在队列中由下一次抽奖继续清理
+	if err := ls.purgeHistory(ctx, params.userID); err != nil {
+		log.Printf("Failed to purge draw history for %s: %v", params.userID, err)
+	}
+
+	ls.notifyDrawListeners(ctx, newDrawEvent(params, pool.Weights, selections))
+
+	return &drawOutcome{selections: selections, modifiers: modifiers, gear: appliedGear(gear)}, nil
+}
+
+// drawSelector 选鱼所需的奖池与规则，不访问Redis，线上抽奖与离线模拟共用
+type drawSelector struct {
+	items      map[string]*model.LotteryItem
+	alias      *AliasTable // 奖池权重对应的别名表
+	strategy   DrawStrategy
+	pityConfig PityConfig
+	fair       *fairSession // 可验证模式，为空时使用策略选鱼
+	gear       []*gearUse   // 生效的装备，按次叠加在修正后的权重上
 }
 
-// weightedRandomSelect 基于权重的随机选择
-func (ls *LotteryService) weightedRandomSelect(pool *model.PoolInfoResponse) (string, *model.LotteryItem, error) {
-	if pool.TotalWeight != 1000000 {
-		return "", nil, fmt.Errorf("invalid total weight: %d, expected 1000000", pool.TotalWeight)
+// drawState 用户的保底计数与抽奖概况，每次选鱼后推进
+type drawState struct {
+	streak  int
+	profile *drawProfile
+}
+
+// advance 记录一次选鱼结果，与提交脚本中保底计数和概况的更新规则一致
+func (st *drawState) advance(item *model.LotteryItem) {
+	st.profile.LastItemID = item.ID
+	st.profile.TotalDraws++
+	if isPityTarget(item) {
+		st.streak = 0
+	} else {
+		st.streak++
 	}
+}
 
-	// 生成随机数 [0, 1000000)
-	randomBig, err := rand.Int(rand.Reader, big.NewInt(1000000))
-	if err != nil {
-		return "", nil, fmt.Errorf("failed to generate random number: %w", err)
+// selectItems 按修正后的权重选出count条鱼；unmodified 表示 baseWeights 即奖池原始权重
+func (s *drawSelector) selectItems(userID string, baseWeights map[string]int, unmodified bool, state *drawState, count int, guarantee bool) ([]*drawSelection, error) {
+	selections := make([]*drawSelection, 0, count)
+	for i := 0; i < count; i++ {
+		// 叠加本次仍有耐久的装备，再根据保底计数调整本次权重
+		drawWeights, geared := gearWeights(s.items, baseWeights, s.gear, i)
+		weights, guaranteed := s.pityConfig.applyPity(s.items, drawWeights, state.streak)
+
+		var selection *drawSelection
+		var err error
+		if s.fair != nil {
+			selection, err = fairSelect(s.fair, s.items, weights)
+		} else {
+			input := &DrawInput{
+				UserID:     userID,
+				Items:      s.items,
+				Weights:    weights,
+				LastItemID: state.profile.LastItemID,
+				TotalDraws: state.profile.TotalDraws,
+			}
+			// 权重未经调整时可直接使用快照中的别名表
+			if unmodified && !geared && !s.pityConfig.adjusts(state.streak) {
+				input.Alias = s.alias
+			}
+
+			var item *model.LotteryItem
+			item, err = s.strategy.Select(input)
+			selection = &drawSelection{item: item, strategy: s.strategy.Name(), weights: weights}
+			if weigher, ok := s.strategy.(StrategyWeigher); ok {
+				selection.weights = weigher.EffectiveWeights(input)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to select item: %w", err)
+		}
+
+		if guaranteed {
+			selection.strategy = StrategyPity
+		}
+		selections = append(selections, selection)
+
+		state.advance(selection.item)
 	}
-	randomNum := int(randomBig.Int64())
 
-	// 累计权重选择
-	cumulativeWeight := 0
-	for fishID, weight := range pool.Weights {
-		cumulativeWeight += weight
-		if randomNum < cumulativeWeight {
-			item, exists := pool.Items[fishID]
-			if !exists {
-				return "", nil, fmt.Errorf("item %s not found in pool", fishID)
+	// 保底：整批都是空军时，把最后一次替换为非空军中按权重抽取的结果
+	if guarantee && len(selections) > 0 && allEmpty(selections[:len(selections)-1]) {
+		weights := make(map[string]int, len(baseWeights))
+		for fishID, weight := range baseWeights {
+			if fishID != EmptyFishID {
+				weights[fishID] = weight
+			}
+		}
+
+		// 前面全是空军时，最后一次抽到空军即被替换，实际概率中空军部分按保底权重重新分配
+		last := selections[len(selections)-1]
+		last.fallback = weights
+		if last.item.ID == EmptyFishID {
+			var selection *drawSelection
+			var err error
+			if s.fair != nil {
+				selection, err = fairSelect(s.fair, s.items, weights)
+			} else {
+				var item *model.LotteryItem
+				item, err = weightedRandomSelect(s.items, weights)
+				selection = &drawSelection{item: item}
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to select guaranteed item: %w", err)
+			}
+			selection.strategy = StrategyGuarantee
+			selection.weights, selection.fallback = last.weights, last.fallback
+			if last.proof != nil {
+				// 被替换的结果同样消耗了nonce，其证明与最终结果一同保存
+				last.proof.ReplacedReason = FairReplacedGuarantee
+				selection.replaced = append(last.replaced, last.proof)
 			}
-			return fishID, item, nil
+			selections[len(selections)-1] = selection
 		}
 	}
 
-	// 理论上不应该到达这里，但为了安全起见返回空军
-	emptyFishID := "00000000-0000-0000-0000-000000000001"
-	if item, exists := pool.Items[emptyFishID]; exists {
-		return emptyFishID, item, nil
+	for _, selection := range selections {
+		selection.size = rollCatchSize(selection.item)
 	}
 
-	return "", nil, fmt.Errorf("no valid item found in pool")
+	return selections, nil
 }
 
-// saveLotteryRecord 保存抽奖记录到Redis
-func (ls *LotteryService) saveLotteryRecord(ctx context.Context, userID string, record *model.LotteryRecord) error {
-	key := fmt.Sprintf("lottery:draws:%s", userID)
+// allEmpty 是否全部为空军
+func allEmpty(selections []*drawSelection) bool {
+	for _, selection := range selections {
+		if selection.item.ID != EmptyFishID {
+			return false
+		}
+	}
+	return true
+}
 
-	// 将记录序列化为JSON
-	recordJSON, err := json.Marshal(record)
+// fairSelect 可验证模式下选鱼
+func fairSelect(fair *fairSession, items map[string]*model.LotteryItem, weights map[string]int) (*drawSelection, error) {
+	proof, err := fair.pick(weights)
 	if err != nil {
-		return fmt.Errorf("failed to marshal record: %w", err)
+		return nil, err
 	}
 
-	// 使用LPUSH添加到列表头部（最新的在前面）
-	if err := ls.redisClient.LPush(ctx, key, recordJSON).Err(); err != nil {
-		return fmt.Errorf("failed to save to redis: %w", err)
+	item, exists := items[proof.ItemID]
+	if !exists {
+		return nil, fmt.Errorf("item %s not found in pool", proof.ItemID)
 	}
 
-	// 可选：限制历史记录数量，只保留最近100条
-	if err := ls.redisClient.LTrim(ctx, key, 0, 99).Err(); err != nil {
-		return fmt.Errorf("failed to trim history: %w", err)
+	return &drawSelection{item: item, strategy: StrategyFair, proof: proof, weights: weights}, nil
+}
+
+// drawGuard 提交脚本在写入前校验的读取时状态及需要一并扣减的资源，字段为空时跳过对应的校验和扣减
+type drawGuard struct {
+	Streak      int                   `json:"streak"`      // 选鱼时的保底计数
+	TotalDraws  int                   `json:"total_draws"` // 选鱼时的累计抽奖次数
+	Stamina     *staminaCharge        `json:"stamina,omitempty"`
+	Boost       string                `json:"boost,omitempty"` // 读取时的下一次抽奖加成JSON，提交时删除
+	Gear        *gearCharge           `json:"gear,omitempty"`
+	Fair        *fairCharge           `json:"fair,omitempty"`
+	Idempotency *idempotencyCharge    `json:"idempotency,omitempty"`
+	Tournaments []*tournamentSnapshot `json:"tournaments,omitempty"` // 用户参加的比赛，为空时不校验也不计分
+}
+
+// commitDraws 原子扣减资源、保存抽奖记录、增加积分、更新保底计数和背包，并以脚本返回的鱼类信息更新选鱼结果
+func (ls *LotteryService) commitDraws(ctx context.Context, params *drawParams, guard *drawGuard, selections []*drawSelection) error {
+	userID := params.userID
+	keys := []string{
+		poolItemsKey(params.spot),
+		drawHistoryKey(userID),
+		GlobalRankingKey,
+		pityKey(userID),
+		drawProfileKey(userID),
+		inventoryKey(userID),
+		personalRecordsKey(userID),
+		GlobalRecordsKey,
+		staminaKey(userID),
+		drawBoostKey(userID),
+		gearKey(userID),
+		gearEquippedKey(userID),
+		fairSeedKey(userID),
+		fairDrawsKey(userID),
+		drawPurgeKey(userID),
+		idempotencyClaimKey(params.claim),
+		tournamentUserKey(userID),
+	}
+	guardJSON, err := json.Marshal(guard)
+	if err != nil {
+		return fmt.Errorf("failed to marshal draw guard: %w", err)
+	}
+	retentionMode, retentionValue := ls.historyRetention.scriptArgs()
+	args := []interface{}{
+		rankingMember(userID),
+		params.now.Format(time.RFC3339Nano),
+		params.now.UnixMilli(),
+		retentionMode,
+		retentionValue,
+		RareFishID,
+		userID,
+		params.traceID,
+		EmptyFishID,
+		params.spot,
+		guardJSON,
+	}
+	for _, selection := range selections {
+		keys = append(keys, drawRecordKey(selection.drawID), fairProofKey(selection.drawID), itemCatchesKey(selection.item.ID), itemCatchersKey(selection.item.ID))
+		var lengthCM, weightKG float64
+		if selection.size != nil {
+			lengthCM, weightKG = selection.size.lengthCM, selection.size.weightKG
+		}
+		args = append(args, selection.drawID, selection.item.ID, selection.strategy, selection.points(), lengthCM, weightKG)
+	}
+	for _, tournament := range guard.Tournaments {
+		keys = append(keys, tournamentKey(tournament.ID), tournament.board)
+	}
+
+	result, err := drawScript.Run(ctx, ls.redisClient, keys, args...).Slice()
+	if err != nil {
+		return fmt.Errorf("failed to execute draw: %w", err)
+	}
+	status, _ := result[0].(string)
+	switch status {
+	case "ok":
+	case "conflict":
+		return ErrDrawConflict
+	case "expired":
+		return ErrIdempotencyExpired
+	case OutOfStaminaReasonStamina, OutOfStaminaReasonQuota:
+		if len(result) != 4 {
+			return fmt.Errorf("unexpected draw script result")
+		}
+		stamina, _ := result[1].(int64)
+		updatedAt, _ := result[2].(int64)
+		used, _ := result[3].(int64)
+		return ls.staminaService.outOfStamina(userID, guard.Stamina, status, stamina, updatedAt, used)
+	default:
+		return fmt.Errorf("unexpected draw script result")
+	}
+
+	if len(result) != 3 {
+		return fmt.Errorf("unexpected draw script result")
+	}
+	itemJSONs, _ := result[1].([]interface{})
+	broken, _ := result[2].([]interface{})
+	if len(itemJSONs) != len(selections) || len(broken) != len(selections) {
+		return fmt.Errorf("unexpected draw script result")
+	}
+
+	for i, raw := range itemJSONs {
+		itemJSON, _ := raw.(string)
+		var item model.LotteryItem
+		if err := json.Unmarshal([]byte(itemJSON), &item); err != nil {
+			return fmt.Errorf("failed to unmarshal selected item: %w", err)
+		}
+		selections[i].item = &item
+
+		if records, _ := broken[i].(string); records != "" {
+			selections[i].records = strings.Split(records, ",")
+		}
 	}
 
 	return nil
 }
 
-// GetUserDrawHistory 获取用户抽奖历史
-func (ls *LotteryService) GetUserDrawHistory(ctx context.Context, userID string, limit int) ([]*model.LotteryRecord, error) {
-	key := fmt.Sprintf("lottery:draws:%s", userID)
+// drawProfile 用户抽奖概况
+type drawProfile struct {
+	LastItemID string
+	TotalDraws int
+}
+
+// drawProfileKey 用户抽奖概况key
+func drawProfileKey(userID string) string {
+	return fmt.Sprintf("lottery:profile:%s", userID)
+}
+
+// getDrawProfile 获取用户上一次钓到的鱼和累计抽奖次数
+func (ls *LotteryService) getDrawProfile(ctx context.Context, userID string) (*drawProfile, error) {
+	fields, err := ls.redisClient.HGetAll(ctx, drawProfileKey(userID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get draw profile: %w", err)
+	}
+
+	profile := &drawProfile{
+		LastItemID: fields["last_item_id"],
+	}
+	if totalDraws, ok := fields["total_draws"]; ok {
+		if n, err := parseWeight(totalDraws); err == nil {
+			profile.TotalDraws = n
+		}
+	}
+
+	return profile, nil
+}
+
+// weightedRandomSelect 基于权重的随机选择（按鱼类ID排序累计，保证顺序稳定）
+func weightedRandomSelect(items map[string]*model.LotteryItem, weights map[string]int) (*model.LotteryItem, error) {
+	fishIDs := make([]string, 0, len(weights))
+	totalWeight := 0
+	for fishID, weight := range weights {
+		if weight <= 0 {
+			continue
+		}
+		fishIDs = append(fishIDs, fishID)
+		totalWeight += weight
+	}
+	if totalWeight <= 0 {
+		return nil, fmt.Errorf("no valid item found in pool")
+	}
+	sort.Strings(fishIDs)
 
-	// 获取历史记录
-	results, err := ls.redisClient.LRange(ctx, key, 0, int64(limit-1)).Result()
+	// 生成随机数 [0, totalWeight)
+	randomBig, err := rand.Int(rand.Reader, big.NewInt(int64(totalWeight)))
 	if err != nil {
-		return nil, fmt.Errorf("failed to get history: %w", err)
+		return nil, fmt.Errorf("failed to generate random number: %w", err)
+	}
+	randomNum := int(randomBig.Int64())
+
+	// 累计权重选择
+	cumulativeWeight := 0
+	for _, fishID := range fishIDs {
+		cumulativeWeight += weights[fishID]
+		if randomNum < cumulativeWeight {
+			item, exists := items[fishID]
+			if !exists {
+				return nil, fmt.Errorf("item %s not found in pool", fishID)
+			}
+			return item, nil
+		}
 	}
 
-	records := make([]*model.LotteryRecord, 0, len(results))
-	for _, result := range results {
-		var record model.LotteryRecord
-		if err := json.Unmarshal([]byte(result), &record); err != nil {
-			continue // 跳过无法解析的记录
+	return nil, fmt.Errorf("no valid item found in pool")
+}
+
+// newDrawID 生成抽奖ID：d_{日期}_{随机UUID}，同一纳秒内的并发抽奖也不会冲突
+func newDrawID(now time.Time) string {
+	return fmt.Sprintf("d_%s_%s", now.Format("20060102"), strings.ReplaceAll(uuid.New().String(), "-", ""))
+}
+
+// drawRecordKey 单条抽奖记录key
+func drawRecordKey(drawID string) string {
+	return fmt.Sprintf("lottery:draw:%s", drawID)
+}
+
+// GetDraw 按抽奖ID查询抽奖记录
+func (ls *LotteryService) GetDraw(ctx context.Context, drawID string) (*model.LotteryRecord, error) {
+	recordJSON, err := ls.redisClient.Get(ctx, drawRecordKey(drawID)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, fmt.Errorf("%w: %s", ErrDrawNotFound, drawID)
 		}
-		records = append(records, &record)
+		return nil, fmt.Errorf("failed to get draw: %w", err)
+	}
+
+	var record model.LotteryRecord
+	if err := json.Unmarshal([]byte(recordJSON), &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal draw: %w", err)
 	}
 
-	return records, nil
+	return &record, nil
+}
+
+// toLotteryResult 将鱼类转换为抽奖结果
+func toLotteryResult(item *model.LotteryItem) model.LotteryResult {
+	return model.LotteryResult{
+		Win:         item.Points > 0,
+		ItemID:      item.ID,
+		ItemName:    item.Name,
+		Description: item.Description,
+		Points:      item.Points,
+		WxID:        item.WxID,     // 透出微信ID
+		ImageURL:    item.ImageURL, // 透出图片URL
+	}
 }
@@ -0,0 +1,728 @@
+package service
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"fishing-game/config"
+	"fishing-game/model"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+const testUserID = "u1"
+
+// errInjected 故障点注入的错误
+var errInjected = errors.New("injected failure")
+
+// setupTestRedis 启动miniredis并作为全局Redis客户端，测试结束时自动关闭
+func setupTestRedis(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+	t.Setenv("CONFIG_DIR", "../configs")
+
+	mr := miniredis.RunT(t)
+	config.RedisClient = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	return mr
+}
+
+// faultHook 在匹配的Redis命令上注入故障：before 为真时命令不发送，否则命令执行后仍返回错误（模拟执行后的传输超时）。
+// 命令执行前调用 onMatch（可为空），用于模拟并发修改
+type faultHook struct {
+	match   func(cmd redis.Cmder) bool
+	before  bool
+	fail    bool
+	onMatch func()
+	hits    int
+}
+
+func (h *faultHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *faultHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if !h.match(cmd) {
+			return next(ctx, cmd)
+		}
+		h.hits++
+		if h.onMatch != nil {
+			h.onMatch()
+		}
+		if !h.fail {
+			return next(ctx, cmd)
+		}
+		if !h.before {
+			if err := next(ctx, cmd); err != nil {
+				return err
+			}
+		}
+		cmd.SetErr(errInjected)
+		return errInjected
+	}
+}
+
+func (h *faultHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+// scriptCall 匹配执行指定脚本的EVAL/EVALSHA命令
+func scriptCall(script *redis.Script) func(cmd redis.Cmder) bool {
+	return func(cmd redis.Cmder) bool {
+		args := cmd.Args()
+		if len(args) < 2 {
+			return false
+		}
+		body, _ := args[1].(string)
+		switch strings.ToLower(cmd.Name()) {
+		case "evalsha":
+			return body == script.Hash()
+		case "eval":
+			sum := sha1.Sum([]byte(body))
+			return hex.EncodeToString(sum[:]) == script.Hash()
+		}
+		return false
+	}
+}
+
+// keyCommand 匹配对指定key执行的命令
+func keyCommand(name, key string) func(cmd redis.Cmder) bool {
+	return func(cmd redis.Cmder) bool {
+		args := cmd.Args()
+		return strings.EqualFold(cmd.Name(), name) && len(args) > 1 && args[1] == key
+	}
+}
+
+// newTestLotteryService 基于miniredis创建启用体力、可验证抽奖和装备的抽奖服务
+func newTestLotteryService(t *testing.T) (*LotteryService, context.Context) {
+	t.Helper()
+	setupTestRedis(t)
+	ctx := context.Background()
+
+	idempotencyService := NewIdempotencyService(time.Hour)
+	rankingService := NewRankingService(NewUserService(), idempotencyService)
+	poolService, err := NewPoolService()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := poolService.InitializePool(ctx); err != nil {
+		t.Fatal(err)
+	}
+	gearService, err := NewGearService(idempotencyService)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	staminaService, err := NewStaminaService()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ls, err := NewLotteryService(rankingService, poolService, idempotencyService, staminaService, NewFairnessService(), gearService, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 预先加载提交脚本，每次提交只有一条EVALSHA
+	if err := drawScript.Load(ctx, ls.redisClient).Err(); err != nil {
+		t.Fatal(err)
+	}
+	return ls, ctx
+}
+
+// seedDrawState 为用户准备加成、已装备的装备和可验证抽奖种子
+func seedDrawState(t *testing.T, ls *LotteryService, ctx context.Context) {
+	t.Helper()
+	boost, err := json.Marshal(&model.DrawBoost{
+		ID:      "test_boost",
+		Name:    "测试加成",
+		Source:  DrawBoostSourceCheckin,
+		Effects: []*model.ModifierEffect{{ItemID: EmptyFishID, Multiply: float64Ptr(0.5)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pipe := ls.redisClient.Pipeline()
+	pipe.Set(ctx, drawBoostKey(testUserID), boost, 0)
+	pipe.HSet(ctx, gearKey(testUserID), "bait_worm", 5)
+	pipe.HSet(ctx, gearEquippedKey(testUserID), "bait", "bait_worm")
+	if _, err := pipe.Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ls.fairnessService.GetCommitment(ctx, testUserID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// drawSnapshot 一次抽奖会修改的全部用户状态
+type drawSnapshot struct {
+	history   []string
+	score     float64
+	streak    string
+	profile   map[string]string
+	stamina   map[string]string
+	boost     string
+	gear      map[string]string
+	equipped  map[string]string
+	fairSeed  map[string]string
+	proofs    []*model.FairProof
+	inventory map[string]string
+}
+
+// takeSnapshot 读取用户当前的抽奖相关状态
+func takeSnapshot(t *testing.T, ls *LotteryService, ctx context.Context) *drawSnapshot {
+	t.Helper()
+	rc := ls.redisClient
+	epoch, _ := rc.HGet(ctx, fairSeedKey(testUserID), "epoch").Int64()
+
+	pipe := rc.Pipeline()
+	history := pipe.ZRange(ctx, drawHistoryKey(testUserID), 0, -1)
+	score := pipe.ZScore(ctx, GlobalRankingKey, rankingMember(testUserID))
+	streak := pipe.Get(ctx, pityKey(testUserID))
+	profile := pipe.HGetAll(ctx, drawProfileKey(testUserID))
+	stamina := pipe.HGetAll(ctx, staminaKey(testUserID))
+	boost := pipe.Get(ctx, drawBoostKey(testUserID))
+	gear := pipe.HGetAll(ctx, gearKey(testUserID))
+	equipped := pipe.HGetAll(ctx, gearEquippedKey(testUserID))
+	fairSeed := pipe.HGetAll(ctx, fairSeedKey(testUserID))
+	inventory := pipe.HGetAll(ctx, inventoryKey(testUserID))
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		t.Fatal(err)
+	}
+	proofs, err := ls.fairnessService.GetProofs(ctx, testUserID, epoch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &drawSnapshot{
+		history:   history.Val(),
+		score:     score.Val(),
+		streak:    streak.Val(),
+		profile:   profile.Val(),
+		stamina:   stamina.Val(),
+		boost:     boost.Val(),
+		gear:      gear.Val(),
+		equipped:  equipped.Val(),
+		fairSeed:  fairSeed.Val(),
+		proofs:    proofs.Proofs,
+		inventory: inventory.Val(),
+	}
+}
+
+// fairDrawRequest 携带客户端种子的单抽请求
+func fairDrawRequest() *model.LotteryDrawRequest {
+	return &model.LotteryDrawRequest{
+		UserID:  testUserID,
+		Context: map[string]interface{}{FairClientSeedContextKey: "client"},
+	}
+}
+
+func TestDrawFailureBeforeCommitLeavesNoWrites(t *testing.T) {
+	steps := map[string]func(cmd redis.Cmder) bool{
+		"pity":   keyCommand("get", pityKey(testUserID)),
+		"boost":  keyCommand("get", drawBoostKey(testUserID)),
+		"gear":   keyCommand("hgetall", gearEquippedKey(testUserID)),
+		"commit": scriptCall(drawScript),
+	}
+	for step, match := range steps {
+		t.Run(step, func(t *testing.T) {
+			ls, ctx := newTestLotteryService(t)
+			seedDrawState(t, ls, ctx)
+			before := takeSnapshot(t, ls, ctx)
+
+			hook := &faultHook{match: match, before: true, fail: true}
+			ls.redisClient.AddHook(hook)
+			if _, err := ls.Draw(ctx, fairDrawRequest()); !errors.Is(err, errInjected) {
+				t.Fatalf("expected injected failure, got %v", err)
+			}
+			if hook.hits == 0 {
+				t.Fatalf("step %s was not reached", step)
+			}
+
+			if after := takeSnapshot(t, ls, ctx); !reflect.DeepEqual(before, after) {
+				t.Fatalf("state changed after failed draw:\nbefore %+v\nafter  %+v", before, after)
+			}
+		})
+	}
+}
+
+func TestDrawFailureAfterCommitKeepsStateConsistent(t *testing.T) {
+	ls, ctx := newTestLotteryService(t)
+	seedDrawState(t, ls, ctx)
+	before := takeSnapshot(t, ls, ctx)
+
+	// 提交脚本已执行，但客户端收到错误（如传输超时）
+	ls.redisClient.AddHook(&faultHook{match: scriptCall(drawScript), fail: true})
+	if _, err := ls.Draw(ctx, fairDrawRequest()); !errors.Is(err, errInjected) {
+		t.Fatalf("expected injected failure, got %v", err)
+	}
+	after := takeSnapshot(t, ls, ctx)
+
+	// 抽奖已提交：记录、积分、保底计数、体力、加成、装备耐久和证明全部生效且只生效一次
+	if len(after.history) != 1 {
+		t.Fatalf("expected 1 history record, got %d", len(after.history))
+	}
+	record, err := ls.GetDraw(ctx, after.history[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.score != float64(record.Points) {
+		t.Errorf("score %v does not match record points %d", after.score, record.Points)
+	}
+	items, err := ls.poolService.GetItems(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := items[record.ItemID]
+	wantStreak := "1"
+	if isPityTarget(item) {
+		wantStreak = "0"
+	}
+	if after.streak != wantStreak {
+		t.Errorf("pity streak %q, expected %q", after.streak, wantStreak)
+	}
+	if after.profile["total_draws"] != "1" || after.profile["last_item_id"] != record.ItemID {
+		t.Errorf("unexpected draw profile %v", after.profile)
+	}
+	if after.stamina["stamina"] != "19" || after.stamina["quota_used"] != "1" {
+		t.Errorf("stamina not charged exactly once: %v", after.stamina)
+	}
+	if after.boost != "" {
+		t.Errorf("draw boost not consumed: %s", after.boost)
+	}
+	if after.gear["bait_worm"] != "4" {
+		t.Errorf("gear durability %q, expected 4", after.gear["bait_worm"])
+	}
+	if before.fairSeed["nonce"] != "0" || after.fairSeed["nonce"] != "1" {
+		t.Errorf("fair nonce %q -> %q, expected 0 -> 1", before.fairSeed["nonce"], after.fairSeed["nonce"])
+	}
+	if len(after.proofs) != 1 {
+		t.Fatalf("expected 1 proof, got %d", len(after.proofs))
+	}
+	proof := after.proofs[0]
+	if proof.DrawID != record.DrawID || proof.ItemID != record.ItemID || proof.Nonce != 0 {
+		t.Errorf("proof %+v does not match record %+v", proof, record)
+	}
+}
+
+func TestDrawRetriesWhenStateChangesBeforeCommit(t *testing.T) {
+	ls, ctx := newTestLotteryService(t)
+	seedDrawState(t, ls, ctx)
+
+	// 第一次选鱼后、提交前模拟并发抽奖修改了保底计数，提交应被拒绝并重新选鱼
+	hook := &faultHook{match: scriptCall(drawScript)}
+	hook.onMatch = func() {
+		if hook.hits == 1 {
+			if err := ls.redisClient.Set(ctx, pityKey(testUserID), 7, 0).Err(); err != nil {
+				t.Error(err)
+			}
+		}
+	}
+	ls.redisClient.AddHook(hook)
+	resp, err := ls.Draw(ctx, fairDrawRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hook.hits != 2 {
+		t.Fatalf("expected 2 attempts, got %d", hook.hits)
+	}
+
+	after := takeSnapshot(t, ls, ctx)
+	if len(after.history) != 1 || after.history[0] != resp.DrawID {
+		t.Fatalf("unexpected history %v", after.history)
+	}
+	items, err := ls.poolService.GetItems(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := items[resp.Result.ItemID]
+	wantStreak := "8"
+	if isPityTarget(item) {
+		wantStreak = "0"
+	}
+	if after.streak != wantStreak {
+		t.Errorf("pity streak %q, expected %q", after.streak, wantStreak)
+	}
+	if after.stamina["stamina"] != "19" || after.gear["bait_worm"] != "4" || after.fairSeed["nonce"] != "1" {
+		t.Errorf("resources not charged exactly once: stamina %v gear %v seed %v", after.stamina, after.gear, after.fairSeed)
+	}
+}
+
+func TestDrawConflictAfterRetriesLeavesNoWrites(t *testing.T) {
+	ls, ctx := newTestLotteryService(t)
+	seedDrawState(t, ls, ctx)
+
+	// 每次提交前都修改装备耐久
+	durability := 10
+	hook := &faultHook{match: scriptCall(drawScript)}
+	hook.onMatch = func() {
+		durability++
+		if err := ls.redisClient.HSet(ctx, gearKey(testUserID), "bait_worm", durability).Err(); err != nil {
+			t.Error(err)
+		}
+	}
+	before := takeSnapshot(t, ls, ctx)
+	ls.redisClient.AddHook(hook)
+	if _, err := ls.Draw(ctx, fairDrawRequest()); !errors.Is(err, ErrDrawConflict) {
+		t.Fatalf("expected draw conflict, got %v", err)
+	}
+	if hook.hits != drawMaxRetries {
+		t.Fatalf("expected %d attempts, got %d", drawMaxRetries, hook.hits)
+	}
+
+	after := takeSnapshot(t, ls, ctx)
+	before.gear = after.gear
+	if !reflect.DeepEqual(before, after) {
+		t.Fatalf("state changed after conflicted draw:\nbefore %+v\nafter  %+v", before, after)
+	}
+}
+
+func TestDrawOutOfStaminaLeavesNoWrites(t *testing.T) {
+	ls, ctx := newTestLotteryService(t)
+	seedDrawState(t, ls, ctx)
+	err := ls.redisClient.HSet(ctx, staminaKey(testUserID),
+		"stamina", 0, "updated_at", time.Now().UnixMilli(), "quota_day", "", "quota_used", 0).Err()
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := takeSnapshot(t, ls, ctx)
+
+	_, err = ls.Draw(ctx, fairDrawRequest())
+	var staminaErr *OutOfStaminaError
+	if !errors.As(err, &staminaErr) || staminaErr.Detail.Reason != OutOfStaminaReasonStamina {
+		t.Fatalf("expected out of stamina, got %v", err)
+	}
+
+	if after := takeSnapshot(t, ls, ctx); !reflect.DeepEqual(before, after) {
+		t.Fatalf("state changed after rejected draw:\nbefore %+v\nafter  %+v", before, after)
+	}
+}
+
+func TestFairGuaranteeKeepsReplacedProof(t *testing.T) {
+	items := map[string]*model.LotteryItem{
+		EmptyFishID: {ID: EmptyFishID, Name: "空军"},
+		SmallFishID: {ID: SmallFishID, Name: "小鱼", Points: 1},
+	}
+	weights := map[string]int{EmptyFishID: 1000000, SmallFishID: 1}
+	fair := &fairSession{userID: testUserID, epoch: 1, serverSeed: "seed", clientSeed: "client"}
+	selector := &drawSelector{items: items, fair: fair}
+
+	selections, err := selector.selectItems(testUserID, weights, false, &drawState{profile: &drawProfile{}}, 3, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := selections[2]
+	if last.strategy != StrategyGuarantee || last.item.ID != SmallFishID {
+		t.Fatalf("last selection %s/%s, expected guaranteed %s", last.strategy, last.item.ID, SmallFishID)
+	}
+
+	proofs := make([][]*model.FairProof, 0, len(selections))
+	for i, selection := range selections {
+		selection.drawID = fmt.Sprintf("d%d", i)
+		proofs = append(proofs, selection.proofs())
+	}
+	lastProofs := proofs[2]
+	if len(lastProofs) != 2 {
+		t.Fatalf("expected 2 proofs for the guaranteed draw, got %d", len(lastProofs))
+	}
+	replaced, final := lastProofs[0], lastProofs[1]
+	if replaced.ReplacedReason != FairReplacedGuarantee || replaced.ItemID != EmptyFishID || replaced.Nonce != 2 {
+		t.Errorf("unexpected replaced proof %+v", replaced)
+	}
+	if final.ReplacedReason != "" || final.ItemID != SmallFishID || final.Nonce != 3 || final.DrawID != "d2" {
+		t.Errorf("unexpected final proof %+v", final)
+	}
+
+	charge, err := fair.charge(proofs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if charge.Used != 4 || len(charge.Proofs) != 3 {
+		t.Errorf("charge used %d nonces with %d proofs, expected 4 and 3", charge.Used, len(charge.Proofs))
+	}
+}
+
+func TestDrawRetryAfterCommitReturnsCommittedDraw(t *testing.T) {
+	ls, ctx := newTestLotteryService(t)
+	seedDrawState(t, ls, ctx)
+	req := fairDrawRequest()
+	req.TraceID = "t1"
+
+	// 提交脚本已执行，但客户端收到错误，幂等key不应被释放
+	hook := &faultHook{match: scriptCall(drawScript), fail: true}
+	ls.redisClient.AddHook(hook)
+	if _, err := ls.Draw(ctx, req); !errors.Is(err, errInjected) {
+		t.Fatalf("expected injected failure, got %v", err)
+	}
+	key := idempotencyKey(IdempotencyScopeDraw, testUserID, req.TraceID)
+	if ttl, _ := ls.redisClient.PTTL(ctx, key).Result(); ttl <= idempotencyPendingLease {
+		t.Fatalf("committed idempotency key ttl %v, expected the idempotency window", ttl)
+	}
+
+	hook.fail = false
+	resp, err := ls.Draw(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hook.hits != 1 {
+		t.Fatalf("retry ran the draw again: %d commits", hook.hits)
+	}
+
+	after := takeSnapshot(t, ls, ctx)
+	if len(after.history) != 1 || after.history[0] != resp.DrawID {
+		t.Fatalf("history %v, expected only %s", after.history, resp.DrawID)
+	}
+	if after.stamina["stamina"] != "19" {
+		t.Errorf("stamina charged more than once: %v", after.stamina)
+	}
+	record, err := ls.GetDraw(ctx, resp.DrawID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Result.ItemID != record.ItemID || resp.Result.Points != record.Points || resp.Result.DrawID != record.DrawID {
+		t.Errorf("replayed result %+v does not match record %+v", resp.Result, record)
+	}
+	if resp.Result.Fair == nil || resp.Result.Fair.DrawID != resp.DrawID || resp.Result.Fair.Weights != nil {
+		t.Errorf("unexpected replayed proof %+v", resp.Result.Fair)
+	}
+	if len(resp.Gear) == 0 || len(resp.Modifiers) == 0 {
+		t.Errorf("replayed response lost gear or modifiers: %+v", resp)
+	}
+
+	// 保存的响应与重建的响应一致
+	again, err := ls.Draw(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(again.Result, resp.Result) || again.DrawID != resp.DrawID {
+		t.Errorf("stored response %+v differs from replayed %+v", again, resp)
+	}
+}
+
+func TestBatchDrawRetryAfterCommitReturnsCommittedDraws(t *testing.T) {
+	ls, ctx := newTestLotteryService(t)
+	req := &model.LotteryBatchDrawRequest{UserID: testUserID, TraceID: "t1", Count: 3}
+
+	hook := &faultHook{match: scriptCall(drawScript), fail: true}
+	ls.redisClient.AddHook(hook)
+	if _, err := ls.BatchDraw(ctx, req); !errors.Is(err, errInjected) {
+		t.Fatalf("expected injected failure, got %v", err)
+	}
+
+	hook.fail = false
+	resp, err := ls.BatchDraw(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hook.hits != 1 {
+		t.Fatalf("retry ran the draw again: %d commits", hook.hits)
+	}
+	if resp.DrawID == "" || len(resp.Results) != 3 {
+		t.Fatalf("unexpected replayed batch %+v", resp)
+	}
+
+	history := takeSnapshot(t, ls, ctx).history
+	if len(history) != 3 {
+		t.Fatalf("expected 3 history records, got %d", len(history))
+	}
+	totalPoints := 0
+	for _, result := range resp.Results {
+		record, err := ls.GetDraw(ctx, result.DrawID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record.ItemID != result.ItemID {
+			t.Errorf("replayed result %+v does not match record %+v", result, record)
+		}
+		totalPoints += record.Points
+	}
+	if resp.TotalPoints != totalPoints {
+		t.Errorf("total points %d, expected %d", resp.TotalPoints, totalPoints)
+	}
+}
+
+func TestDrawWithExpiredIdempotencyLeaseLeavesNoWrites(t *testing.T) {
+	ls, ctx := newTestLotteryService(t)
+	seedDrawState(t, ls, ctx)
+	req := fairDrawRequest()
+	req.TraceID = "t1"
+	before := takeSnapshot(t, ls, ctx)
+
+	// 提交前处理中的占用已过期
+	key := idempotencyKey(IdempotencyScopeDraw, testUserID, req.TraceID)
+	ls.redisClient.AddHook(&faultHook{match: scriptCall(drawScript), onMatch: func() {
+		if err := ls.redisClient.Del(ctx, key).Err(); err != nil {
+			t.Error(err)
+		}
+	}})
+	if _, err := ls.Draw(ctx, req); !errors.Is(err, ErrIdempotencyExpired) {
+		t.Fatalf("expected expired idempotency lease, got %v", err)
+	}
+	if after := takeSnapshot(t, ls, ctx); !reflect.DeepEqual(before, after) {
+		t.Fatalf("state changed after expired draw:\nbefore %+v\nafter  %+v", before, after)
+	}
+}
+
+func TestDrawCommitsItemCatches(t *testing.T) {
+	ls, ctx := newTestLotteryService(t)
+
+	// 未注册任何监听器，被钓累计由提交脚本写入
+	counts := make(map[string]int64)
+	catchers := make(map[string]map[string]bool)
+	for _, userID := range []string{"u1", "u2", "u1"} {
+		resp, err := ls.BatchDraw(ctx, &model.LotteryBatchDrawRequest{UserID: userID, Count: 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, result := range resp.Results {
+			counts[result.ItemID]++
+			if catchers[result.ItemID] == nil {
+				catchers[result.ItemID] = make(map[string]bool)
+			}
+			catchers[result.ItemID][userID] = true
+		}
+	}
+
+	for itemID, count := range counts {
+		fields, err := ls.redisClient.HGetAll(ctx, itemCatchesKey(itemID)).Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fields["total"] != fmt.Sprint(count) || fields["first_caught_at"] == "" || fields["last_caught_at"] == "" {
+			t.Errorf("catches of %s %v, expected total %d", itemID, fields, count)
+		}
+		if n, _ := ls.redisClient.SCard(ctx, itemCatchersKey(itemID)).Result(); n != int64(len(catchers[itemID])) {
+			t.Errorf("catchers of %s %d, expected %d", itemID, n, len(catchers[itemID]))
+		}
+	}
+}
+
+func float64Ptr(value float64) *float64 {
+	return &value
+}
+
+func TestModifierContextIsDerivedOnServer(t *testing.T) {
+	location := time.FixedZone("UTC+8", 8*3600)
+	pipeline, err := NewModifierPipeline([]*model.DrawModifier{
+		{
+			ID:         "spot_sea",
+			ContextKey: ModifierContextSpot,
+			Values:     []string{"sea"},
+			Effects:    []*model.ModifierEffect{{ItemID: SmallFishID, Multiply: float64Ptr(2)}},
+		},
+		{
+			ID:         "time_night",
+			ContextKey: ModifierContextTimeOfDay,
+			Values:     []string{"night"},
+			Effects:    []*model.ModifierEffect{{ItemID: EmptyFishID, Multiply: float64Ptr(0)}},
+		},
+	}, nil, location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	weights := map[string]int{EmptyFishID: 10, SmallFishID: 10}
+
+	// 游戏时区 12:00 为白天
+	noon := time.Date(2024, 1, 1, 4, 0, 0, 0, time.UTC)
+	adjusted, applied := pipeline.Apply(nil, weights, pipeline.Context("lake", noon))
+	if len(applied) != 0 || adjusted[SmallFishID] != 10 || adjusted[EmptyFishID] != 10 {
+		t.Errorf("lake at noon modifiers %v, weights %v", applied, adjusted)
+	}
+
+	// 游戏时区 23:00 为夜间，UTC 仍为白天；显式的 multiply 0 使空军权重为0
+	night := time.Date(2024, 1, 1, 15, 0, 0, 0, time.UTC)
+	adjusted, applied = pipeline.Apply(nil, weights, pipeline.Context("sea", night))
+	if len(applied) != 2 || adjusted[SmallFishID] != 20 || adjusted[EmptyFishID] != 0 {
+		t.Errorf("sea at night modifiers %v, weights %v", applied, adjusted)
+	}
+}
+
+func TestModifierPipelineRejectsClientContextKeys(t *testing.T) {
+	_, err := NewModifierPipeline([]*model.DrawModifier{{
+		ID:         "bait_worm",
+		ContextKey: "bait",
+		Values:     []string{"worm"},
+		Effects:    []*model.ModifierEffect{{Tag: "small", Multiply: float64Ptr(1.3)}},
+	}}, nil, time.UTC)
+	if err == nil {
+		t.Error("expected bait context modifier to be rejected")
+	}
+}
+
+func TestBatchDrawGuaranteeCommitsNonEmptyCatch(t *testing.T) {
+	ls, ctx := newTestLotteryService(t)
+
+	// 加成使空军几乎必出，整批保底把最后一次替换为非空军
+	boost, err := json.Marshal(&model.DrawBoost{
+		ID:      "test_empty",
+		Name:    "测试空军",
+		Source:  DrawBoostSourceCheckin,
+		Effects: []*model.ModifierEffect{{ItemID: EmptyFishID, Multiply: float64Ptr(1000000)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ls.redisClient.Set(ctx, drawBoostKey(testUserID), boost, 0).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := ls.BatchDraw(ctx, &model.LotteryBatchDrawRequest{UserID: testUserID, Count: 10, Guarantee: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Results) != 10 {
+		t.Fatalf("expected 10 results, got %d", len(resp.Results))
+	}
+
+	empty := 0
+	for _, result := range resp.Results[:9] {
+		if result.ItemID == EmptyFishID {
+			empty++
+		}
+	}
+	last := resp.Results[9]
+	if last.ItemID == EmptyFishID && empty == 9 {
+		t.Fatal("guaranteed batch committed only empty catches")
+	}
+	if empty == 9 {
+		record, err := ls.GetDraw(ctx, last.DrawID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record.Strategy != StrategyGuarantee || record.ItemID != last.ItemID {
+			t.Errorf("guaranteed record %+v", record)
+		}
+	}
+
+	// 整批一次提交：10条记录、加成已消耗、累计抽奖次数为10
+	if n, _ := ls.redisClient.ZCard(ctx, drawHistoryKey(testUserID)).Result(); n != 10 {
+		t.Errorf("history has %d entries, expected 10", n)
+	}
+	if exists, _ := ls.redisClient.Exists(ctx, drawBoostKey(testUserID)).Result(); exists != 0 {
+		t.Error("boost not consumed by batch")
+	}
+	if total, _ := ls.redisClient.HGet(ctx, drawProfileKey(testUserID), "total_draws").Int(); total != 10 {
+		t.Errorf("total draws %d, expected 10", total)
+	}
+}
+
+func TestBatchDrawInvalidCountLeavesNoWrites(t *testing.T) {
+	ls, ctx := newTestLotteryService(t)
+
+	for _, count := range []int{0, MaxBatchDrawCount + 1} {
+		if _, err := ls.BatchDraw(ctx, &model.LotteryBatchDrawRequest{UserID: testUserID, Count: count}); err == nil {
+			t.Errorf("expected batch of %d to be rejected", count)
+		}
+	}
+	if exists, _ := ls.redisClient.Exists(ctx, drawHistoryKey(testUserID), staminaKey(testUserID), drawProfileKey(testUserID)).Result(); exists != 0 {
+		t.Error("rejected batch wrote draw state")
+	}
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"fishing-game/config"
+	"fishing-game/model"
+)
+
+const (
+	// DrawModifiersConfigFile 抽奖修正配置文件
+	DrawModifiersConfigFile = "draw_modifiers.json"
+
+	// 抽奖修正Context的key，均由服务端生成，不接受客户端传入
+	ModifierContextSpot      = "spot"        // 解析后的钓点
+	ModifierContextTimeOfDay = "time_of_day" // 按游戏时区计算的时段
+)
+
+// DefaultTimesOfDay 默认时段划分
+var DefaultTimesOfDay = []*model.TimeOfDay{
+	{Value: "dawn", StartHour: 5, EndHour: 7},
+	{Value: "day", StartHour: 7, EndHour: 17},
+	{Value: "dusk", StartHour: 17, EndHour: 19},
+	{Value: "night", StartHour: 19, EndHour: 5},
+}
+
+// ModifierPipeline 根据服务端生成的抽奖Context计算本次抽奖有效权重的修正流水线
+type ModifierPipeline struct {
+	modifiers  []*model.DrawModifier
+	timesOfDay []*model.TimeOfDay
+	location   *time.Location
+}
+
+// NewModifierPipeline 创建修正流水线，timesOfDay 为空时使用默认时段划分，时段按 location 计算
+func NewModifierPipeline(modifiers []*model.DrawModifier, timesOfDay []*model.TimeOfDay, location *time.Location) (*ModifierPipeline, error) {
+	for _, modifier := range modifiers {
+		if modifier.ID == "" || modifier.ContextKey == "" {
+			return nil, fmt.Errorf("modifier requires id and context_key")
+		}
+		if modifier.ContextKey != ModifierContextSpot && modifier.ContextKey != ModifierContextTimeOfDay {
+			return nil, fmt.Errorf("modifier %s: unsupported context_key %s, expected %s or %s", modifier.ID, modifier.ContextKey, ModifierContextSpot, ModifierContextTimeOfDay)
+		}
+		if err := validateModifierEffects(modifier.Effects); err != nil {
+			return nil, fmt.Errorf("modifier %s: %w", modifier.ID, err)
+		}
+	}
+
+	if len(timesOfDay) == 0 {
+		timesOfDay = DefaultTimesOfDay
+	}
+	for _, period := range timesOfDay {
+		if period.Value == "" || period.StartHour < 0 || period.StartHour > 23 || period.EndHour < 0 || period.EndHour > 24 {
+			return nil, fmt.Errorf("time of day requires value and hours within 0-24")
+		}
+	}
+	if location == nil {
+		location = time.UTC
+	}
+
+	return &ModifierPipeline{modifiers: modifiers, timesOfDay: timesOfDay, location: location}, nil
+}
+
+// timeOfDay 抽奖时间所在的时段，不在任何时段内时为空
+func (m *ModifierPipeline) timeOfDay(now time.Time) string {
+	hour := now.In(m.location).Hour()
+	for _, period := range m.timesOfDay {
+		if period.StartHour < period.EndHour {
+			if hour >= period.StartHour && hour < period.EndHour {
+				return period.Value
+			}
+		} else if hour >= period.StartHour || hour < period.EndHour {
+			return period.Value
+		}
+	}
+	return ""
+}
+
+// Context 服务端生成的抽奖修正Context：解析后的钓点和抽奖时间所在的时段
+func (m *ModifierPipeline) Context(spot string, now time.Time) map[string]interface{} {
+	drawContext := map[string]interface{}{ModifierContextSpot: spot}
+	if m == nil {
+		return drawContext
+	}
+	if period := m.timeOfDay(now); period != "" {
+		drawContext[ModifierContextTimeOfDay] = period
+	}
+	return drawContext
+}
+
+// validateModifierEffects 校验权重修正，每条修正必须且只能指定item_id或tag之一
+func validateModifierEffects(effects []*model.ModifierEffect) error {
+	for _, effect := range effects {
+		if (effect.ItemID == "") == (effect.Tag == "") {
+			return fmt.Errorf("effect requires exactly one of item_id or tag")
+		}
+		if effect.Multiply != nil && *effect.Multiply < 0 {
+			return fmt.Errorf("multiply must not be negative")
+		}
+	}
+	return nil
+}
+
+// LoadModifierPipeline 从配置文件加载修正流水线，配置文件不存在时不做任何修正
+func LoadModifierPipeline() (*ModifierPipeline, error) {
+	var cfg model.DrawModifierConfig
+	found, err := config.LoadJSON(DrawModifiersConfigFile, &cfg)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		log.Printf("%s not found, draw modifiers disabled", DrawModifiersConfigFile)
+	}
+
+	return NewModifierPipeline(cfg.Modifiers, cfg.TimesOfDay, config.GetEnvLocation("GAME_TIMEZONE", DefaultGameTimezone))
+}
+
+// matches 修正是否被本次抽奖Context触发，返回匹配到的取值
+func (m *ModifierPipeline) matches(modifier *model.DrawModifier, drawContext map[string]interface{}) (string, bool) {
+	raw, exists := drawContext[modifier.ContextKey]
+	if !exists || raw == nil {
+		return "", false
+	}
+
+	value := fmt.Sprint(raw)
+	if len(modifier.Values) == 0 {
+		return value, true
+	}
+	for _, v := range modifier.Values {
+		if v == value {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// Apply 计算本次抽奖的有效权重，不修改传入的权重。没有修正生效时原样返回传入的权重
+func (m *ModifierPipeline) Apply(items map[string]*model.LotteryItem, weights map[string]int, drawContext map[string]interface{}) (map[string]int, []model.AppliedModifier) {
+	if m == nil || len(m.modifiers) == 0 || len(drawContext) == 0 {
+		return weights, nil
+	}
+
+	var adjusted map[string]int
+	var applied []model.AppliedModifier
+	for _, modifier := range m.modifiers {
+		value, ok := m.matches(modifier, drawContext)
+		if !ok {
+			continue
+		}
+
+		if adjusted == nil {
+			adjusted = make(map[string]int, len(weights))
+			for fishID, weight := range weights {
+				adjusted[fishID] = weight
+			}
+		}
+		for _, effect := range modifier.Effects {
+			applyEffect(items, adjusted, effect)
+		}
+
+		applied = append(applied, model.AppliedModifier{
+			ID:         modifier.ID,
+			Name:       modifier.Name,
+			ContextKey: modifier.ContextKey,
+			Value:      value,
+		})
+	}
+
+	if adjusted == nil {
+		return weights, nil
+	}
+	return adjusted, applied
+}
+
+// applyEffect 对目标鱼类或标签的权重执行 weight * multiply + add（未设置multiply时为1），结果不小于0
+func applyEffect(items map[string]*model.LotteryItem, weights map[string]int, effect *model.ModifierEffect) {
+	multiply := 1.0
+	if effect.Multiply != nil {
+		multiply = *effect.Multiply
+	}
+
+	for fishID, weight := range weights {
+		if effect.ItemID != "" && fishID != effect.ItemID {
+			continue
+		}
+		if effect.Tag != "" {
+			item, exists := items[fishID]
+			if !exists || !ItemHasTag(item, effect.Tag) {
+				continue
+			}
+		}
+
+		weights[fishID] = int(math.Max(0, math.Round(float64(weight)*multiply)+float64(effect.Add)))
+	}
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"fishing-game/model"
+)
+
+func TestModifierTimeOfDayUsesGameTimezone(t *testing.T) {
+	t.Setenv("CONFIG_DIR", "../configs")
+	t.Setenv("GAME_TIMEZONE", "Asia/Shanghai")
+	pipeline, err := LoadModifierPipeline()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	location, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for hour, want := range map[int]string{0: "night", 4: "night", 5: "dawn", 6: "dawn", 7: "day", 16: "day", 17: "dusk", 19: "night", 23: "night"} {
+		now := time.Date(2024, 6, 1, hour, 30, 0, 0, location)
+		if got := pipeline.Context("lake", now)[ModifierContextTimeOfDay]; got != want {
+			t.Errorf("time of day at %02d:30 %v, expected %s", hour, got, want)
+		}
+	}
+}
+
+func TestBaitModifierRequiresEquippedGear(t *testing.T) {
+	ls, ctx := newTestLotteryService(t)
+
+	if _, err := ls.gearService.Equip(ctx, &model.GearEquipRequest{UserID: testUserID, GearID: "bait_lure"}); !errors.Is(err, ErrGearNotOwned) {
+		t.Fatalf("expected ErrGearNotOwned, got %v", err)
+	}
+	resp, err := ls.Draw(ctx, &model.LotteryDrawRequest{UserID: testUserID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Gear) != 0 {
+		t.Errorf("unowned bait applied: %+v", resp.Gear)
+	}
+
+	// 持有2次耐久的假饵，3连抽只作用于前2次，耗尽后卸下
+	if err := ls.redisClient.HSet(ctx, gearKey(testUserID), "bait_lure", 2).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ls.gearService.Equip(ctx, &model.GearEquipRequest{UserID: testUserID, GearID: "bait_lure"}); err != nil {
+		t.Fatal(err)
+	}
+	batch, err := ls.BatchDraw(ctx, &model.LotteryBatchDrawRequest{UserID: testUserID, Count: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batch.Gear) != 1 || batch.Gear[0].GearID != "bait_lure" || batch.Gear[0].Draws != 2 || !batch.Gear[0].Broken {
+		t.Errorf("applied gear %+v, expected bait_lure for 2 draws", batch.Gear)
+	}
+	if exists, _ := ls.redisClient.HExists(ctx, gearKey(testUserID), "bait_lure").Result(); exists {
+		t.Error("consumed bait still owned")
+	}
+	if exists, _ := ls.redisClient.HExists(ctx, gearEquippedKey(testUserID), "bait").Result(); exists {
+		t.Error("consumed bait still equipped")
+	}
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"fishing-game/config"
+	"fishing-game/model"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// 保底默认配置
+	DefaultPitySoftThreshold = 30  // 连续未出稀有鱼/用户鱼达到该次数后开始逐步提升权重
+	DefaultPityHardThreshold = 50  // 连续未出稀有鱼/用户鱼达到该次数后必出
+	DefaultPityBoostStep     = 0.5 // 超过软保底后每多一次未出，权重倍数增加的量
+
+	StrategyPity = "pity"
+)
+
+// PityConfig 保底配置
+type PityConfig struct {
+	SoftThreshold int     `json:"soft_threshold"`
+	HardThreshold int     `json:"hard_threshold"`
+	BoostStep     float64 `json:"boost_step"`
+}
+
+// LoadPityConfig 从环境变量加载保底配置
+func LoadPityConfig() PityConfig {
+	return PityConfig{
+		SoftThreshold: config.GetEnvInt("PITY_SOFT_THRESHOLD", DefaultPitySoftThreshold),
+		HardThreshold: config.GetEnvInt("PITY_HARD_THRESHOLD", DefaultPityHardThreshold),
+		BoostStep:     config.GetEnvFloat("PITY_BOOST_STEP", DefaultPityBoostStep),
+	}
+}
+
+// pityKey 用户保底计数key
+func pityKey(userID string) string {
+	return fmt.Sprintf("lottery:pity:%s", userID)
+}
+
+// isPityTarget 是否为保底目标（稀有鱼或用户鱼）
+func isPityTarget(item *model.LotteryItem) bool {
+	return item.ID == RareFishID || item.IsUserFish
+}
+
+// boostMultiplier 当前连续未出次数对应的保底目标权重倍数
+func (pc PityConfig) boostMultiplier(streak int) float64 {
+	if pc.SoftThreshold <= 0 || streak < pc.SoftThreshold {
+		return 1
+	}
+	return 1 + pc.BoostStep*float64(streak-pc.SoftThreshold+1)
+}
+
+// guaranteed 当前连续未出次数是否触发必出
+func (pc PityConfig) guaranteed(streak int) bool {
+	return pc.HardThreshold > 0 && streak >= pc.HardThreshold
+}
+
+// adjusts 当前连续未出次数是否会调整权重
+func (pc PityConfig) adjusts(streak int) bool {
+	return pc.guaranteed(streak) || pc.boostMultiplier(streak) != 1
+}
+
+// applyPity 根据连续未出次数调整权重，返回调整后的权重以及是否触发必出。
+// 不修改传入的权重。
+func (pc PityConfig) applyPity(items map[string]*model.LotteryItem, weights map[string]int, streak int) (map[string]int, bool) {
+	guaranteed := pc.guaranteed(streak)
+	multiplier := pc.boostMultiplier(streak)
+	if !guaranteed && multiplier == 1 {
+		return weights, false
+	}
+
+	adjusted := make(map[string]int, len(weights))
+	targetWeight := 0
+	for fishID, weight := range weights {
+		item, exists := items[fishID]
+		isTarget := exists && isPityTarget(item)
+
+		switch {
+		case isTarget && guaranteed:
+			adjusted[fishID] = weight
+		case isTarget:
+			adjusted[fishID] = int(float64(weight) * multiplier)
+		case guaranteed:
+			adjusted[fishID] = 0
+		default:
+			adjusted[fishID] = weight
+		}
+
+		if isTarget {
+			targetWeight += weight
+		}
+	}
+
+	// 奖池中没有可保底的鱼时不做调整
+	if targetWeight == 0 {
+		return weights, false
+	}
+
+	return adjusted, guaranteed
+}
+
+// GetPityState 获取用户保底状态
+func (ls *LotteryService) GetPityState(ctx context.Context, userID string) (*model.PityStateResponse, error) {
+	streak, err := ls.getPityStreak(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	drawsUntilGuarantee := 0
+	if ls.pityConfig.HardThreshold > 0 {
+		drawsUntilGuarantee = ls.pityConfig.HardThreshold - streak
+		if drawsUntilGuarantee < 0 {
+			drawsUntilGuarantee = 0
+		}
+	}
+
+	return &model.PityStateResponse{
+		UserID:              userID,
+		Streak:              streak,
+		SoftThreshold:       ls.pityConfig.SoftThreshold,
+		HardThreshold:       ls.pityConfig.HardThreshold,
+		DrawsUntilGuarantee: drawsUntilGuarantee,
+		BoostMultiplier:     ls.pityConfig.boostMultiplier(streak),
+	}, nil
+}
+
+// getPityStreak 获取用户连续未出稀有鱼/用户鱼的次数
+func (ls *LotteryService) getPityStreak(ctx context.Context, userID string) (int, error) {
+	streak, err := ls.redisClient.Get(ctx, pityKey(userID)).Int()
+	if err != nil {
+		if err == redis.Nil {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get pity streak: %w", err)
+	}
+	return streak, nil
+}
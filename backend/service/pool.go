@@ -8,6 +8,7 @@ import (
 	"math"
 	"math/big"
 	"strings"
+	"sync"
 
 	"fishing-game/config"
 	"fishing-game/model"
@@ -17,9 +18,10 @@ import (
 )
 
 const (
-	// Redis keys
-	PoolItemsKey   = "lottery:pool:items"
-	PoolWeightsKey = "lottery:pool:weights"
+	// 旧版单奖池的Redis keys，启动时迁移到默认钓点（见 poolItemsKey 等）
+	PoolItemsKey    = "lottery:pool:items"
+	PoolWeightsKey  = "lottery:pool:weights"
+	PoolStrategyKey = "lottery:pool:strategy"
 
 	// 权重配置
 	TotalWeight      = 1000000
@@ -49,6 +51,40 @@ var (
 	RareFishID   = "00000000-0000-0000-0000-000000000005"
 )
 
+// 鱼类标签
+const (
+	TagEmpty    = "empty"
+	TagSmall    = "small"
+	TagMedium   = "medium"
+	TagLarge    = "large"
+	TagRare     = "rare"
+	TagSystem   = "system"
+	TagUserFish = "user_fish"
+)
+
+// 系统鱼类的固有标签（兼容未保存标签的历史数据）
+var systemFishTags = map[string]string{
+	EmptyFishID:  TagEmpty,
+	SmallFishID:  TagSmall,
+	MediumFishID: TagMedium,
+	LargeFishID:  TagLarge,
+	RareFishID:   TagRare,
+}
+
+// ItemHasTag 判断鱼类是否带有标签。除保存的标签外，系统鱼类带有 system 及其尺寸标签，用户鱼带有 user_fish
+func ItemHasTag(item *model.LotteryItem, tag string) bool {
+	for _, t := range item.Tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	if item.IsUserFish {
+		return tag == TagUserFish
+	}
+	return tag == TagSystem || systemFishTags[item.ID] == tag
+}
+
 // 用户鱼图片资源池（fish_1.jpg到fish_15.jpg）
 var UserFishImages = []string{
 	"fish_1", "fish_2", "fish_3", "fish_4", "fish_5",
@@ -61,7 +97,7 @@ type BorrowInfo struct {
 	MinWeight int
 }
 
-// 权重借用顺序（全局配置，便于后续修改）
+// 内置默认钓点的权重借用顺序，配置了钓点时使用各钓点的 borrow_order
 var BorrowOrder = []BorrowInfo{
 	{EmptyFishID, EmptyMinWeight},   // 优先从空军借用
 	{SmallFishID, SmallMinWeight},   // 其次从小鱼借用
@@ -72,19 +108,57 @@ var BorrowOrder = []BorrowInfo{
 
 type PoolService struct {
 	redisClient *redis.Client
+
+	// 钓点定义，每个钓点有独立的奖池
+	defaultSpot string
+	spots       map[string]*model.SpotDefinition
+	spotOrder   []*model.SpotDefinition
+
+	// 进程内奖池快照（按钓点），按版本号失效
+	snapshotMu sync.RWMutex
+	snapshots  map[string]*PoolSnapshot
 }
 
-// NewPoolService 创建奖池服务
-func NewPoolService() *PoolService {
+// NewPoolService 创建奖池服务，钓点配置文件不存在时只有内置的默认钓点
+func NewPoolService() (*PoolService, error) {
+	defaultSpot, definitions, err := loadSpotDefinitions()
+	if err != nil {
+		return nil, err
+	}
+
+	spots := make(map[string]*model.SpotDefinition, len(definitions))
+	for _, def := range definitions {
+		spots[def.ID] = def
+	}
+
 	return &PoolService{
 		redisClient: config.GetRedisClient(),
-	}
+		defaultSpot: defaultSpot,
+		spots:       spots,
+		spotOrder:   definitions,
+		snapshots:   make(map[string]*PoolSnapshot),
+	}, nil
 }
 
-// InitializePool 初始化奖池（迁移现有数据）
+// InitializePool 初始化奖池：旧版单奖池数据迁移到默认钓点，尚无数据的钓点按定义写入初始奖池
 func (ps *PoolService) InitializePool(ctx context.Context) error {
+	if err := ps.migrateLegacyPool(ctx); err != nil {
+		return err
+	}
+
+	for _, def := range ps.spotOrder {
+		if err := ps.seedSpot(ctx, def); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// seedSpot 按定义写入钓点的初始奖池，已初始化的钓点不做修改
+func (ps *PoolService) seedSpot(ctx context.Context, def *model.SpotDefinition) error {
 	// 检查是否已经初始化
-	exists, err := ps.redisClient.Exists(ctx, PoolItemsKey).Result()
+	exists, err := ps.redisClient.Exists(ctx, poolItemsKey(def.ID)).Result()
 	if err != nil {
 		return fmt.Errorf("failed to check pool existence: %w", err)
 	}
@@ -93,8 +167,38 @@ func (ps *PoolService) InitializePool(ctx context.Context) error {
 		return nil // 已经初始化过了
 	}
 
-	// 初始化系统鱼类
-	systemFishes := []*model.LotteryItem{
+	// 保存到Redis
+	pipe := ps.redisClient.Pipeline()
+
+	// 保存鱼类信息
+	for _, fish := range def.Items {
+		fishJSON, err := json.Marshal(fish)
+		if err != nil {
+			return fmt.Errorf("failed to marshal fish %s: %w", fish.ID, err)
+		}
+		pipe.HSet(ctx, poolItemsKey(def.ID), fish.ID, fishJSON)
+	}
+
+	// 保存权重信息
+	for fishID, weight := range def.Weights {
+		pipe.HSet(ctx, poolWeightsKey(def.ID), fishID, weight)
+	}
+	if def.Strategy != "" {
+		pipe.Set(ctx, poolStrategyKey(def.ID), def.Strategy, 0)
+	}
+	pipe.Incr(ctx, PoolVersionKey)
+
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to initialize pool for spot %s: %w", def.ID, err)
+	}
+
+	return nil
+}
+
+// DefaultPoolItems 奖池初始的系统鱼类
+func DefaultPoolItems() []*model.LotteryItem {
+	return []*model.LotteryItem{
 		{
 			ID:          EmptyFishID,
 			Name:        "空军",
@@ -102,6 +206,7 @@ func (ps *PoolService) InitializePool(ctx context.Context) error {
 			Points:      0,
 			IsUserFish:  false,
 			ImageURL:    "",
+			Tags:        []string{TagSystem, TagEmpty},
 		},
 		{
 			ID:          SmallFishID,
@@ -110,6 +215,8 @@ func (ps *PoolService) InitializePool(ctx context.Context) error {
 			Points:      5,
 			IsUserFish:  false,
 			ImageURL:    fmt.Sprintf("%s/small%s", BaseAssetURL, ImageExtension),
+			Tags:        []string{TagSystem, TagSmall},
+			Size:        systemFishSizes[SmallFishID],
 		},
 		{
 			ID:          MediumFishID,
@@ -118,6 +225,8 @@ func (ps *PoolService) InitializePool(ctx context.Context) error {
 			Points:      20,
 			IsUserFish:  false,
 			ImageURL:    fmt.Sprintf("%s/medium%s", BaseAssetURL, ImageExtension),
+			Tags:        []string{TagSystem, TagMedium},
+			Size:        systemFishSizes[MediumFishID],
 		},
 		{
 			ID:          LargeFishID,
@@ -126,6 +235,8 @@ func (ps *PoolService) InitializePool(ctx context.Context) error {
 			Points:      100,
 			IsUserFish:  false,
 			ImageURL:    fmt.Sprintf("%s/large%s", BaseAssetURL, ImageExtension),
+			Tags:        []string{TagSystem, TagLarge},
+			Size:        systemFishSizes[LargeFishID],
 		},
 		{
 			ID:          RareFishID,
@@ -134,41 +245,21 @@ func (ps *PoolService) InitializePool(ctx context.Context) error {
 			Points:      500,
 			IsUserFish:  false,
 			ImageURL:    fmt.Sprintf("%s/rare%s", BaseAssetURL, ImageExtension),
+			Tags:        []string{TagSystem, TagRare},
+			Size:        systemFishSizes[RareFishID],
 		},
 	}
+}
 
-	// 初始权重分布
-	initialWeights := map[string]int{
+// DefaultPoolWeights 奖池初始权重分布
+func DefaultPoolWeights() map[string]int {
+	return map[string]int{
 		EmptyFishID:  500000, // 50%
 		SmallFishID:  300000, // 30%
 		MediumFishID: 150000, // 15%
 		LargeFishID:  40000,  // 4%
 		RareFishID:   10000,  // 1%
 	}
-
-	// 保存到Redis
-	pipe := ps.redisClient.Pipeline()
-
-	// 保存鱼类信息
-	for _, fish := range systemFishes {
-		fishJSON, err := json.Marshal(fish)
-		if err != nil {
-			return fmt.Errorf("failed to marshal fish %s: %w", fish.ID, err)
-		}
-		pipe.HSet(ctx, PoolItemsKey, fish.ID, fishJSON)
-	}
-
-	// 保存权重信息
-	for fishID, weight := range initialWeights {
-		pipe.HSet(ctx, PoolWeightsKey, fishID, weight)
-	}
-
-	_, err = pipe.Exec(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to initialize pool: %w", err)
-	}
-
-	return nil
 }
 
 // getRandomUserImage 随机选择用户鱼图片
@@ -215,8 +306,52 @@ func getUserImageURL(imageName string) (string, error) {
 	return getRandomUserImage()
 }
 
-// AddFish 添加新鱼到奖池
+// addFishScript 按借用顺序从系统鱼类借出权重，借够后写入新鱼并递增奖池版本号（与 BorrowWeightsInOrder 规则一致）
+//
+// KEYS[1] 钓点鱼类 KEYS[2] 钓点权重 KEYS[3] 奖池版本号
+// ARGV[1] 新鱼ID ARGV[2] 新鱼JSON ARGV[3] 新鱼权重 ARGV[4..] 借用顺序（鱼类ID, 最低权重）
+//
+// 返回仍缺少的权重，借够并写入时为0
+var addFishScript = redis.NewScript(`
+local need = tonumber(ARGV[3])
+local weights = {}
+local borrowed = {}
+for i = 4, #ARGV, 2 do
+	if need <= 0 then
+		break
+	end
+	local fishID = ARGV[i]
+	if weights[fishID] == nil then
+		weights[fishID] = tonumber(redis.call('HGET', KEYS[2], fishID) or '0')
+	end
+	local available = weights[fishID] - tonumber(ARGV[i + 1])
+	if available > 0 then
+		local amount = math.min(need, available)
+		weights[fishID] = weights[fishID] - amount
+		borrowed[fishID] = true
+		need = need - amount
+	end
+end
+if need > 0 then
+	return need
+end
+
+for fishID in pairs(borrowed) do
+	redis.call('HSET', KEYS[2], fishID, weights[fishID])
+end
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+redis.call('HSET', KEYS[2], ARGV[1], ARGV[3])
+redis.call('INCR', KEYS[3])
+return 0
+`)
+
+// AddFish 添加新鱼到钓点奖池，借用权重与写入新鱼原子完成
 func (ps *PoolService) AddFish(ctx context.Context, req *model.AddFishRequest) (*model.AddFishResponse, error) {
+	spot, err := ps.resolveSpot(req.Spot)
+	if err != nil {
+		return nil, err
+	}
+
 	// 生成UUID
 	fishID := uuid.New().String()
 
@@ -238,28 +373,29 @@ func (ps *PoolService) AddFish(ctx context.Context, req *model.AddFishRequest) (
 		IsUserFish:  true,
 		WxID:        req.WxID, // 保存微信ID
 		ImageURL:    imageURL, // 随机分配的图片URL
+		Tags:        []string{TagUserFish},
+		Size:        DefaultUserFishSize,
 	}
 
-	// 借用权重
-	if err := ps.borrowWeight(ctx, weight); err != nil {
-		return nil, fmt.Errorf("failed to borrow weight: %w", err)
-	}
-
-	// 保存新鱼到Redis
-	pipe := ps.redisClient.Pipeline()
-
 	fishJSON, err := json.Marshal(newFish)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal new fish: %w", err)
 	}
 
-	pipe.HSet(ctx, PoolItemsKey, fishID, fishJSON)
-	pipe.HSet(ctx, PoolWeightsKey, fishID, weight)
-
-	_, err = pipe.Exec(ctx)
+	// 借用权重并保存新鱼
+	args := []interface{}{fishID, fishJSON, weight}
+	for _, borrowInfo := range SpotBorrowOrder(ps.spots[spot]) {
+		args = append(args, borrowInfo.FishID, borrowInfo.MinWeight)
+	}
+	remainingNeed, err := addFishScript.Run(ctx, ps.redisClient,
+		[]string{poolItemsKey(spot), poolWeightsKey(spot), PoolVersionKey}, args...,
+	).Int()
 	if err != nil {
 		return nil, fmt.Errorf("failed to save new fish: %w", err)
 	}
+	if remainingNeed > 0 {
+		return nil, fmt.Errorf("failed to borrow weight: insufficient weight available, still need %d", remainingNeed)
+	}
 
 	return &model.AddFishResponse{
 		ID:          fishID,
@@ -271,36 +407,23 @@ func (ps *PoolService) AddFish(ctx context.Context, req *model.AddFishRequest) (
 
 // calculateWeight 计算鱼的权重
 func (ps *PoolService) calculateWeight(description string) int {
+	return CalculateWeight(description)
+}
+
+// CalculateWeight 按描述长度计算用户鱼的权重
+func CalculateWeight(description string) int {
 	descLength := len([]rune(strings.TrimSpace(description)))
 	effectiveLength := int(math.Min(float64(descLength), float64(MaxDescLength)))
 	return BaseWeight + effectiveLength*WeightMultiplier
 }
 
-// borrowWeight 从系统鱼类借用权重
-func (ps *PoolService) borrowWeight(ctx context.Context, needWeight int) error {
-	// 获取当前权重
-	weights, err := ps.redisClient.HGetAll(ctx, PoolWeightsKey).Result()
-	if err != nil {
-		return fmt.Errorf("failed to get current weights: %w", err)
-	}
-
-	// 转换为int map
-	currentWeights := make(map[string]int)
-	for fishID, weightStr := range weights {
-		var weight int
-		if err := json.Unmarshal([]byte(weightStr), &weight); err != nil {
-			// 如果JSON解析失败，尝试直接转换字符串
-			if w, parseErr := parseWeight(weightStr); parseErr == nil {
-				weight = w
-			} else {
-				return fmt.Errorf("failed to parse weight for %s: %w", fishID, err)
-			}
-		}
-		currentWeights[fishID] = weight
-	}
-
-	// 按全局配置的优先级借用权重
+// BorrowWeights 按 BorrowOrder 从系统鱼类借出needWeight权重，返回借用后的新权重（不修改传入的权重）
+func BorrowWeights(currentWeights map[string]int, needWeight int) (map[string]int, error) {
+	return BorrowWeightsInOrder(currentWeights, needWeight, BorrowOrder)
+}
 
+// BorrowWeightsInOrder 按指定的借用顺序借出needWeight权重，返回借用后的新权重（不修改传入的权重）
+func BorrowWeightsInOrder(currentWeights map[string]int, needWeight int, order []BorrowInfo) (map[string]int, error) {
 	remainingNeed := needWeight
 	newWeights := make(map[string]int)
 
@@ -310,7 +433,7 @@ func (ps *PoolService) borrowWeight(ctx context.Context, needWeight int) error {
 	}
 
 	// 按顺序借用
-	for _, borrowInfo := range BorrowOrder {
+	for _, borrowInfo := range order {
 		if remainingNeed <= 0 {
 			break
 		}
@@ -327,35 +450,27 @@ func (ps *PoolService) borrowWeight(ctx context.Context, needWeight int) error {
 
 	// 检查是否成功借到足够权重
 	if remainingNeed > 0 {
-		return fmt.Errorf("insufficient weight available, still need %d", remainingNeed)
+		return nil, fmt.Errorf("insufficient weight available, still need %d", remainingNeed)
 	}
 
-	// 更新Redis中的权重
-	pipe := ps.redisClient.Pipeline()
-	for fishID, weight := range newWeights {
-		if currentWeights[fishID] != weight {
-			pipe.HSet(ctx, PoolWeightsKey, fishID, weight)
-		}
-	}
+	return newWeights, nil
+}
 
-	_, err = pipe.Exec(ctx)
+// GetPool 获取钓点的完整奖池信息，spot为空时为默认钓点
+func (ps *PoolService) GetPool(ctx context.Context, spot string) (*model.PoolInfoResponse, error) {
+	spot, err := ps.resolveSpot(spot)
 	if err != nil {
-		return fmt.Errorf("failed to update weights: %w", err)
+		return nil, err
 	}
 
-	return nil
-}
-
-// GetPool 获取完整奖池信息
-func (ps *PoolService) GetPool(ctx context.Context) (*model.PoolInfoResponse, error) {
 	// 获取所有鱼类
-	itemsData, err := ps.redisClient.HGetAll(ctx, PoolItemsKey).Result()
+	itemsData, err := ps.redisClient.HGetAll(ctx, poolItemsKey(spot)).Result()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get items: %w", err)
 	}
 
 	// 获取所有权重
-	weightsData, err := ps.redisClient.HGetAll(ctx, PoolWeightsKey).Result()
+	weightsData, err := ps.redisClient.HGetAll(ctx, poolWeightsKey(spot)).Result()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get weights: %w", err)
 	}
@@ -387,14 +502,45 @@ func (ps *PoolService) GetPool(ctx context.Context) (*model.PoolInfoResponse, er
 		totalWeight += weight
 	}
 
+	// 获取奖池默认策略
+	strategy, err := ps.redisClient.Get(ctx, poolStrategyKey(spot)).Result()
+	if err != nil {
+		if err != redis.Nil {
+			return nil, fmt.Errorf("failed to get pool strategy: %w", err)
+		}
+		strategy = StrategyDefault
+	}
+
 	return &model.PoolInfoResponse{
+		Spot:        spot,
 		TotalItems:  len(items),
 		Items:       items,
 		Weights:     weights,
 		TotalWeight: totalWeight,
+		Strategy:    strategy,
 	}, nil
 }
 
+// SetStrategy 设置钓点奖池的默认抽奖策略，spot为空时为默认钓点
+func (ps *PoolService) SetStrategy(ctx context.Context, spot, strategy string) error {
+	spot, err := ps.resolveSpot(spot)
+	if err != nil {
+		return err
+	}
+	if _, err := DrawStrategies.Get(strategy); err != nil {
+		return err
+	}
+
+	pipe := ps.redisClient.TxPipeline()
+	pipe.Set(ctx, poolStrategyKey(spot), strategy, 0)
+	pipe.Incr(ctx, PoolVersionKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to set pool strategy: %w", err)
+	}
+
+	return nil
+}
+
 // parseWeight 辅助函数：解析权重字符串
 func parseWeight(weightStr string) (int, error) {
 	// 尝试多种解析方式
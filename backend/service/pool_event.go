@@ -0,0 +1,434 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"fishing-game/config"
+	"fishing-game/model"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// Redis keys
+	PoolEventsKey       = "lottery:events"         // 限时活动（HASH，活动ID -> PoolEvent JSON）
+	PoolEventsEndKey    = "lottery:events:end"     // 活动结束时间索引（ZSET，score为结束时间毫秒）
+	PoolEventVersionKey = "lottery:events:version" // 活动版本号，活动任何变更都会自增
+
+	// PoolEventContextKey 限时活动在生效修正列表中使用的context_key
+	PoolEventContextKey = "event"
+
+	// DefaultPoolEventRetention 活动结束后保留的时长，超过后自动删除
+	DefaultPoolEventRetention = 7 * 24 * time.Hour
+)
+
+var (
+	// ErrInvalidPoolEvent 活动内容不合法
+	ErrInvalidPoolEvent = errors.New("invalid pool event")
+	// ErrPoolEventNotFound 活动不存在
+	ErrPoolEventNotFound = errors.New("pool event not found")
+	// ErrPoolEventClosed 活动已取消或已结束
+	ErrPoolEventClosed = errors.New("pool event is cancelled or ended")
+)
+
+// poolEventPruneScript 删除结束时间早于截止时间的活动
+//
+// KEYS[1] 活动 KEYS[2] 结束时间索引 KEYS[3] 活动版本号
+// ARGV[1] 截止时间（毫秒）
+//
+// 返回删除的活动数
+var poolEventPruneScript = redis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[1])
+if #ids == 0 then
+	return 0
+end
+for _, id in ipairs(ids) do
+	redis.call('HDEL', KEYS[1], id)
+	redis.call('ZREM', KEYS[2], id)
+end
+redis.call('INCR', KEYS[3])
+return #ids
+`)
+
+// poolEventUpdateScript 活动内容未被并发修改时写入新内容
+//
+// KEYS[1] 活动 KEYS[2] 活动版本号
+// ARGV[1] 活动ID ARGV[2] 原内容 ARGV[3] 新内容
+//
+// 返回是否写入
+var poolEventUpdateScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], ARGV[1]) ~= ARGV[2] then
+	return 0
+end
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[3])
+redis.call('INCR', KEYS[2])
+return 1
+`)
+
+// PoolEventService 限时活动服务：在指定时间段内按鱼类或标签调整奖池权重，到期自动失效
+type PoolEventService struct {
+	redisClient *redis.Client
+	poolService *PoolService
+	retention   time.Duration
+
+	// 进程内活动缓存，按版本号失效
+	cacheMu      sync.RWMutex
+	cacheLoaded  bool
+	cacheVersion int64
+	cached       []*model.PoolEvent
+}
+
+// NewPoolEventService 创建限时活动服务
+func NewPoolEventService(poolService *PoolService) *PoolEventService {
+	return &PoolEventService{
+		redisClient: config.GetRedisClient(),
+		poolService: poolService,
+		retention:   config.GetEnvDuration("POOL_EVENT_RETENTION", DefaultPoolEventRetention),
+	}
+}
+
+// poolEventStatus 活动在指定时间的状态
+func poolEventStatus(event *model.PoolEvent, at time.Time) string {
+	switch {
+	case event.CancelledAt != nil:
+		return model.PoolEventStatusCancelled
+	case at.Before(event.StartAt):
+		return model.PoolEventStatusScheduled
+	case at.Before(event.EndAt):
+		return model.PoolEventStatusActive
+	default:
+		return model.PoolEventStatusEnded
+	}
+}
+
+// poolEventApplies 活动是否作用于钓点
+func poolEventApplies(event *model.PoolEvent, spot string) bool {
+	return event.Spot == "" || event.Spot == spot
+}
+
+// CreateEvent 创建限时活动
+func (es *PoolEventService) CreateEvent(ctx context.Context, req *model.CreatePoolEventRequest) (*model.PoolEvent, error) {
+	now := time.Now()
+	if !req.StartAt.Before(req.EndAt) {
+		return nil, fmt.Errorf("%w: start_at must be before end_at", ErrInvalidPoolEvent)
+	}
+	if !req.EndAt.After(now) {
+		return nil, fmt.Errorf("%w: end_at must be in the future", ErrInvalidPoolEvent)
+	}
+	if err := validateModifierEffects(req.Effects); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPoolEvent, err)
+	}
+	if req.Spot != "" {
+		if _, err := es.poolService.resolveSpot(req.Spot); err != nil {
+			return nil, err
+		}
+	}
+
+	es.prune(ctx, now)
+
+	event := &model.PoolEvent{
+		ID:        uuid.New().String(),
+		Name:      req.Name,
+		Spot:      req.Spot,
+		StartAt:   req.StartAt,
+		EndAt:     req.EndAt,
+		Effects:   req.Effects,
+		CreatedAt: now,
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal pool event: %w", err)
+	}
+
+	pipe := es.redisClient.TxPipeline()
+	pipe.HSet(ctx, PoolEventsKey, event.ID, data)
+	pipe.ZAdd(ctx, PoolEventsEndKey, redis.Z{Score: float64(event.EndAt.UnixMilli()), Member: event.ID})
+	pipe.Incr(ctx, PoolEventVersionKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to save pool event: %w", err)
+	}
+
+	event.Status = poolEventStatus(event, now)
+	return event, nil
+}
+
+// ListEvents 获取限时活动列表，可按钓点（包括作用于所有钓点的活动）和状态筛选
+func (es *PoolEventService) ListEvents(ctx context.Context, spot, status string) (*model.PoolEventListResponse, error) {
+	switch status {
+	case "", model.PoolEventStatusScheduled, model.PoolEventStatusActive, model.PoolEventStatusEnded, model.PoolEventStatusCancelled:
+	default:
+		return nil, fmt.Errorf("%w: unknown status %s", ErrInvalidPoolEvent, status)
+	}
+	if spot != "" {
+		if _, err := es.poolService.resolveSpot(spot); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	es.prune(ctx, now)
+
+	events, err := es.getEvents(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &model.PoolEventListResponse{Events: make([]*model.PoolEvent, 0, len(events))}
+	for _, cached := range events {
+		if spot != "" && !poolEventApplies(cached, spot) {
+			continue
+		}
+		event := *cached
+		event.Status = poolEventStatus(&event, now)
+		if status != "" && event.Status != status {
+			continue
+		}
+		response.Events = append(response.Events, &event)
+	}
+
+	return response, nil
+}
+
+// CancelEvent 取消尚未结束的活动，取消后立即停止生效
+func (es *PoolEventService) CancelEvent(ctx context.Context, eventID string) (*model.PoolEvent, error) {
+	data, err := es.redisClient.HGet(ctx, PoolEventsKey, eventID).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, fmt.Errorf("%w: %s", ErrPoolEventNotFound, eventID)
+		}
+		return nil, fmt.Errorf("failed to get pool event: %w", err)
+	}
+
+	var event model.PoolEvent
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal pool event: %w", err)
+	}
+
+	now := time.Now()
+	if status := poolEventStatus(&event, now); status == model.PoolEventStatusCancelled || status == model.PoolEventStatusEnded {
+		return nil, ErrPoolEventClosed
+	}
+
+	event.CancelledAt = &now
+	updated, err := json.Marshal(&event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal pool event: %w", err)
+	}
+
+	written, err := poolEventUpdateScript.Run(ctx, es.redisClient, []string{PoolEventsKey, PoolEventVersionKey}, eventID, data, updated).Int()
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel pool event: %w", err)
+	}
+	if written == 0 {
+		// 并发取消或已被清理
+		return nil, ErrPoolEventClosed
+	}
+
+	event.Status = model.PoolEventStatusCancelled
+	return &event, nil
+}
+
+// GetEffectivePool 获取钓点在指定时间点的有效奖池（当前存储权重叠加该时间点生效的活动），spot为空时为默认钓点
+func (es *PoolEventService) GetEffectivePool(ctx context.Context, spot string, at time.Time) (*model.EffectivePoolResponse, error) {
+	snapshot, err := es.poolService.GetSnapshot(ctx, spot)
+	if err != nil {
+		return nil, err
+	}
+	pool := snapshot.Pool
+
+	active, err := es.activeEvents(ctx, pool.Spot, at)
+	if err != nil {
+		return nil, err
+	}
+	weights := applyPoolEvents(pool.Items, pool.Weights, active)
+
+	response := &model.EffectivePoolResponse{
+		Spot:          pool.Spot,
+		At:            at,
+		Items:         pool.Items,
+		StoredWeights: pool.Weights,
+		Weights:       weights,
+		Strategy:      pool.Strategy,
+		Events:        make([]*model.PoolEvent, 0, len(active)),
+	}
+	for _, weight := range weights {
+		response.TotalWeight += weight
+	}
+	for _, cached := range active {
+		event := *cached
+		event.Status = model.PoolEventStatusActive
+		response.Events = append(response.Events, &event)
+	}
+
+	return response, nil
+}
+
+// overlay 计算抽奖时的奖池权重：在存储权重上叠加生效的活动并归一，返回新的权重与生效修正列表。
+// 没有活动生效时原样返回存储权重
+func (es *PoolEventService) overlay(ctx context.Context, spot string, items map[string]*model.LotteryItem, weights map[string]int, at time.Time) (map[string]int, []model.AppliedModifier, error) {
+	active, err := es.activeEvents(ctx, spot, at)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(active) == 0 {
+		return weights, nil, nil
+	}
+
+	applied := make([]model.AppliedModifier, 0, len(active))
+	for _, event := range active {
+		applied = append(applied, model.AppliedModifier{
+			ID:         event.ID,
+			Name:       event.Name,
+			ContextKey: PoolEventContextKey,
+			Value:      event.EndAt.Format(time.RFC3339),
+		})
+	}
+	return applyPoolEvents(items, weights, active), applied, nil
+}
+
+// activeEvents 钓点在指定时间点生效的活动，按开始时间排列
+func (es *PoolEventService) activeEvents(ctx context.Context, spot string, at time.Time) ([]*model.PoolEvent, error) {
+	events, err := es.getEvents(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var active []*model.PoolEvent
+	for _, event := range events {
+		if poolEventApplies(event, spot) && poolEventStatus(event, at) == model.PoolEventStatusActive {
+			active = append(active, event)
+		}
+	}
+	return active, nil
+}
+
+// applyPoolEvents 依次叠加活动修正后按原总权重归一，不修改传入的权重。修正后总权重为0时保持原权重
+func applyPoolEvents(items map[string]*model.LotteryItem, weights map[string]int, events []*model.PoolEvent) map[string]int {
+	if len(events) == 0 {
+		return weights
+	}
+
+	total := 0
+	adjusted := make(map[string]int, len(weights))
+	for fishID, weight := range weights {
+		adjusted[fishID] = weight
+		total += weight
+	}
+	for _, event := range events {
+		for _, effect := range event.Effects {
+			applyEffect(items, adjusted, effect)
+		}
+	}
+
+	normalized, ok := normalizeWeights(adjusted, total)
+	if !ok {
+		log.Printf("Pool events zeroed all weights, ignoring events")
+		return weights
+	}
+	return normalized
+}
+
+// normalizeWeights 按比例缩放权重使总和为total，舍入误差按最大余数法分配（余数相同时按鱼类ID）。
+// 权重总和为0时返回false
+func normalizeWeights(weights map[string]int, total int) (map[string]int, bool) {
+	var sum int64
+	for _, weight := range weights {
+		sum += int64(weight)
+	}
+	if sum <= 0 {
+		return nil, false
+	}
+
+	type remainder struct {
+		fishID string
+		value  int64
+	}
+	normalized := make(map[string]int, len(weights))
+	remainders := make([]remainder, 0, len(weights))
+	assigned := 0
+	for fishID, weight := range weights {
+		scaled := int64(weight) * int64(total)
+		normalized[fishID] = int(scaled / sum)
+		assigned += normalized[fishID]
+		remainders = append(remainders, remainder{fishID: fishID, value: scaled % sum})
+	}
+
+	sort.Slice(remainders, func(i, j int) bool {
+		if remainders[i].value != remainders[j].value {
+			return remainders[i].value > remainders[j].value
+		}
+		return remainders[i].fishID < remainders[j].fishID
+	})
+	for i := 0; assigned < total; i++ {
+		normalized[remainders[i].fishID]++
+		assigned++
+	}
+
+	return normalized, true
+}
+
+// getEvents 获取所有活动（按开始时间排列，只读）。仅当版本号变化时才重新加载
+func (es *PoolEventService) getEvents(ctx context.Context) ([]*model.PoolEvent, error) {
+	version, err := es.redisClient.Get(ctx, PoolEventVersionKey).Int64()
+	if err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get pool event version: %w", err)
+	}
+
+	es.cacheMu.RLock()
+	if es.cacheLoaded && es.cacheVersion == version {
+		cached := es.cached
+		es.cacheMu.RUnlock()
+		return cached, nil
+	}
+	es.cacheMu.RUnlock()
+
+	es.cacheMu.Lock()
+	defer es.cacheMu.Unlock()
+
+	// 等锁期间可能已被其他请求重新加载
+	if es.cacheLoaded && es.cacheVersion == version {
+		return es.cached, nil
+	}
+
+	data, err := es.redisClient.HGetAll(ctx, PoolEventsKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool events: %w", err)
+	}
+
+	events := make([]*model.PoolEvent, 0, len(data))
+	for eventID, eventJSON := range data {
+		var event model.PoolEvent
+		if err := json.Unmarshal([]byte(eventJSON), &event); err != nil {
+			log.Printf("Failed to unmarshal pool event %s: %v", eventID, err)
+			continue
+		}
+		events = append(events, &event)
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].StartAt.Equal(events[j].StartAt) {
+			return events[i].StartAt.Before(events[j].StartAt)
+		}
+		return events[i].ID < events[j].ID
+	})
+
+	es.cacheLoaded = true
+	es.cacheVersion = version
+	es.cached = events
+	return events, nil
+}
+
+// prune 删除结束超过保留时长的活动，失败只记录日志
+func (es *PoolEventService) prune(ctx context.Context, now time.Time) {
+	cutoff := now.Add(-es.retention).UnixMilli()
+	if err := poolEventPruneScript.Run(ctx, es.redisClient, []string{PoolEventsKey, PoolEventsEndKey, PoolEventVersionKey}, cutoff).Err(); err != nil {
+		log.Printf("Failed to prune pool events: %v", err)
+	}
+}
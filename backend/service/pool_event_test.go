@@ -0,0 +1,128 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"fishing-game/model"
+)
+
+func TestNormalizeWeightsUsesLargestRemainder(t *testing.T) {
+	for name, tc := range map[string]struct {
+		weights map[string]int
+		total   int
+		want    map[string]int
+	}{
+		"exact scale":     {map[string]int{"a": 1, "b": 3}, 8, map[string]int{"a": 2, "b": 6}},
+		"largest first":   {map[string]int{"a": 1, "b": 2}, 10, map[string]int{"a": 3, "b": 7}},
+		"ties by fish id": {map[string]int{"c": 1, "b": 1, "a": 1}, 10, map[string]int{"a": 4, "b": 3, "c": 3}},
+		"zero weight":     {map[string]int{"a": 0, "b": 5}, 7, map[string]int{"a": 0, "b": 7}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, ok := normalizeWeights(tc.weights, tc.total)
+			if !ok || !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("normalizeWeights(%v, %d) = %v, %v, expected %v", tc.weights, tc.total, got, ok, tc.want)
+			}
+		})
+	}
+
+	if _, ok := normalizeWeights(map[string]int{"a": 0}, 10); ok {
+		t.Error("expected zero total to be rejected")
+	}
+}
+
+func TestEffectivePoolAppliesActiveEventsUntilCancelled(t *testing.T) {
+	ps, ctx := newTestPoolService(t)
+	es := NewPoolEventService(ps)
+	now := time.Now()
+
+	active, err := es.CreateEvent(ctx, &model.CreatePoolEventRequest{
+		Name:    "小鱼翻倍",
+		StartAt: now.Add(-time.Minute),
+		EndAt:   now.Add(time.Hour),
+		Effects: []*model.ModifierEffect{{ItemID: SmallFishID, Multiply: float64Ptr(2)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := es.CreateEvent(ctx, &model.CreatePoolEventRequest{
+		Name:    "尚未开始",
+		StartAt: now.Add(time.Hour),
+		EndAt:   now.Add(2 * time.Hour),
+		Effects: []*model.ModifierEffect{{Tag: TagSystem, Multiply: float64Ptr(0)}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	pool, err := es.GetEffectivePool(ctx, "", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storedTotal := 0
+	for _, weight := range pool.StoredWeights {
+		storedTotal += weight
+	}
+	if len(pool.Events) != 1 || pool.Events[0].ID != active.ID {
+		t.Fatalf("effective events %+v, expected only %s", pool.Events, active.ID)
+	}
+	if pool.TotalWeight != storedTotal || pool.Weights[SmallFishID] <= pool.StoredWeights[SmallFishID] {
+		t.Errorf("effective weights %v (total %d), stored %v", pool.Weights, pool.TotalWeight, pool.StoredWeights)
+	}
+
+	if _, err := es.CancelEvent(ctx, active.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := es.CancelEvent(ctx, active.ID); !errors.Is(err, ErrPoolEventClosed) {
+		t.Fatalf("expected ErrPoolEventClosed, got %v", err)
+	}
+	pool, err = es.GetEffectivePool(ctx, "", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pool.Events) != 0 || !reflect.DeepEqual(pool.Weights, pool.StoredWeights) {
+		t.Errorf("cancelled event still applied: %+v", pool.Events)
+	}
+}
+
+func TestCancelEventConflictWritesOnce(t *testing.T) {
+	ps, ctx := newTestPoolService(t)
+	es := NewPoolEventService(ps)
+	now := time.Now()
+
+	event, err := es.CreateEvent(ctx, &model.CreatePoolEventRequest{
+		Name:    "小鱼翻倍",
+		StartAt: now,
+		EndAt:   now.Add(time.Hour),
+		Effects: []*model.ModifierEffect{{ItemID: SmallFishID, Multiply: float64Ptr(2)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := poolEventUpdateScript.Load(ctx, es.redisClient).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 读取活动后、写入前被另一个请求取消
+	hook := &faultHook{match: scriptCall(poolEventUpdateScript)}
+	hook.onMatch = func() {
+		if hook.hits == 1 {
+			if _, err := es.CancelEvent(ctx, event.ID); err != nil {
+				t.Error(err)
+			}
+		}
+	}
+	es.redisClient.AddHook(hook)
+
+	version, err := es.redisClient.Get(ctx, PoolEventVersionKey).Int64()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := es.CancelEvent(ctx, event.ID); !errors.Is(err, ErrPoolEventClosed) {
+		t.Fatalf("expected ErrPoolEventClosed, got %v", err)
+	}
+	if got, _ := es.redisClient.Get(ctx, PoolEventVersionKey).Int64(); got != version+1 {
+		t.Errorf("event version %d, expected one write after %d", got, version)
+	}
+}
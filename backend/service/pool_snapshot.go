@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"fishing-game/model"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// PoolVersionKey 奖池版本号，任一钓点奖池的变更都会自增
+const PoolVersionKey = "lottery:pool:version"
+
+// PoolSnapshot 进程内缓存的奖池快照（只读，不得修改）
+type PoolSnapshot struct {
+	Version int64
+	Pool    *model.PoolInfoResponse
+	Alias   *AliasTable // 基于奖池存储权重预先构建的别名表
+}
+
+// GetSnapshot 获取钓点的奖池快照，spot为空时为默认钓点。仅当Redis中的版本号变化时才重新加载奖池并构建别名表，
+// 其余情况只需一次GET。
+func (ps *PoolService) GetSnapshot(ctx context.Context, spot string) (*PoolSnapshot, error) {
+	spot, err := ps.resolveSpot(spot)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := ps.getVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ps.snapshotMu.RLock()
+	cached := ps.snapshots[spot]
+	ps.snapshotMu.RUnlock()
+	if cached != nil && cached.Version == version {
+		return cached, nil
+	}
+
+	ps.snapshotMu.Lock()
+	defer ps.snapshotMu.Unlock()
+
+	// 等锁期间可能已被其他请求重建
+	if cached := ps.snapshots[spot]; cached != nil && cached.Version == version {
+		return cached, nil
+	}
+
+	pool, err := ps.GetPool(ctx, spot)
+	if err != nil {
+		return nil, err
+	}
+
+	alias, err := NewAliasTable(pool.Weights)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build alias table: %w", err)
+	}
+
+	snapshot := &PoolSnapshot{
+		Version: version,
+		Pool:    pool,
+		Alias:   alias,
+	}
+	ps.snapshots[spot] = snapshot
+
+	return snapshot, nil
+}
+
+// getVersion 获取奖池版本号
+func (ps *PoolService) getVersion(ctx context.Context) (int64, error) {
+	version, err := ps.redisClient.Get(ctx, PoolVersionKey).Int64()
+	if err != nil {
+		if err == redis.Nil {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get pool version: %w", err)
+	}
+	return version, nil
+}
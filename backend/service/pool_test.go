@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"fishing-game/model"
+)
+
+// newTestPoolService 基于miniredis创建并初始化奖池服务
+func newTestPoolService(t *testing.T) (*PoolService, context.Context) {
+	t.Helper()
+	setupTestRedis(t)
+	ctx := context.Background()
+
+	ps, err := NewPoolService()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ps.InitializePool(ctx); err != nil {
+		t.Fatal(err)
+	}
+	return ps, ctx
+}
+
+func TestAddFishBorrowsWeightAtomically(t *testing.T) {
+	ps, ctx := newTestPoolService(t)
+	spot := ps.DefaultSpot()
+	before, err := ps.GetPool(ctx, spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	version, err := ps.redisClient.Get(ctx, PoolVersionKey).Int64()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := &model.AddFishRequest{Name: "测试鱼", Description: "一条用于测试的鱼", WxID: "creator"}
+	resp, err := ps.AddFish(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := ps.GetPool(ctx, spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := BorrowWeightsInOrder(before.Weights, CalculateWeight(req.Description), SpotBorrowOrder(ps.spots[spot]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want[resp.ID] = CalculateWeight(req.Description)
+	if !reflect.DeepEqual(after.Weights, want) {
+		t.Errorf("weights %v, expected %v", after.Weights, want)
+	}
+	if after.TotalWeight != before.TotalWeight {
+		t.Errorf("total weight %d, expected %d", after.TotalWeight, before.TotalWeight)
+	}
+	if item := after.Items[resp.ID]; item == nil || item.WxID != req.WxID {
+		t.Errorf("new fish not saved: %+v", item)
+	}
+	if got, _ := ps.redisClient.Get(ctx, PoolVersionKey).Int64(); got != version+1 {
+		t.Errorf("pool version %d, expected %d", got, version+1)
+	}
+}
+
+func TestAddFishInsufficientWeightLeavesNoWrites(t *testing.T) {
+	ps, ctx := newTestPoolService(t)
+	spot := ps.DefaultSpot()
+
+	// 所有可借用的鱼类都压到下限
+	for _, borrowInfo := range SpotBorrowOrder(ps.spots[spot]) {
+		if err := ps.redisClient.HSet(ctx, poolWeightsKey(spot), borrowInfo.FishID, borrowInfo.MinWeight).Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	before, err := ps.GetPool(ctx, spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	version, err := ps.redisClient.Get(ctx, PoolVersionKey).Int64()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ps.AddFish(ctx, &model.AddFishRequest{Name: "测试鱼", Description: "一条用于测试的鱼"}); err == nil {
+		t.Fatal("expected insufficient weight error")
+	}
+
+	after, err := ps.GetPool(ctx, spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(before, after) {
+		t.Errorf("pool changed after failed add:\nbefore %+v\nafter  %+v", before, after)
+	}
+	if got, _ := ps.redisClient.Get(ctx, PoolVersionKey).Int64(); got != version {
+		t.Errorf("pool version %d, expected %d", got, version)
+	}
+}
+
+// seedLegacyPool 写入旧版单奖池数据，小鱼权重为smallWeight
+func seedLegacyPool(t *testing.T, ps *PoolService, ctx context.Context, smallWeight int) {
+	t.Helper()
+	pipe := ps.redisClient.Pipeline()
+	for _, item := range DefaultPoolItems() {
+		itemJSON, err := json.Marshal(item)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pipe.HSet(ctx, PoolItemsKey, item.ID, itemJSON)
+	}
+	pipe.HSet(ctx, PoolWeightsKey, SmallFishID, smallWeight)
+	pipe.Set(ctx, PoolStrategyKey, "legacy_strategy", 0)
+	if _, err := pipe.Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInitializePoolMigratesLegacyPool(t *testing.T) {
+	setupTestRedis(t)
+	ctx := context.Background()
+	ps, err := NewPoolService()
+	if err != nil {
+		t.Fatal(err)
+	}
+	seedLegacyPool(t, ps, ctx, 777)
+
+	if err := ps.InitializePool(ctx); err != nil {
+		t.Fatal(err)
+	}
+	spot := ps.DefaultSpot()
+	if weight, _ := ps.redisClient.HGet(ctx, poolWeightsKey(spot), SmallFishID).Int(); weight != 777 {
+		t.Errorf("migrated small fish weight %d, expected 777", weight)
+	}
+	if strategy, _ := ps.redisClient.Get(ctx, poolStrategyKey(spot)).Result(); strategy != "legacy_strategy" {
+		t.Errorf("migrated strategy %q", strategy)
+	}
+	if n, _ := ps.redisClient.Exists(ctx, PoolItemsKey, PoolWeightsKey, PoolStrategyKey).Result(); n != 0 {
+		t.Errorf("%d legacy keys left after migration", n)
+	}
+	// 迁移一次，默认钓点不再初始化，其他钓点各初始化一次
+	if version, _ := ps.redisClient.Get(ctx, PoolVersionKey).Int64(); version != int64(len(ps.spotOrder)) {
+		t.Errorf("pool version %d, expected %d", version, len(ps.spotOrder))
+	}
+}
+
+func TestInitializePoolKeepsExistingSpotOverLegacyPool(t *testing.T) {
+	ps, ctx := newTestPoolService(t)
+	spot := ps.DefaultSpot()
+	before, err := ps.GetPool(ctx, spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	version, err := ps.redisClient.Get(ctx, PoolVersionKey).Int64()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 默认钓点已有数据时，残留的旧版奖池不覆盖钓点
+	seedLegacyPool(t, ps, ctx, 777)
+	if err := ps.InitializePool(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := ps.GetPool(ctx, spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(before, after) {
+		t.Errorf("spot pool changed by legacy migration:\nbefore %+v\nafter  %+v", before, after)
+	}
+	if n, _ := ps.redisClient.Exists(ctx, PoolItemsKey, PoolWeightsKey, PoolStrategyKey).Result(); n != 3 {
+		t.Errorf("legacy keys touched, %d left", n)
+	}
+	if got, _ := ps.redisClient.Get(ctx, PoolVersionKey).Int64(); got != version {
+		t.Errorf("pool version %d, expected %d", got, version)
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"fmt"
+	"log"
 	"time"
 
 	"fishing-game/config"
@@ -16,21 +17,55 @@ const (
 )
 
 type RankingService struct {
-	redisClient *redis.Client
-	UserService *UserService
+	redisClient        *redis.Client
+	UserService        *UserService
+	idempotencyService *IdempotencyService
+	listeners          []ScoreListener
+}
+
+// ScoreListener 积分变更监听器，在手动增加积分成功后同步调用（抽奖加分通过 DrawListener 通知）
+type ScoreListener interface {
+	OnScoreChange(ctx context.Context, userID string, score int) error
 }
 
 // NewRankingService 创建榜单服务
-func NewRankingService(UserService *UserService) *RankingService {
+func NewRankingService(UserService *UserService, idempotencyService *IdempotencyService) *RankingService {
 	return &RankingService{
-		redisClient: config.GetRedisClient(),
-		UserService: UserService,
+		redisClient:        config.GetRedisClient(),
+		UserService:        UserService,
+		idempotencyService: idempotencyService,
 	}
 }
 
-// IncrementScore 增加用户积分
+// IncrementScore 增加用户积分（携带trace_id时幂等，重复请求不会重复加分）
 func (rs *RankingService) IncrementScore(ctx context.Context, req *model.RankingIncrementRequest) (*model.RankingIncrementResponse, error) {
-	userKey := fmt.Sprintf("user:%s", req.UserID)
+	return withIdempotency(ctx, rs.idempotencyService, IdempotencyScopeIncrement, req.UserID, req.TraceID, req, func() (*model.RankingIncrementResponse, error) {
+		response, err := rs.incrementScore(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		rs.notifyScoreListeners(ctx, req.UserID, response.NewScore)
+		return response, nil
+	})
+}
+
+// AddScoreListener 注册积分变更监听器
+func (rs *RankingService) AddScoreListener(listener ScoreListener) {
+	rs.listeners = append(rs.listeners, listener)
+}
+
+// notifyScoreListeners 通知所有监听器。积分已经增加，监听器失败只记录日志
+func (rs *RankingService) notifyScoreListeners(ctx context.Context, userID string, score int) {
+	for _, listener := range rs.listeners {
+		if err := listener.OnScoreChange(ctx, userID, score); err != nil {
+			log.Printf("Score listener %T failed for %s: %v", listener, userID, err)
+		}
+	}
+}
+
+// incrementScore 执行一次积分增加
+func (rs *RankingService) incrementScore(ctx context.Context, req *model.RankingIncrementRequest) (*model.RankingIncrementResponse, error) {
+	userKey := rankingMember(req.UserID)
 
 	// 使用 ZINCRBY 增加积分
 	newScore, err := rs.redisClient.ZIncrBy(ctx, GlobalRankingKey, float64(req.Delta), userKey).Result()
@@ -52,6 +87,11 @@ func (rs *RankingService) IncrementScore(ctx context.Context, req *model.Ranking
 	}, nil
 }
 
+// rankingMember 榜单成员名（user:{user_id}）
+func rankingMember(userID string) string {
+	return fmt.Sprintf("user:%s", userID)
+}
+
 // GetTopRanking 获取Top N排行榜
 func (rs *RankingService) GetTopRanking(ctx context.Context, req *model.RankingTopRequest) (*model.RankingTopResponse, error) {
 	// 计算偏移量
@@ -106,7 +146,7 @@ func (rs *RankingService) GetTopRanking(ctx context.Context, req *model.RankingT
 
 // GetUserRanking 获取单个用户的排名和积分
 func (rs *RankingService) GetUserRanking(ctx context.Context, userID string) (*model.RankingUserResponse, error) {
-	userKey := fmt.Sprintf("user:%s", userID)
+	userKey := rankingMember(userID)
 
 	// 获取用户信息
 	userResp, err := rs.UserService.GetUser(ctx, userID)
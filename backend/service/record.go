@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"fishing-game/config"
+	"fishing-game/model"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// GlobalRecordsKey 全服各鱼类最大渔获纪录（item_id -> CatchRecord JSON）
+const GlobalRecordsKey = "lottery:records:global"
+
+// RecordService 渔获纪录服务。纪录在抽奖提交脚本中原子更新
+type RecordService struct {
+	redisClient *redis.Client
+}
+
+// NewRecordService 创建纪录服务
+func NewRecordService() *RecordService {
+	return &RecordService{
+		redisClient: config.GetRedisClient(),
+	}
+}
+
+// personalRecordsKey 用户各鱼类个人最佳纪录
+func personalRecordsKey(userID string) string {
+	return fmt.Sprintf("lottery:records:user:%s", userID)
+}
+
+// GetPersonalRecords 获取用户各鱼类的个人最佳纪录
+func (rs *RecordService) GetPersonalRecords(ctx context.Context, userID string) (*model.CatchRecordsResponse, error) {
+	records, err := rs.getRecords(ctx, personalRecordsKey(userID))
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.CatchRecordsResponse{
+		UserID:  userID,
+		Records: records,
+	}, nil
+}
+
+// GetGlobalRecords 获取全服各鱼类的最大渔获纪录
+func (rs *RecordService) GetGlobalRecords(ctx context.Context) (*model.CatchRecordsResponse, error) {
+	records, err := rs.getRecords(ctx, GlobalRecordsKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.CatchRecordsResponse{
+		Records: records,
+	}, nil
+}
+
+// getRecords 读取纪录hash，按鱼类ID排序
+func (rs *RecordService) getRecords(ctx context.Context, key string) ([]*model.CatchRecord, error) {
+	data, err := rs.redisClient.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get catch records: %w", err)
+	}
+
+	records := make([]*model.CatchRecord, 0, len(data))
+	for itemID, recordJSON := range data {
+		var record model.CatchRecord
+		if err := json.Unmarshal([]byte(recordJSON), &record); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal catch record %s: %w", itemID, err)
+		}
+		records = append(records, &record)
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].ItemID < records[j].ItemID
+	})
+
+	return records, nil
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"fishing-game/model"
+)
+
+// seedSingleFishBoost 为用户准备下一次抽奖只会钓到itemID的加成
+func seedSingleFishBoost(t *testing.T, ls *LotteryService, ctx context.Context, itemID string) {
+	t.Helper()
+	boost, err := json.Marshal(&model.DrawBoost{
+		ID:     "test_single_fish",
+		Name:   "测试单一鱼类",
+		Source: DrawBoostSourceCheckin,
+		Effects: []*model.ModifierEffect{
+			{Tag: TagSystem, Multiply: float64Ptr(0)},
+			{ItemID: itemID, Add: 1000},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ls.redisClient.Set(ctx, drawBoostKey(testUserID), boost, 0).Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDrawBreaksOnlyHeavierRecords(t *testing.T) {
+	ls, ctx := newTestLotteryService(t)
+	rs := NewRecordService()
+
+	// 已有个人纪录比任何小鱼都轻，全服纪录比任何小鱼都重
+	for key, weight := range map[string]float64{personalRecordsKey(testUserID): 0.001, GlobalRecordsKey: 100} {
+		catch, err := json.Marshal(&model.CatchRecord{ItemID: SmallFishID, UserID: "other", WeightKG: weight})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ls.redisClient.HSet(ctx, key, SmallFishID, catch).Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	seedSingleFishBoost(t, ls, ctx, SmallFishID)
+
+	resp, err := ls.BatchDraw(ctx, &model.LotteryBatchDrawRequest{UserID: testUserID, Count: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	heaviest := resp.Results[0]
+	for i, result := range resp.Results {
+		if result.ItemID != SmallFishID || result.WeightKG <= 0 {
+			t.Fatalf("result %d %+v, expected a sized small fish", i, result)
+		}
+		// 第一条必然打破个人纪录，之后只有更重的才打破
+		broke := i == 0 || result.WeightKG > heaviest.WeightKG
+		if got := len(result.Records) == 1 && result.Records[0] == "personal"; got != broke {
+			t.Errorf("result %d (%.2fkg) broke %v, expected personal=%v", i, result.WeightKG, result.Records, broke)
+		}
+		if result.WeightKG > heaviest.WeightKG {
+			heaviest = result
+		}
+	}
+
+	personal, err := rs.GetPersonalRecords(ctx, testUserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(personal.Records) != 1 || personal.Records[0].DrawID != heaviest.DrawID || personal.Records[0].WeightKG != heaviest.WeightKG {
+		t.Errorf("personal records %+v, expected draw %s", personal.Records, heaviest.DrawID)
+	}
+	global, err := rs.GetGlobalRecords(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(global.Records) != 1 || global.Records[0].WeightKG != 100 {
+		t.Errorf("global record replaced by a lighter catch: %+v", global.Records)
+	}
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"fishing-game/config"
+	"fishing-game/model"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// 版税默认配置
+	DefaultRoyaltyRate            = 0.1 // 按被钓到的用户鱼积分的比例
+	DefaultRoyaltyDailyCap        = 500 // 每名创建者每日版税上限
+	DefaultRoyaltyCatcherDailyCap = 100 // 同一名玩家每日为同一创建者贡献的版税上限
+
+	// royaltyDailyTotalField 当日版税hash中创建者当日总额的字段，其余字段为各玩家的贡献
+	royaltyDailyTotalField = "_total"
+	// royaltyDailyDrawPrefix 当日版税hash中已处理抽奖的字段前缀，完整字段为前缀加抽奖ID
+	royaltyDailyDrawPrefix = "_draw:"
+)
+
+// RoyaltyConfig 版税配置
+type RoyaltyConfig struct {
+	Rate            float64        // 版税为被钓到的鱼积分乘以该比例
+	Flat            int            // 大于0时每次固定给予该积分，替代按比例计算
+	DailyCap        int            // 每名创建者每日上限，0为不限
+	CatcherDailyCap int            // 同一名玩家每日为同一创建者贡献的上限，0为不限
+	Location        *time.Location // 每日额度重置所在时区
+}
+
+// LoadRoyaltyConfig 从环境变量加载版税配置
+func LoadRoyaltyConfig() RoyaltyConfig {
+	return RoyaltyConfig{
+		Rate:            config.GetEnvFloat("ROYALTY_RATE", DefaultRoyaltyRate),
+		Flat:            config.GetEnvInt("ROYALTY_FLAT", 0),
+		DailyCap:        config.GetEnvInt("ROYALTY_DAILY_CAP", DefaultRoyaltyDailyCap),
+		CatcherDailyCap: config.GetEnvInt("ROYALTY_CATCHER_DAILY_CAP", DefaultRoyaltyCatcherDailyCap),
+		Location:        config.GetEnvLocation("GAME_TIMEZONE", DefaultGameTimezone),
+	}
+}
+
+// royaltyFor 一次被钓到的版税，按比例计算时有积分的鱼至少给予1分
+func (rc RoyaltyConfig) royaltyFor(points int) int {
+	if rc.Flat > 0 {
+		return rc.Flat
+	}
+	if points <= 0 || rc.Rate <= 0 {
+		return 0
+	}
+	return int(math.Max(1, math.Round(float64(points)*rc.Rate)))
+}
+
+// royaltyKey 创建者版税累计（HASH：points / catches 为总计，points:{item_id} / catches:{item_id} 为各鱼类）
+func royaltyKey(userID string) string {
+	return fmt.Sprintf("royalty:%s", userID)
+}
+
+// royaltyDailyKey 创建者当日版税（HASH：_total 为当日总额，_draw:{draw_id} 为已处理的抽奖，其余字段为各玩家当日贡献）
+func royaltyDailyKey(userID, day string) string {
+	return fmt.Sprintf("royalty:daily:%s:%s", userID, day)
+}
+
+// royaltyScript 按每日上限截断后给予创建者版税积分，并累计收益、写入账本。
+// HSETNX按抽奖ID去重，同一次抽奖重复处理时不会再次发放
+//
+// KEYS[1] 创建者当日版税 KEYS[2] 创建者版税累计 KEYS[3] 全局榜单 KEYS[4] 创建者钱包 KEYS[5] 创建者账本
+// ARGV[1] 钓到鱼的玩家 ARGV[2] 版税积分 ARGV[3] 创建者每日上限 ARGV[4] 单个玩家每日贡献上限
+// ARGV[5] 当日版税过期时刻（毫秒时间戳） ARGV[6] 鱼类ID ARGV[7] 榜单成员 ARGV[8] 账本记录JSON ARGV[9] 抽奖ID
+//
+// 返回 {实际给予的积分, 创建者新的榜单积分}，达到上限或已处理过该抽奖时为 {0, 0}
+var royaltyScript = redis.NewScript(`
+if redis.call('HSETNX', KEYS[1], '_draw:' .. ARGV[9], ARGV[2]) == 0 then
+	return {0, 0}
+end
+redis.call('PEXPIREAT', KEYS[1], ARGV[5])
+
+local amount = tonumber(ARGV[2])
+local cap = tonumber(ARGV[3])
+if cap > 0 then
+	local remaining = cap - tonumber(redis.call('HGET', KEYS[1], '_total') or '0')
+	if remaining < amount then
+		amount = remaining
+	end
+end
+local catcherCap = tonumber(ARGV[4])
+if catcherCap > 0 then
+	local remaining = catcherCap - tonumber(redis.call('HGET', KEYS[1], ARGV[1]) or '0')
+	if remaining < amount then
+		amount = remaining
+	end
+end
+if amount <= 0 then
+	return {0, 0}
+end
+
+redis.call('HINCRBY', KEYS[1], '_total', amount)
+redis.call('HINCRBY', KEYS[1], ARGV[1], amount)
+redis.call('HINCRBY', KEYS[2], 'points', amount)
+redis.call('HINCRBY', KEYS[2], 'catches', 1)
+redis.call('HINCRBY', KEYS[2], 'points:' .. ARGV[6], amount)
+redis.call('HINCRBY', KEYS[2], 'catches:' .. ARGV[6], 1)
+local score = redis.call('ZINCRBY', KEYS[3], amount, ARGV[7])
+
+local entry = cjson.decode(ARGV[8])
+entry['points'] = amount
+entry['balance'] = tonumber(redis.call('HGET', KEYS[4], 'coins') or '0')
+redis.call('RPUSH', KEYS[5], cjson.encode(entry))
+return {amount, tonumber(score)}
+`)
+
+// RoyaltyService 用户鱼版税服务：用户鱼被他人钓到时给予创建者（wx_id）榜单积分
+type RoyaltyService struct {
+	redisClient    *redis.Client
+	poolService    *PoolService
+	rankingService *RankingService
+	config         RoyaltyConfig
+}
+
+// NewRoyaltyService 创建版税服务
+func NewRoyaltyService(poolService *PoolService, rankingService *RankingService) *RoyaltyService {
+	return &RoyaltyService{
+		redisClient:    config.GetRedisClient(),
+		poolService:    poolService,
+		rankingService: rankingService,
+		config:         LoadRoyaltyConfig(),
+	}
+}
+
+// OnDraw 为本次抽中的每条他人创建的用户鱼给予版税，钓到自己创建的鱼不产生版税
+func (rs *RoyaltyService) OnDraw(ctx context.Context, event *DrawEvent) error {
+	day := event.CreatedAt.In(rs.config.Location).Format("20060102")
+	expireAt := rs.dailyExpireAt(event.CreatedAt)
+	for _, record := range event.Records {
+		item := event.Items[record.ItemID]
+		if item == nil || !item.IsUserFish || item.WxID == "" || item.WxID == event.UserID {
+			continue
+		}
+		amount := rs.config.royaltyFor(record.Points)
+		if amount <= 0 {
+			continue
+		}
+		if err := rs.grant(ctx, record, item.WxID, day, expireAt, amount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dailyExpireAt 当日版税的过期时刻：抽奖所在日的次日结束，跨零点处理的抽奖事件仍能按抽奖ID去重
+func (rs *RoyaltyService) dailyExpireAt(at time.Time) time.Time {
+	local := at.In(rs.config.Location)
+	return time.Date(local.Year(), local.Month(), local.Day()+2, 0, 0, 0, 0, rs.config.Location)
+}
+
+// grant 给予一次版税，账本记录关联抽奖ID
+func (rs *RoyaltyService) grant(ctx context.Context, record *model.LotteryRecord, creator, day string, expireAt time.Time, amount int) error {
+	entry := newLedgerEntry(creator, model.LedgerTypeRoyalty, "", record.Timestamp)
+	entry.ItemID = record.ItemID
+	entry.Ref = record.DrawID
+
+	entryJSON, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal ledger entry: %w", err)
+	}
+
+	result, err := royaltyScript.Run(ctx, rs.redisClient,
+		[]string{royaltyDailyKey(creator, day), royaltyKey(creator), GlobalRankingKey, walletKey(creator), ledgerKey(creator)},
+		record.UserID, amount, rs.config.DailyCap, rs.config.CatcherDailyCap, expireAt.UnixMilli(),
+		record.ItemID, rankingMember(creator), entryJSON, record.DrawID,
+	).Int64Slice()
+	if err != nil {
+		return fmt.Errorf("failed to grant royalty: %w", err)
+	}
+	if len(result) != 2 {
+		return fmt.Errorf("unexpected royalty result")
+	}
+	if result[0] == 0 {
+		// 已达到每日上限或已处理过该抽奖
+		return nil
+	}
+
+	rs.rankingService.notifyScoreListeners(ctx, creator, int(result[1]))
+	return nil
+}
+
+// GetEarnings 获取创建者的版税收益
+func (rs *RoyaltyService) GetEarnings(ctx context.Context, userID string) (*model.RoyaltyEarningsResponse, error) {
+	day := time.Now().In(rs.config.Location).Format("20060102")
+
+	pipe := rs.redisClient.Pipeline()
+	totals := pipe.HGetAll(ctx, royaltyKey(userID))
+	today := pipe.HGet(ctx, royaltyDailyKey(userID, day), royaltyDailyTotalField)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get royalty earnings: %w", err)
+	}
+
+	items, err := rs.poolService.GetItems(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &model.RoyaltyEarningsResponse{
+		UserID: userID,
+		Today: &model.RoyaltyDailyUsage{
+			Date: day,
+			Cap:  rs.config.DailyCap,
+		},
+		Items: make([]*model.RoyaltyItemEarnings, 0),
+	}
+	response.Today.Points, _ = strconv.Atoi(today.Val())
+	if rs.config.DailyCap > 0 && response.Today.Points < rs.config.DailyCap {
+		response.Today.Remaining = rs.config.DailyCap - response.Today.Points
+	}
+
+	byItem := make(map[string]*model.RoyaltyItemEarnings)
+	itemEarnings := func(itemID string) *model.RoyaltyItemEarnings {
+		earnings, exists := byItem[itemID]
+		if !exists {
+			earnings = &model.RoyaltyItemEarnings{ItemID: itemID}
+			if item, ok := items[itemID]; ok {
+				earnings.ItemName = item.Name
+			}
+			byItem[itemID] = earnings
+			response.Items = append(response.Items, earnings)
+		}
+		return earnings
+	}
+	for field, value := range totals.Val() {
+		n, _ := strconv.ParseInt(value, 10, 64)
+		switch {
+		case field == "points":
+			response.TotalPoints = n
+		case field == "catches":
+			response.TotalCatches = n
+		case strings.HasPrefix(field, "points:"):
+			itemEarnings(strings.TrimPrefix(field, "points:")).Points = n
+		case strings.HasPrefix(field, "catches:"):
+			itemEarnings(strings.TrimPrefix(field, "catches:")).Catches = n
+		}
+	}
+
+	sort.Slice(response.Items, func(i, j int) bool {
+		if response.Items[i].Points != response.Items[j].Points {
+			return response.Items[i].Points > response.Items[j].Points
+		}
+		return response.Items[i].ItemID < response.Items[j].ItemID
+	})
+
+	return response, nil
+}
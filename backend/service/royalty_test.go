@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"fishing-game/model"
+)
+
+// newTestRoyaltyService 基于miniredis创建版税服务，每日上限按参数设置
+func newTestRoyaltyService(t *testing.T, dailyCap, catcherDailyCap int) (*RoyaltyService, context.Context) {
+	t.Helper()
+	ls, ctx := newTestLotteryService(t)
+	rs := NewRoyaltyService(ls.poolService, ls.rankingService)
+	rs.config.Rate = 0.1
+	rs.config.Flat = 0
+	rs.config.DailyCap = dailyCap
+	rs.config.CatcherDailyCap = catcherDailyCap
+	return rs, ctx
+}
+
+// userFishEvent 玩家钓到creator创建的用户鱼的抽奖事件
+func userFishEvent(catcher, creator, drawID string, points int, now time.Time) *DrawEvent {
+	item := &model.LotteryItem{ID: "user-fish", Name: "用户鱼", IsUserFish: true, WxID: creator}
+	return &DrawEvent{
+		UserID: catcher,
+		Records: []*model.LotteryRecord{{
+			DrawID:    drawID,
+			UserID:    catcher,
+			ItemID:    item.ID,
+			WxID:      creator,
+			Points:    points,
+			Timestamp: now,
+		}},
+		Items:     map[string]*model.LotteryItem{item.ID: item},
+		CreatedAt: now,
+	}
+}
+
+func TestRoyaltyIsPaidOncePerDraw(t *testing.T) {
+	rs, ctx := newTestRoyaltyService(t, 0, 0)
+	now := time.Now()
+
+	event := userFishEvent("catcher", "creator", "draw-1", 100, now)
+	for i := 0; i < 2; i++ {
+		if err := rs.OnDraw(ctx, event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	earnings, err := rs.GetEarnings(ctx, "creator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if earnings.TotalPoints != 10 || earnings.TotalCatches != 1 {
+		t.Errorf("earnings %d points over %d catches, expected 10 over 1", earnings.TotalPoints, earnings.TotalCatches)
+	}
+	if n, _ := rs.redisClient.LLen(ctx, ledgerKey("creator")).Result(); n != 1 {
+		t.Errorf("creator has %d ledger entries, expected 1", n)
+	}
+	if score, _ := rs.redisClient.ZScore(ctx, GlobalRankingKey, rankingMember("creator")).Result(); score != 10 {
+		t.Errorf("creator score %v, expected 10", score)
+	}
+
+	// 当日版税保留到次日结束
+	day := now.In(rs.config.Location).Format("20060102")
+	ttl, err := rs.redisClient.PTTL(ctx, royaltyDailyKey("creator", day)).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Until(rs.dailyExpireAt(now)); ttl <= 24*time.Hour || ttl > want+time.Second {
+		t.Errorf("daily royalty ttl %s, expected %s", ttl, want)
+	}
+}
+
+func TestRoyaltyRespectsDailyCaps(t *testing.T) {
+	rs, ctx := newTestRoyaltyService(t, 15, 8)
+	now := time.Now()
+
+	// 玩家贡献上限截断为8，之后该玩家不再贡献；另一名玩家只能贡献创建者当日剩余的7
+	for _, event := range []*DrawEvent{
+		userFishEvent("a", "creator", "draw-1", 100, now),
+		userFishEvent("a", "creator", "draw-2", 100, now),
+		userFishEvent("b", "creator", "draw-3", 100, now),
+		userFishEvent("c", "creator", "draw-4", 100, now),
+		userFishEvent("creator", "creator", "draw-5", 100, now),
+	} {
+		if err := rs.OnDraw(ctx, event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	day := now.In(rs.config.Location).Format("20060102")
+	daily, err := rs.redisClient.HGetAll(ctx, royaltyDailyKey("creator", day)).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for field, want := range map[string]string{royaltyDailyTotalField: "15", "a": "8", "b": "7"} {
+		if daily[field] != want {
+			t.Errorf("daily %s %q, expected %q", field, daily[field], want)
+		}
+	}
+	if _, exists := daily["c"]; exists {
+		t.Errorf("capped catcher recorded: %v", daily)
+	}
+
+	earnings, err := rs.GetEarnings(ctx, "creator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if earnings.TotalPoints != 15 || earnings.Today.Points != 15 || earnings.Today.Remaining != 0 {
+		t.Errorf("earnings total %d, today %+v", earnings.TotalPoints, earnings.Today)
+	}
+	if n, _ := rs.redisClient.LLen(ctx, ledgerKey("creator")).Result(); n != 2 {
+		t.Errorf("creator has %d ledger entries, expected 2", n)
+	}
+}
@@ -0,0 +1,301 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"fishing-game/model"
+)
+
+// SimulationConfig 离线模拟配置
+type SimulationConfig struct {
+	Items          map[string]*model.LotteryItem
+	Weights        map[string]int
+	Strategy       string // 为空时使用默认策略
+	Players        int
+	DrawsPerPlayer int
+	BatchSize      int  // 每次请求的抽数，1为单抽
+	Guarantee      bool // 连抽保底
+	Context        map[string]interface{}
+	Pity           PityConfig
+	Modifiers      *ModifierPipeline // 为空时不做Context修正
+	RareTag        string            // 视为稀有的鱼类标签，默认 rare
+	Workers        int
+}
+
+// simulatedPlayer 单个模拟玩家的结果
+type simulatedPlayer struct {
+	points    int
+	firstRare int // 首次钓到稀有鱼是第几抽，0表示未钓到
+}
+
+// RunSimulation 使用线上选鱼逻辑（策略、别名表、Context修正、保底、连抽保底）离线模拟
+// Players 名玩家各抽 DrawsPerPlayer 次，不访问Redis
+func RunSimulation(cfg *SimulationConfig) (*model.SimulationReport, error) {
+	if cfg.Players <= 0 || cfg.DrawsPerPlayer <= 0 {
+		return nil, errors.New("players and draws per player must be positive")
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 1
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.RareTag == "" {
+		cfg.RareTag = TagRare
+	}
+	if cfg.Strategy == "" {
+		cfg.Strategy = StrategyDefault
+	}
+
+	totalWeight := 0
+	for _, weight := range cfg.Weights {
+		totalWeight += weight
+	}
+	if totalWeight != TotalWeight {
+		return nil, fmt.Errorf("invalid total weight: %d, expected %d", totalWeight, TotalWeight)
+	}
+
+	strategy, err := DrawStrategies.Get(cfg.Strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	alias, err := NewAliasTable(cfg.Weights)
+	if err != nil {
+		return nil, err
+	}
+
+	baseWeights, modifiers := cfg.Weights, []model.AppliedModifier(nil)
+	if cfg.Modifiers != nil {
+		baseWeights, modifiers = cfg.Modifiers.Apply(cfg.Items, cfg.Weights, cfg.Context)
+	}
+
+	selector := &drawSelector{
+		items:      cfg.Items,
+		alias:      alias,
+		strategy:   strategy,
+		pityConfig: cfg.Pity,
+	}
+
+	players := make([]simulatedPlayer, cfg.Players)
+	counts := make([]map[string]int64, cfg.Workers)
+	errs := make([]error, cfg.Workers)
+
+	var wg sync.WaitGroup
+	for w := 0; w < cfg.Workers; w++ {
+		counts[w] = make(map[string]int64)
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for p := w; p < cfg.Players; p += cfg.Workers {
+				player, err := simulatePlayer(cfg, selector, baseWeights, len(modifiers) == 0, fmt.Sprintf("sim_%d", p), counts[w])
+				if err != nil {
+					errs[w] = err
+					return
+				}
+				players[p] = *player
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	itemCounts := make(map[string]int64)
+	for _, workerCounts := range counts {
+		for itemID, count := range workerCounts {
+			itemCounts[itemID] += count
+		}
+	}
+
+	return buildSimulationReport(cfg, baseWeights, itemCounts, players), nil
+}
+
+// simulatePlayer 模拟单个玩家的全部抽奖，按提交脚本的规则推进保底计数与抽奖概况
+func simulatePlayer(cfg *SimulationConfig, selector *drawSelector, baseWeights map[string]int, unmodified bool, userID string, counts map[string]int64) (*simulatedPlayer, error) {
+	player := &simulatedPlayer{}
+	state := &drawState{profile: &drawProfile{}}
+
+	for drawn := 0; drawn < cfg.DrawsPerPlayer; {
+		count := cfg.BatchSize
+		if remaining := cfg.DrawsPerPlayer - drawn; count > remaining {
+			count = remaining
+		}
+
+		// 选鱼过程会推进传入的状态，连抽保底可能替换结果，因此以最终结果重新推进
+		scratch := &drawState{streak: state.streak, profile: &drawProfile{
+			LastItemID: state.profile.LastItemID,
+			TotalDraws: state.profile.TotalDraws,
+		}}
+		selections, err := selector.selectItems(userID, baseWeights, unmodified, scratch, count, cfg.Guarantee)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, selection := range selections {
+			drawn++
+			item := selection.item
+			state.advance(item)
+			counts[item.ID]++
+			player.points += selection.points()
+			if player.firstRare == 0 && ItemHasTag(item, cfg.RareTag) {
+				player.firstRare = drawn
+			}
+		}
+	}
+
+	return player, nil
+}
+
+// buildSimulationReport 汇总模拟结果
+func buildSimulationReport(cfg *SimulationConfig, baseWeights map[string]int, itemCounts map[string]int64, players []simulatedPlayer) *model.SimulationReport {
+	totalDraws := int64(cfg.Players) * int64(cfg.DrawsPerPlayer)
+	report := &model.SimulationReport{
+		Players:        cfg.Players,
+		DrawsPerPlayer: cfg.DrawsPerPlayer,
+		TotalDraws:     totalDraws,
+		Strategy:       cfg.Strategy,
+		BatchSize:      cfg.BatchSize,
+		Guarantee:      cfg.Guarantee,
+	}
+
+	effectiveTotal := 0
+	for _, weight := range baseWeights {
+		if weight > 0 {
+			effectiveTotal += weight
+		}
+	}
+
+	itemIDs := make([]string, 0, len(cfg.Items))
+	for itemID := range cfg.Items {
+		itemIDs = append(itemIDs, itemID)
+	}
+	sort.Strings(itemIDs)
+
+	totalPoints := 0.0
+	for _, itemID := range itemIDs {
+		item := cfg.Items[itemID]
+		stats := &model.SimulatedItemStats{
+			ItemID:   itemID,
+			ItemName: item.Name,
+			Points:   item.Points,
+			Count:    itemCounts[itemID],
+		}
+		if weight := baseWeights[itemID]; weight > 0 && effectiveTotal > 0 {
+			stats.ConfiguredProbability = float64(weight) / float64(effectiveTotal)
+		}
+		stats.ObservedFrequency = float64(stats.Count) / float64(totalDraws)
+		report.ExpectedValuePerDraw += stats.ConfiguredProbability * float64(item.Points)
+		totalPoints += float64(stats.Count) * float64(item.Points)
+		report.Items = append(report.Items, stats)
+	}
+	report.ObservedValuePerDraw = totalPoints / float64(totalDraws)
+
+	points := make([]float64, 0, len(players))
+	firstRare := make([]float64, 0, len(players))
+	for _, player := range players {
+		points = append(points, float64(player.points))
+		if player.firstRare > 0 {
+			firstRare = append(firstRare, float64(player.firstRare))
+		}
+	}
+
+	report.Points = summarize(points)
+	report.FirstRare = &model.FirstRareSummary{
+		Tag:          cfg.RareTag,
+		Reached:      len(firstRare),
+		NeverReached: len(players) - len(firstRare),
+	}
+	if len(firstRare) > 0 {
+		report.FirstRare.Draws = summarize(firstRare)
+	}
+	report.Leaderboard = projectLeaderboard(points)
+
+	return report
+}
+
+// summarize 计算分布摘要，会对传入的切片排序
+func summarize(values []float64) *model.DistributionSummary {
+	sort.Float64s(values)
+
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= float64(len(values))
+
+	return &model.DistributionSummary{
+		Min:    values[0],
+		P10:    percentile(values, 10),
+		P25:    percentile(values, 25),
+		P50:    percentile(values, 50),
+		P75:    percentile(values, 75),
+		P90:    percentile(values, 90),
+		P99:    percentile(values, 99),
+		Max:    values[len(values)-1],
+		Mean:   mean,
+		StdDev: math.Sqrt(variance),
+	}
+}
+
+// percentile 已排序数据的百分位数（最近秩法）
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
+
+// projectLeaderboard 按玩家最终积分预测榜单分布，传入的积分需已升序排列
+func projectLeaderboard(sorted []float64) *model.LeaderboardProjection {
+	n := len(sorted)
+	projection := &model.LeaderboardProjection{
+		Top1:             sorted[n-1],
+		Median:           percentile(sorted, 50),
+		Bottom:           sorted[0],
+		InterDecileRange: percentile(sorted, 90) - percentile(sorted, 10),
+	}
+
+	top10 := 10
+	if top10 > n {
+		top10 = n
+	}
+	projection.Top10Cutoff = sorted[n-top10]
+
+	total, topSum, weightedSum := 0.0, 0.0, 0.0
+	for i, v := range sorted {
+		total += v
+		if i >= n-top10 {
+			topSum += v
+		}
+		weightedSum += float64(i+1) * v
+	}
+	if total > 0 {
+		projection.Top10Share = topSum / total
+		// 基尼系数：G = 2Σ(i·x_i) / (nΣx) - (n+1)/n
+		projection.GiniCoefficient = 2*weightedSum/(float64(n)*total) - float64(n+1)/float64(n)
+	}
+	if projection.Median > 0 {
+		projection.Top1MedianRatio = projection.Top1 / projection.Median
+	}
+
+	return projection
+}
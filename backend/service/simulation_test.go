@@ -0,0 +1,86 @@
+package service
+
+import (
+	"testing"
+
+	"fishing-game/model"
+)
+
+// simulationItems 模拟使用的空军、小鱼和稀有鱼
+func simulationItems() map[string]*model.LotteryItem {
+	return map[string]*model.LotteryItem{
+		EmptyFishID: {ID: EmptyFishID, Name: "空军"},
+		SmallFishID: {ID: SmallFishID, Name: "小鱼", Points: 10},
+		RareFishID:  {ID: RareFishID, Name: "稀有鱼", Points: 100},
+	}
+}
+
+func TestRunSimulationWithSingleItem(t *testing.T) {
+	report, err := RunSimulation(&SimulationConfig{
+		Items:          simulationItems(),
+		Weights:        map[string]int{EmptyFishID: 0, SmallFishID: TotalWeight, RareFishID: 0},
+		Players:        7,
+		DrawsPerPlayer: 13,
+		BatchSize:      5,
+		Workers:        3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.TotalDraws != 7*13 || report.ExpectedValuePerDraw != 10 || report.ObservedValuePerDraw != 10 {
+		t.Errorf("report draws %d, expected value %v, observed value %v", report.TotalDraws, report.ExpectedValuePerDraw, report.ObservedValuePerDraw)
+	}
+	for _, stats := range report.Items {
+		want := int64(0)
+		if stats.ItemID == SmallFishID {
+			want = 7 * 13
+		}
+		if stats.Count != want {
+			t.Errorf("%s count %d, expected %d", stats.ItemID, stats.Count, want)
+		}
+	}
+	if report.Points.Min != 130 || report.Points.Max != 130 {
+		t.Errorf("points distribution %+v, expected every player at 130", report.Points)
+	}
+	if report.FirstRare.Reached != 0 || report.FirstRare.NeverReached != 7 {
+		t.Errorf("first rare %+v, expected never reached", report.FirstRare)
+	}
+}
+
+func TestRunSimulationAppliesBatchGuarantee(t *testing.T) {
+	report, err := RunSimulation(&SimulationConfig{
+		Items:          simulationItems(),
+		Weights:        map[string]int{EmptyFishID: TotalWeight - 1, SmallFishID: 1, RareFishID: 0},
+		Players:        20,
+		DrawsPerPlayer: 30,
+		BatchSize:      10,
+		Guarantee:      true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 每批至少一次非空军
+	for _, stats := range report.Items {
+		if stats.ItemID == SmallFishID && stats.Count < 20*3 {
+			t.Errorf("small fish count %d, expected at least one per batch (%d)", stats.Count, 20*3)
+		}
+	}
+	if report.Points.Min < 30 {
+		t.Errorf("player points min %v, expected at least 30", report.Points.Min)
+	}
+}
+
+func TestRunSimulationRejectsInvalidConfig(t *testing.T) {
+	for name, cfg := range map[string]*SimulationConfig{
+		"no players":     {Items: simulationItems(), Weights: map[string]int{SmallFishID: TotalWeight}, DrawsPerPlayer: 1},
+		"no draws":       {Items: simulationItems(), Weights: map[string]int{SmallFishID: TotalWeight}, Players: 1},
+		"partial weight": {Items: simulationItems(), Weights: map[string]int{SmallFishID: TotalWeight - 1}, Players: 1, DrawsPerPlayer: 1},
+		"unknown":        {Items: simulationItems(), Weights: map[string]int{SmallFishID: TotalWeight}, Players: 1, DrawsPerPlayer: 1, Strategy: "unknown"},
+	} {
+		if _, err := RunSimulation(cfg); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"fishing-game/config"
+	"fishing-game/model"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// SpotConfigFile 钓点配置文件
+	SpotConfigFile = "spots.json"
+
+	// DefaultSpotID 未配置钓点时的内置钓点，旧版单奖池数据迁移到该钓点
+	DefaultSpotID = "lake"
+)
+
+// ErrUnknownSpot 钓点不存在
+var ErrUnknownSpot = errors.New("unknown spot")
+
+// poolItemsKey 钓点奖池鱼类（HASH，鱼类ID -> LotteryItem JSON）
+func poolItemsKey(spot string) string {
+	return fmt.Sprintf("lottery:pool:%s:items", spot)
+}
+
+// poolWeightsKey 钓点奖池权重（HASH，鱼类ID -> 权重）
+func poolWeightsKey(spot string) string {
+	return fmt.Sprintf("lottery:pool:%s:weights", spot)
+}
+
+// poolStrategyKey 钓点奖池默认抽奖策略（STRING）
+func poolStrategyKey(spot string) string {
+	return fmt.Sprintf("lottery:pool:%s:strategy", spot)
+}
+
+// SpotPoolKeys 钓点奖池的鱼类、权重、策略key（供离线工具解析Redis导出）
+func SpotPoolKeys(spot string) (string, string, string) {
+	return poolItemsKey(spot), poolWeightsKey(spot), poolStrategyKey(spot)
+}
+
+// migrateLegacyPoolScript 将旧版单奖池的键重命名为默认钓点的键，仅当默认钓点尚无数据时执行
+//
+// KEYS[1..3] 旧版鱼类/权重/策略 KEYS[4..6] 默认钓点鱼类/权重/策略 KEYS[7] 奖池版本号
+//
+// 返回是否迁移
+var migrateLegacyPoolScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 or redis.call('EXISTS', KEYS[4]) == 1 then
+	return 0
+end
+for i = 1, 3 do
+	if redis.call('EXISTS', KEYS[i]) == 1 then
+		redis.call('RENAME', KEYS[i], KEYS[i + 3])
+	end
+end
+redis.call('INCR', KEYS[7])
+return 1
+`)
+
+// loadSpotDefinitions 读取并校验钓点配置，配置文件不存在时只有内置的默认钓点
+func loadSpotDefinitions() (string, []*model.SpotDefinition, error) {
+	var cfg model.SpotConfig
+	found, err := config.LoadJSON(SpotConfigFile, &cfg)
+	if err != nil {
+		return "", nil, err
+	}
+	if !found || len(cfg.Spots) == 0 {
+		log.Printf("%s not found, using built-in spot %s", SpotConfigFile, DefaultSpotID)
+		return DefaultSpotID, []*model.SpotDefinition{DefaultSpotDefinition()}, nil
+	}
+
+	builtin := make(map[string]*model.LotteryItem)
+	for _, item := range DefaultPoolItems() {
+		builtin[item.ID] = item
+	}
+
+	seen := make(map[string]bool, len(cfg.Spots))
+	for _, def := range cfg.Spots {
+		if def.ID == "" {
+			return "", nil, fmt.Errorf("spot requires id")
+		}
+		if seen[def.ID] {
+			return "", nil, fmt.Errorf("duplicate spot id %s", def.ID)
+		}
+		seen[def.ID] = true
+
+		if def.Strategy != "" {
+			if _, err := DrawStrategies.Get(def.Strategy); err != nil {
+				return "", nil, fmt.Errorf("spot %s: %w", def.ID, err)
+			}
+		}
+
+		items := make(map[string]bool, len(def.Items))
+		for i, item := range def.Items {
+			if item.ID == "" {
+				return "", nil, fmt.Errorf("spot %s: item requires id", def.ID)
+			}
+			// 只填id的系统鱼类使用内置定义
+			if item.Name == "" {
+				fallback, ok := builtin[item.ID]
+				if !ok {
+					return "", nil, fmt.Errorf("spot %s: item %s requires name", def.ID, item.ID)
+				}
+				def.Items[i] = fallback
+			}
+			items[item.ID] = true
+		}
+
+		total := 0
+		for itemID, weight := range def.Weights {
+			if !items[itemID] {
+				return "", nil, fmt.Errorf("spot %s: weight for unknown item %s", def.ID, itemID)
+			}
+			if weight < 0 {
+				return "", nil, fmt.Errorf("spot %s: weight for %s must not be negative", def.ID, itemID)
+			}
+			total += weight
+		}
+		if total != TotalWeight {
+			return "", nil, fmt.Errorf("spot %s: weights sum to %d, want %d", def.ID, total, TotalWeight)
+		}
+
+		for _, rule := range def.BorrowOrder {
+			if !items[rule.ItemID] {
+				return "", nil, fmt.Errorf("spot %s: borrow order references unknown item %s", def.ID, rule.ItemID)
+			}
+		}
+	}
+
+	defaultSpot := cfg.DefaultSpot
+	if defaultSpot == "" {
+		defaultSpot = cfg.Spots[0].ID
+	}
+	if !seen[defaultSpot] {
+		return "", nil, fmt.Errorf("default spot %s is not defined", defaultSpot)
+	}
+
+	return defaultSpot, cfg.Spots, nil
+}
+
+// LoadSpotDefinition 从钓点配置读取单个钓点定义，spot为空时为默认钓点
+func LoadSpotDefinition(spot string) (*model.SpotDefinition, error) {
+	defaultSpot, definitions, err := loadSpotDefinitions()
+	if err != nil {
+		return nil, err
+	}
+	if spot == "" {
+		spot = defaultSpot
+	}
+	for _, def := range definitions {
+		if def.ID == spot {
+			return def, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownSpot, spot)
+}
+
+// DefaultSpotDefinition 内置的默认钓点，使用内置系统鱼类、初始权重及借用顺序
+func DefaultSpotDefinition() *model.SpotDefinition {
+	order := make([]model.SpotBorrowRule, 0, len(BorrowOrder))
+	for _, info := range BorrowOrder {
+		order = append(order, model.SpotBorrowRule{ItemID: info.FishID, MinWeight: info.MinWeight})
+	}
+
+	return &model.SpotDefinition{
+		ID:          DefaultSpotID,
+		Name:        "湖泊",
+		Items:       DefaultPoolItems(),
+		Weights:     DefaultPoolWeights(),
+		BorrowOrder: order,
+	}
+}
+
+// resolveSpot 返回钓点ID，为空时使用默认钓点
+func (ps *PoolService) resolveSpot(spot string) (string, error) {
+	if spot == "" {
+		return ps.defaultSpot, nil
+	}
+	if _, ok := ps.spots[spot]; !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownSpot, spot)
+	}
+	return spot, nil
+}
+
+// SpotBorrowOrder 钓点的权重借用顺序
+func SpotBorrowOrder(def *model.SpotDefinition) []BorrowInfo {
+	order := make([]BorrowInfo, 0, len(def.BorrowOrder))
+	for _, rule := range def.BorrowOrder {
+		order = append(order, BorrowInfo{FishID: rule.ItemID, MinWeight: rule.MinWeight})
+	}
+	return order
+}
+
+// DefaultSpot 默认钓点ID
+func (ps *PoolService) DefaultSpot() string {
+	return ps.defaultSpot
+}
+
+// ListSpots 获取所有钓点及其当前奖池概况
+func (ps *PoolService) ListSpots(ctx context.Context) (*model.SpotListResponse, error) {
+	spots := make([]*model.SpotInfo, 0, len(ps.spotOrder))
+	for _, def := range ps.spotOrder {
+		snapshot, err := ps.GetSnapshot(ctx, def.ID)
+		if err != nil {
+			return nil, err
+		}
+		spots = append(spots, &model.SpotInfo{
+			ID:          def.ID,
+			Name:        def.Name,
+			Description: def.Description,
+			TotalItems:  snapshot.Pool.TotalItems,
+			Strategy:    snapshot.Pool.Strategy,
+			Default:     def.ID == ps.defaultSpot,
+		})
+	}
+
+	return &model.SpotListResponse{
+		DefaultSpot: ps.defaultSpot,
+		Spots:       spots,
+	}, nil
+}
+
+// GetItems 获取所有钓点奖池中的鱼类（同一鱼类出现在多个钓点时取配置顺序靠前的钓点）。
+// 返回的鱼类来自快照，只读
+func (ps *PoolService) GetItems(ctx context.Context) (map[string]*model.LotteryItem, error) {
+	items := make(map[string]*model.LotteryItem)
+	for _, def := range ps.spotOrder {
+		snapshot, err := ps.GetSnapshot(ctx, def.ID)
+		if err != nil {
+			return nil, err
+		}
+		for itemID, item := range snapshot.Pool.Items {
+			if _, exists := items[itemID]; !exists {
+				items[itemID] = item
+			}
+		}
+	}
+	return items, nil
+}
+
+// migrateLegacyPool 将旧版单奖池数据迁移到默认钓点
+func (ps *PoolService) migrateLegacyPool(ctx context.Context) error {
+	migrated, err := migrateLegacyPoolScript.Run(ctx, ps.redisClient, []string{
+		PoolItemsKey, PoolWeightsKey, PoolStrategyKey,
+		poolItemsKey(ps.defaultSpot), poolWeightsKey(ps.defaultSpot), poolStrategyKey(ps.defaultSpot),
+		PoolVersionKey,
+	}).Int()
+	if err != nil {
+		return fmt.Errorf("failed to migrate legacy pool: %w", err)
+	}
+	if migrated == 1 {
+		log.Printf("migrated legacy pool to spot %s", ps.defaultSpot)
+	}
+	return nil
+}
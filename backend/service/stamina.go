@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"fishing-game/config"
+	"fishing-game/model"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// 体力默认配置
+	DefaultMaxStamina     = 20
+	DefaultDrawCost       = 1
+	DefaultRegenInterval  = 6 * time.Minute // 每恢复一点体力所需时间
+	DefaultDailyDrawQuota = 200
+	DefaultGameTimezone   = "Asia/Shanghai"
+
+	// 体力不足原因
+	OutOfStaminaReasonStamina = "stamina"
+	OutOfStaminaReasonQuota   = "quota"
+
+	// staminaBonusField 额外抽奖次数字段（签到等奖励获得），足够时优先使用，不消耗体力和每日次数
+	staminaBonusField = "bonus_draws"
+)
+
+var (
+	// ErrOutOfStamina 体力或每日次数不足
+	ErrOutOfStamina = errors.New("out of stamina")
+	// ErrDrawCostExceedsMax 连抽消耗的体力超过体力上限，恢复满体力也无法抽奖
+	ErrDrawCostExceedsMax = errors.New("draw cost exceeds max stamina")
+)
+
+// OutOfStaminaError 体力或每日次数不足，携带下次可抽奖的时间
+type OutOfStaminaError struct {
+	Detail *model.OutOfStaminaResponse
+}
+
+func (e *OutOfStaminaError) Error() string {
+	return fmt.Sprintf("out of %s, next refill at %s", e.Detail.Reason, e.Detail.NextRefillAt.Format(time.RFC3339))
+}
+
+// Is 支持 errors.Is(err, ErrOutOfStamina)
+func (e *OutOfStaminaError) Is(target error) bool {
+	return target == ErrOutOfStamina
+}
+
+// StaminaConfig 体力配置
+type StaminaConfig struct {
+	MaxStamina    int
+	DrawCost      int
+	RegenInterval time.Duration
+	DailyQuota    int
+	Location      *time.Location // 每日次数重置所在时区
+}
+
+// LoadStaminaConfig 从环境变量加载并校验体力配置
+func LoadStaminaConfig() (StaminaConfig, error) {
+	regenInterval, err := config.ParseEnvDuration("STAMINA_REGEN_INTERVAL", DefaultRegenInterval)
+	if err != nil {
+		return StaminaConfig{}, err
+	}
+
+	cfg := StaminaConfig{
+		MaxStamina:    config.GetEnvInt("STAMINA_MAX", DefaultMaxStamina),
+		DrawCost:      config.GetEnvInt("STAMINA_DRAW_COST", DefaultDrawCost),
+		RegenInterval: regenInterval,
+		DailyQuota:    config.GetEnvInt("DAILY_DRAW_QUOTA", DefaultDailyDrawQuota),
+		Location:      config.GetEnvLocation("GAME_TIMEZONE", DefaultGameTimezone),
+	}
+	if err := cfg.Validate(); err != nil {
+		return StaminaConfig{}, err
+	}
+	return cfg, nil
+}
+
+// Validate 校验体力配置：体力上限、单抽消耗、恢复间隔和每日次数必须为正，且单抽消耗不超过体力上限
+func (c StaminaConfig) Validate() error {
+	if c.MaxStamina <= 0 {
+		return fmt.Errorf("STAMINA_MAX must be positive, got %d", c.MaxStamina)
+	}
+	if c.DrawCost <= 0 {
+		return fmt.Errorf("STAMINA_DRAW_COST must be positive, got %d", c.DrawCost)
+	}
+	if c.DrawCost > c.MaxStamina {
+		return fmt.Errorf("STAMINA_DRAW_COST %d exceeds STAMINA_MAX %d", c.DrawCost, c.MaxStamina)
+	}
+	if c.RegenInterval <= 0 {
+		return fmt.Errorf("STAMINA_REGEN_INTERVAL must be positive, got %s", c.RegenInterval)
+	}
+	if c.DailyQuota <= 0 {
+		return fmt.Errorf("DAILY_DRAW_QUOTA must be positive, got %d", c.DailyQuota)
+	}
+	return nil
+}
+
+// staminaScriptFunctions 体力扣减的Lua函数，由抽奖提交脚本调用，与抽奖记录在同一脚本中写入
+//
+// consumeStamina 额外抽奖次数足够时直接扣减，否则按时间恢复体力后扣减体力和每日次数，
+// charge 为 staminaCharge 解码后的table。返回 {状态, 体力, 体力更新时间, 今日已用次数}，
+// 状态为 ok / bonus / stamina / quota，不足时不做任何写入
+const staminaScriptFunctions = `
+local function consumeStamina(key, charge)
+	local bonus = tonumber(redis.call('HGET', key, 'bonus_draws')) or 0
+	if bonus >= charge['draws'] then
+		redis.call('HINCRBY', key, 'bonus_draws', -charge['draws'])
+		return {'bonus', 0, 0, 0}
+	end
+
+	local now = charge['now']
+	local max = charge['max']
+	local interval = charge['interval']
+
+	local state = redis.call('HMGET', key, 'stamina', 'updated_at', 'quota_day', 'quota_used')
+	local stamina = tonumber(state[1]) or max
+	local updated = tonumber(state[2]) or now
+	local used = tonumber(state[4]) or 0
+	if state[3] ~= charge['day'] then
+		used = 0
+	end
+
+	-- 按经过的时间恢复体力
+	if stamina < max then
+		local regen = math.floor((now - updated) / interval)
+		if regen > 0 then
+			stamina = math.min(max, stamina + regen)
+			updated = updated + regen * interval
+		end
+	end
+	if stamina >= max then
+		stamina = max
+		updated = now
+	end
+
+	if used + charge['draws'] > charge['quota'] then
+		return {'quota', stamina, updated, used}
+	end
+	if stamina < charge['cost'] then
+		return {'stamina', stamina, updated, used}
+	end
+
+	stamina = stamina - charge['cost']
+	used = used + charge['draws']
+	redis.call('HSET', key, 'stamina', stamina, 'updated_at', updated, 'quota_day', charge['day'], 'quota_used', used)
+	return {'ok', stamina, updated, used}
+end
+`
+
+// staminaCharge 一次抽奖请求需要扣减的体力，作为抽奖提交脚本的参数
+type staminaCharge struct {
+	Now      int64  `json:"now"`      // 当前时间（毫秒）
+	Max      int    `json:"max"`      // 体力上限
+	Interval int64  `json:"interval"` // 恢复间隔（毫秒）
+	Cost     int    `json:"cost"`     // 扣减体力
+	Day      string `json:"day"`      // 当日日期
+	Quota    int    `json:"quota"`    // 每日次数上限
+	Draws    int    `json:"draws"`    // 扣减次数
+}
+
+// StaminaService 体力服务
+type StaminaService struct {
+	redisClient *redis.Client
+	config      StaminaConfig
+}
+
+// NewStaminaService 创建体力服务，体力配置无效时返回错误
+func NewStaminaService() (*StaminaService, error) {
+	cfg, err := LoadStaminaConfig()
+	if err != nil {
+		return nil, fmt.Errorf("invalid stamina config: %w", err)
+	}
+
+	return &StaminaService{
+		redisClient: config.GetRedisClient(),
+		config:      cfg,
+	}, nil
+}
+
+// staminaKey 用户体力key
+func staminaKey(userID string) string {
+	return fmt.Sprintf("stamina:%s", userID)
+}
+
+// quotaDay 每日次数所属日期
+func (ss *StaminaService) quotaDay(now time.Time) string {
+	return now.In(ss.config.Location).Format("20060102")
+}
+
+// quotaResetAt 每日次数下次重置时间
+func (ss *StaminaService) quotaResetAt(now time.Time) time.Time {
+	local := now.In(ss.config.Location)
+	return time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, ss.config.Location)
+}
+
+// checkDrawCost 校验draws次抽奖消耗的体力不超过体力上限，超过时即使体力恢复满也无法抽奖
+func (ss *StaminaService) checkDrawCost(draws int) error {
+	if cost := ss.config.DrawCost * draws; cost > ss.config.MaxStamina {
+		return fmt.Errorf("%w: %d draws cost %d, max stamina is %d", ErrDrawCostExceedsMax, draws, cost, ss.config.MaxStamina)
+	}
+	return nil
+}
+
+// charge 构造draws次抽奖需要扣减的体力
+func (ss *StaminaService) charge(now time.Time, draws int) *staminaCharge {
+	return &staminaCharge{
+		Now:      now.UnixMilli(),
+		Max:      ss.config.MaxStamina,
+		Interval: ss.config.RegenInterval.Milliseconds(),
+		Cost:     ss.config.DrawCost * draws,
+		Day:      ss.quotaDay(now),
+		Quota:    ss.config.DailyQuota,
+		Draws:    draws,
+	}
+}
+
+// outOfStamina 根据脚本返回的体力状态构造 *OutOfStaminaError，status 为 stamina 或 quota
+func (ss *StaminaService) outOfStamina(userID string, charge *staminaCharge, status string, stamina, updatedAt, used int64) error {
+	now := time.UnixMilli(charge.Now)
+	state := ss.buildState(userID, int(stamina), time.UnixMilli(updatedAt), int(used), now)
+	detail := &model.OutOfStaminaResponse{
+		Reason: status,
+		State:  state,
+	}
+	if status == OutOfStaminaReasonQuota {
+		detail.NextRefillAt = state.QuotaResetAt
+	} else {
+		// 恢复到足够本次消耗的体力所需时间
+		need := int64(charge.Cost) - stamina
+		detail.NextRefillAt = time.UnixMilli(updatedAt).Add(time.Duration(need) * ss.config.RegenInterval)
+	}
+
+	return &OutOfStaminaError{Detail: detail}
+}
+
+// GetState 获取用户当前体力状态（只读，按时间计算恢复后的体力）
+func (ss *StaminaService) GetState(ctx context.Context, userID string) (*model.StaminaStateResponse, error) {
+	now := time.Now()
+
+	fields, err := ss.redisClient.HGetAll(ctx, staminaKey(userID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stamina: %w", err)
+	}
+
+	stamina := ss.config.MaxStamina
+	updatedAt := now
+	used := 0
+	bonus, _ := strconv.Atoi(fields[staminaBonusField])
+	if value, ok := fields["stamina"]; ok {
+		stamina, _ = strconv.Atoi(value)
+	}
+	if value, ok := fields["updated_at"]; ok {
+		if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+			updatedAt = time.UnixMilli(ms)
+		}
+	}
+	if fields["quota_day"] == ss.quotaDay(now) {
+		used, _ = strconv.Atoi(fields["quota_used"])
+	}
+
+	// 按经过的时间恢复体力
+	if stamina < ss.config.MaxStamina && ss.config.RegenInterval > 0 {
+		regen := int(now.Sub(updatedAt) / ss.config.RegenInterval)
+		if regen > 0 {
+			stamina += regen
+			updatedAt = updatedAt.Add(time.Duration(regen) * ss.config.RegenInterval)
+		}
+	}
+	if stamina >= ss.config.MaxStamina {
+		stamina = ss.config.MaxStamina
+		updatedAt = now
+	}
+
+	state := ss.buildState(userID, stamina, updatedAt, used, now)
+	state.BonusDraws = bonus
+	return state, nil
+}
+
+// buildState 构造体力状态
+func (ss *StaminaService) buildState(userID string, stamina int, updatedAt time.Time, used int, now time.Time) *model.StaminaStateResponse {
+	remaining := ss.config.DailyQuota - used
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	state := &model.StaminaStateResponse{
+		UserID:         userID,
+		Stamina:        stamina,
+		MaxStamina:     ss.config.MaxStamina,
+		DrawCost:       ss.config.DrawCost,
+		DailyQuota:     ss.config.DailyQuota,
+		QuotaUsed:      used,
+		QuotaRemaining: remaining,
+		QuotaResetAt:   ss.quotaResetAt(now),
+	}
+
+	if stamina < ss.config.MaxStamina {
+		nextRefillAt := updatedAt.Add(ss.config.RegenInterval)
+		fullAt := updatedAt.Add(time.Duration(ss.config.MaxStamina-stamina) * ss.config.RegenInterval)
+		state.NextRefillAt = &nextRefillAt
+		state.FullAt = &fullAt
+	}
+
+	return state
+}
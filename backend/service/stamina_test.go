@@ -0,0 +1,62 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"fishing-game/model"
+)
+
+func TestLoadStaminaConfigRejectsInvalidValues(t *testing.T) {
+	for name, env := range map[string]map[string]string{
+		"zero max":         {"STAMINA_MAX": "0"},
+		"negative cost":    {"STAMINA_DRAW_COST": "-1"},
+		"cost above max":   {"STAMINA_MAX": "5", "STAMINA_DRAW_COST": "6"},
+		"zero regen":       {"STAMINA_REGEN_INTERVAL": "0s"},
+		"negative regen":   {"STAMINA_REGEN_INTERVAL": "-1m"},
+		"zero daily quota": {"DAILY_DRAW_QUOTA": "0"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			for key, value := range env {
+				t.Setenv(key, value)
+			}
+			if _, err := LoadStaminaConfig(); err == nil {
+				t.Errorf("expected %v to be rejected", env)
+			}
+		})
+	}
+
+	if _, err := LoadStaminaConfig(); err != nil {
+		t.Errorf("default config rejected: %v", err)
+	}
+}
+
+func TestBatchDrawRejectsCostAboveMaxStamina(t *testing.T) {
+	ls, ctx := newTestLotteryService(t)
+	ls.staminaService.config.MaxStamina = 5
+	ls.staminaService.config.DrawCost = 1
+
+	_, err := ls.BatchDraw(ctx, &model.LotteryBatchDrawRequest{UserID: testUserID, Count: 6})
+	if !errors.Is(err, ErrDrawCostExceedsMax) {
+		t.Fatalf("expected ErrDrawCostExceedsMax, got %v", err)
+	}
+	if exists, _ := ls.redisClient.Exists(ctx, staminaKey(testUserID), drawHistoryKey(testUserID)).Result(); exists != 0 {
+		t.Error("rejected batch wrote stamina or history")
+	}
+
+	resp, err := ls.BatchDraw(ctx, &model.LotteryBatchDrawRequest{UserID: testUserID, Count: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Results) != 5 {
+		t.Errorf("expected 5 results, got %d", len(resp.Results))
+	}
+	state, err := ls.staminaService.GetState(ctx, testUserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Stamina != 0 || state.NextRefillAt == nil || state.NextRefillAt.Before(time.Now()) {
+		t.Errorf("stamina after full-cost batch %+v", state)
+	}
+}
@@ -0,0 +1,395 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"fishing-game/config"
+	"fishing-game/model"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// 统计范围
+	StatsScopeGlobal = "global"
+	StatsScopeDaily  = "daily"
+	StatsScopeUser   = "user"
+
+	// StatsTotalField 统计hash中记录总次数的字段
+	StatsTotalField = "_total"
+	// StatsSpotTotalPrefix 统计hash中记录各钓点次数的字段前缀（_total:{spot}）
+	StatsSpotTotalPrefix = "_total:"
+	// StatsDeviationPrefix 全局和每日统计hash中记录各鱼类概率偏差的字段前缀（_deviation:{item_id}），
+	// 为各次抽奖实际概率与配置概率之差的累计，按 StatsDeviationScale 取整，只记录概率被调整过的鱼类
+	StatsDeviationPrefix = "_deviation:"
+	// StatsAdjustedField 统计hash中记录实际概率与配置概率不同的抽奖次数的字段
+	StatsAdjustedField = "_adjusted"
+	// StatsDeviationScale 概率偏差的取整精度，整数累计避免浮点误差
+	StatsDeviationScale = 1000000000
+
+	// DailyStatsTTL 每日统计保留时长
+	DailyStatsTTL = 90 * 24 * time.Hour
+)
+
+// ErrInvalidStatsQuery 统计查询参数错误
+var ErrInvalidStatsQuery = errors.New("invalid stats query")
+
+// StatsService 出鱼统计服务，作为抽奖事件监听器维护各鱼类的出鱼次数
+type StatsService struct {
+	redisClient *redis.Client
+	poolService *PoolService
+	location    *time.Location
+}
+
+// NewStatsService 创建统计服务
+func NewStatsService(poolService *PoolService) *StatsService {
+	return &StatsService{
+		redisClient: config.GetRedisClient(),
+		poolService: poolService,
+		location:    config.GetEnvLocation("GAME_TIMEZONE", DefaultGameTimezone),
+	}
+}
+
+// statsGlobalKey 全局出鱼次数（item_id -> 次数）
+func statsGlobalKey() string {
+	return "lottery:stats:global"
+}
+
+// statsDailyKey 每日出鱼次数
+func statsDailyKey(date string) string {
+	return fmt.Sprintf("lottery:stats:daily:%s", date)
+}
+
+// statsUserKey 用户出鱼次数
+func statsUserKey(userID string) string {
+	return fmt.Sprintf("lottery:stats:user:%s", userID)
+}
+
+// itemCatchesKey 鱼类被钓累计（HASH：total 为被钓次数，first_caught_at / last_caught_at 为毫秒时间），由抽奖提交脚本维护
+func itemCatchesKey(itemID string) string {
+	return fmt.Sprintf("lottery:catches:%s", itemID)
+}
+
+// itemCatchersKey 钓到过该鱼类的玩家（SET，member为用户ID），由抽奖提交脚本维护
+func itemCatchersKey(itemID string) string {
+	return fmt.Sprintf("lottery:catchers:%s", itemID)
+}
+
+// oddsDeviation 一次选鱼的实际概率与配置概率之差，按 StatsDeviationScale 取整，只包含不为0的鱼类。
+// 取整误差计入偏差绝对值最大的鱼类，保证偏差之和为0
+func oddsDeviation(effective, configured map[string]float64) map[string]int64 {
+	deviation := make(map[string]int64)
+	var sum int64
+	largest := ""
+	for itemID, p := range effective {
+		if d := int64(math.Round((p - configured[itemID]) * StatsDeviationScale)); d != 0 {
+			deviation[itemID] = d
+		}
+	}
+	for itemID, p := range configured {
+		if _, exists := effective[itemID]; exists {
+			continue
+		}
+		if d := int64(math.Round(-p * StatsDeviationScale)); d != 0 {
+			deviation[itemID] = d
+		}
+	}
+	for itemID, d := range deviation {
+		sum += d
+		if largest == "" || abs64(d) > abs64(deviation[largest]) || (abs64(d) == abs64(deviation[largest]) && itemID < largest) {
+			largest = itemID
+		}
+	}
+	if sum != 0 {
+		deviation[largest] -= sum
+		if deviation[largest] == 0 {
+			delete(deviation, largest)
+		}
+	}
+	return deviation
+}
+
+// abs64 整数绝对值
+func abs64(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// OnDraw 累加全局、当日和用户的出鱼次数、钓点次数和调整过概率的抽奖次数，全局和当日统计另累加概率偏差。
+// 用户统计只记录钓到过的鱼类
+func (ss *StatsService) OnDraw(ctx context.Context, event *DrawEvent) error {
+	counts := make(map[string]int64)
+	for _, record := range event.Records {
+		counts[record.ItemID]++
+	}
+	dailyKey := statsDailyKey(event.CreatedAt.In(ss.location).Format("20060102"))
+	keys := []string{statsGlobalKey(), dailyKey, statsUserKey(event.UserID)}
+
+	pipe := ss.redisClient.Pipeline()
+	for _, key := range keys {
+		for itemID, count := range counts {
+			pipe.HIncrBy(ctx, key, itemID, count)
+		}
+		pipe.HIncrBy(ctx, key, StatsTotalField, int64(len(event.Records)))
+		pipe.HIncrBy(ctx, key, StatsSpotTotalPrefix+event.Spot, int64(len(event.Records)))
+		if event.Adjusted > 0 {
+			pipe.HIncrBy(ctx, key, StatsAdjustedField, event.Adjusted)
+		}
+	}
+	for _, key := range keys[:2] {
+		for itemID, deviation := range event.Deviation {
+			if deviation != 0 {
+				pipe.HIncrBy(ctx, key, StatsDeviationPrefix+itemID, deviation)
+			}
+		}
+	}
+	pipe.Expire(ctx, dailyKey, DailyStatsTTL)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to update draw stats: %w", err)
+	}
+	return nil
+}
+
+// GetStats 获取指定范围的出鱼统计，并做卡方拟合优度检验。
+// 期望次数为配置概率乘以抽奖次数，全局和每日范围再加上累计的概率偏差（活动、加成、装备、保底和策略的调整），
+// 用户范围只按配置概率计算。
+// 配置概率为各钓点奖池概率按该范围内各钓点抽奖次数加权的混合
+func (ss *StatsService) GetStats(ctx context.Context, scope, date, userID string) (*model.DrawStatsResponse, error) {
+	response := &model.DrawStatsResponse{Scope: scope}
+
+	var key string
+	switch scope {
+	case StatsScopeGlobal, "":
+		response.Scope = StatsScopeGlobal
+		key = statsGlobalKey()
+	case StatsScopeDaily:
+		if date == "" {
+			date = time.Now().In(ss.location).Format("20060102")
+		}
+		if _, err := time.Parse("20060102", date); err != nil {
+			return nil, fmt.Errorf("%w: date must be YYYYMMDD", ErrInvalidStatsQuery)
+		}
+		response.Date = date
+		key = statsDailyKey(date)
+	case StatsScopeUser:
+		if userID == "" {
+			return nil, fmt.Errorf("%w: user_id is required", ErrInvalidStatsQuery)
+		}
+		response.UserID = userID
+		key = statsUserKey(userID)
+	default:
+		return nil, fmt.Errorf("%w: unknown scope %s", ErrInvalidStatsQuery, scope)
+	}
+
+	counters, err := ss.redisClient.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get draw stats: %w", err)
+	}
+
+	items, err := ss.poolService.GetItems(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool: %w", err)
+	}
+
+	observed := make(map[string]int64, len(counters))
+	deviation := make(map[string]float64)
+	spotDraws := make(map[string]int64)
+	var attributed int64
+	for field, value := range counters {
+		count, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue // 跳过旧版的浮点期望次数等无法解析的字段
+		}
+		if strings.HasPrefix(field, StatsDeviationPrefix) {
+			deviation[strings.TrimPrefix(field, StatsDeviationPrefix)] = float64(count) / StatsDeviationScale
+			continue
+		}
+		if strings.HasPrefix(field, "_expected:") {
+			continue // 旧版累计的期望次数，已由概率偏差替代
+		}
+		switch field {
+		case StatsTotalField:
+			response.TotalDraws = count
+			continue
+		case StatsAdjustedField:
+			response.AdjustedDraws = count
+			continue
+		}
+		if strings.HasPrefix(field, StatsSpotTotalPrefix) {
+			spotDraws[strings.TrimPrefix(field, StatsSpotTotalPrefix)] = count
+			attributed += count
+			continue
+		}
+		observed[field] = count
+	}
+	// 未记录钓点的历史抽奖计入默认钓点
+	if rest := response.TotalDraws - attributed; rest > 0 {
+		spotDraws[ss.poolService.DefaultSpot()] += rest
+	}
+	if len(spotDraws) > 0 {
+		response.Spots = spotDraws
+	}
+
+	probabilities, err := ss.mixedProbabilities(ctx, spotDraws, response.TotalDraws)
+	if err != nil {
+		return nil, err
+	}
+
+	// 汇总配置中的鱼类和统计中出现过的鱼类（可能已不在奖池中）
+	itemSet := make(map[string]bool, len(probabilities))
+	for itemID := range probabilities {
+		itemSet[itemID] = true
+	}
+	for itemID := range observed {
+		itemSet[itemID] = true
+	}
+	for itemID := range deviation {
+		itemSet[itemID] = true
+	}
+	itemIDs := make([]string, 0, len(itemSet))
+	for itemID := range itemSet {
+		itemIDs = append(itemIDs, itemID)
+	}
+	sort.Strings(itemIDs)
+
+	categories := 0
+	for _, itemID := range itemIDs {
+		stats := &model.ItemDrawStats{
+			ItemID:                itemID,
+			Observed:              observed[itemID],
+			ConfiguredProbability: probabilities[itemID],
+			ConfiguredWeight:      int(math.Round(probabilities[itemID] * TotalWeight)),
+		}
+		if item, exists := items[itemID]; exists {
+			stats.ItemName = item.Name
+		}
+		if response.TotalDraws > 0 {
+			stats.ObservedFrequency = float64(stats.Observed) / float64(response.TotalDraws)
+		}
+
+		stats.Expected = stats.ConfiguredProbability*float64(response.TotalDraws) + deviation[itemID]
+		if stats.Expected < 0 {
+			stats.Expected = 0
+		}
+		if response.TotalDraws > 0 {
+			stats.EffectiveProbability = stats.Expected / float64(response.TotalDraws)
+		}
+		if stats.Expected > 0 {
+			diff := float64(stats.Observed) - stats.Expected
+			stats.ChiSquare = diff * diff / stats.Expected
+			response.ChiSquare += stats.ChiSquare
+			categories++
+		}
+
+		response.Items = append(response.Items, stats)
+	}
+
+	if categories > 1 {
+		response.DegreesOfFreedom = categories - 1
+		response.PValue = chiSquareSurvival(response.ChiSquare, response.DegreesOfFreedom)
+	} else {
+		response.PValue = 1
+	}
+
+	return response, nil
+}
+
+// mixedProbabilities 按各钓点抽奖次数占比混合各钓点奖池的配置概率，没有抽奖时使用默认钓点的概率。
+// 已不再配置的钓点不参与混合
+func (ss *StatsService) mixedProbabilities(ctx context.Context, spotDraws map[string]int64, totalDraws int64) (map[string]float64, error) {
+	shares := map[string]float64{ss.poolService.DefaultSpot(): 1}
+	if totalDraws > 0 {
+		shares = make(map[string]float64, len(spotDraws))
+		for spot, count := range spotDraws {
+			shares[spot] = float64(count) / float64(totalDraws)
+		}
+	}
+
+	probabilities := make(map[string]float64)
+	for spot, share := range shares {
+		snapshot, err := ss.poolService.GetSnapshot(ctx, spot)
+		if err != nil {
+			if errors.Is(err, ErrUnknownSpot) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to get pool: %w", err)
+		}
+		pool := snapshot.Pool
+		if pool.TotalWeight <= 0 {
+			continue
+		}
+		for itemID, weight := range pool.Weights {
+			probabilities[itemID] += share * float64(weight) / float64(pool.TotalWeight)
+		}
+	}
+	return probabilities, nil
+}
+
+// chiSquareSurvival 卡方分布的右尾概率 P(X >= x)，即 Q(k/2, x/2)
+func chiSquareSurvival(x float64, k int) float64 {
+	if x <= 0 {
+		return 1
+	}
+	return regularizedGammaQ(float64(k)/2, x/2)
+}
+
+// regularizedGammaQ 正则化上不完全伽马函数 Q(a, x)
+func regularizedGammaQ(a, x float64) float64 {
+	const (
+		maxIterations = 500
+		epsilon       = 1e-14
+		tiny          = 1e-300
+	)
+
+	lgammaA, _ := math.Lgamma(a)
+	prefix := math.Exp(-x + a*math.Log(x) - lgammaA)
+
+	if x < a+1 {
+		// 级数展开求 P(a, x)
+		sum := 1 / a
+		term := sum
+		for n := 1; n < maxIterations; n++ {
+			term *= x / (a + float64(n))
+			sum += term
+			if math.Abs(term) < math.Abs(sum)*epsilon {
+				break
+			}
+		}
+		return math.Max(0, 1-sum*prefix)
+	}
+
+	// 连分式求 Q(a, x)（Lentz算法）
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for n := 1; n < maxIterations; n++ {
+		an := -float64(n) * (float64(n) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+	return math.Min(1, prefix*h)
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestStatsExpectedUsesEffectiveProbability(t *testing.T) {
+	ls, ctx := newTestLotteryService(t)
+	seedDrawState(t, ls, ctx)
+	ss := NewStatsService(ls.poolService)
+	ls.AddDrawListener(ss)
+
+	// 第一次抽奖带有空军减半的加成，第二次没有
+	caught := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		resp, err := ls.Draw(ctx, fairDrawRequest())
+		if err != nil {
+			t.Fatal(err)
+		}
+		caught[resp.Result.ItemID] = true
+	}
+
+	stats, err := ss.GetStats(ctx, StatsScopeGlobal, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.TotalDraws != 2 || stats.AdjustedDraws < 1 {
+		t.Fatalf("total draws %d adjusted %d, expected 2 and at least 1", stats.TotalDraws, stats.AdjustedDraws)
+	}
+
+	var total float64
+	for _, item := range stats.Items {
+		total += item.Expected
+		if item.ItemID != EmptyFishID {
+			continue
+		}
+		if item.EffectiveProbability >= item.ConfiguredProbability {
+			t.Errorf("empty effective probability %v not below configured %v", item.EffectiveProbability, item.ConfiguredProbability)
+		}
+	}
+	if math.Abs(total-2) > 1e-9 {
+		t.Errorf("expected counts sum to %v, expected 2", total)
+	}
+
+	// 用户统计只记录钓到过的鱼类
+	fields, err := ss.redisClient.HGetAll(ctx, statsUserKey(testUserID)).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for field := range fields {
+		if !strings.HasPrefix(field, "_") && !caught[field] {
+			t.Errorf("user stats has unobserved item %s", field)
+		}
+		if strings.HasPrefix(field, StatsDeviationPrefix) {
+			t.Errorf("user stats has deviation field %s", field)
+		}
+	}
+}
+
+func TestOddsDeviationSumsToZero(t *testing.T) {
+	configured := map[string]float64{EmptyFishID: 0.5, SmallFishID: 0.3, MediumFishID: 0.2}
+	if deviation := oddsDeviation(configured, configured); len(deviation) != 0 {
+		t.Errorf("unadjusted draw has deviation %v", deviation)
+	}
+
+	// 空军概率被三等分给其余鱼类，各项取整后仍精确抵消
+	effective := map[string]float64{EmptyFishID: 1.0 / 3, SmallFishID: 0.3 + 1.0/18, MediumFishID: 0.2 + 1.0/18, RareFishID: 1.0 / 18}
+	deviation := oddsDeviation(effective, configured)
+	var sum int64
+	for _, d := range deviation {
+		sum += d
+	}
+	if sum != 0 || len(deviation) != 4 {
+		t.Errorf("deviation %v does not sum to zero", deviation)
+	}
+	if d := deviation[RareFishID]; d != int64(math.Round(StatsDeviationScale/18.0)) {
+		t.Errorf("rare fish deviation %d", d)
+	}
+
+	// 配置中有而本次不可能出的鱼类记为负偏差
+	deviation = oddsDeviation(map[string]float64{SmallFishID: 0.6, MediumFishID: 0.4}, configured)
+	if deviation[EmptyFishID] != -StatsDeviationScale/2 || deviation[SmallFishID]+deviation[MediumFishID] != StatsDeviationScale/2 {
+		t.Errorf("unexpected deviation %v", deviation)
+	}
+}
+
+func TestDrawSelectionProbabilitiesWithGuarantee(t *testing.T) {
+	selection := &drawSelection{
+		weights:  map[string]int{EmptyFishID: 50, SmallFishID: 30, MediumFishID: 20},
+		fallback: map[string]int{SmallFishID: 3, MediumFishID: 1},
+	}
+
+	// 空军的0.5按保底权重3:1分给小鱼和中鱼
+	want := map[string]float64{SmallFishID: 0.3 + 0.375, MediumFishID: 0.2 + 0.125}
+	got := selection.probabilities()
+	if len(got) != len(want) {
+		t.Fatalf("probabilities %v, expected %v", got, want)
+	}
+	for itemID, p := range want {
+		if math.Abs(got[itemID]-p) > 1e-9 {
+			t.Errorf("probability of %s %v, expected %v", itemID, got[itemID], p)
+		}
+	}
+}
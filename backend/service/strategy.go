@@ -0,0 +1,195 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"fishing-game/model"
+)
+
+const (
+	// 内置策略名称
+	StrategyNoRepeat = "no_repeat"
+	StrategyNewbie   = "newbie"
+
+	// 新手策略配置
+	NewbieDrawThreshold  = 20 // 累计抽奖次数少于该值视为新手
+	NewbieEmptyWeightPct = 50 // 新手空军权重保留的百分比
+)
+
+// ErrUnknownStrategy 未注册的抽奖策略
+var ErrUnknownStrategy = errors.New("unknown draw strategy")
+
+// DrawInput 策略选鱼所需的上下文
+type DrawInput struct {
+	UserID     string
+	Items      map[string]*model.LotteryItem // 奖池所有鱼类
+	Weights    map[string]int                // 本次抽奖的有效权重（已应用保底等调整，策略不得修改）
+	Alias      *AliasTable                   // 有效权重对应的别名表，仅当权重未经调整时提供
+	LastItemID string                        // 用户上一次钓到的鱼
+	TotalDraws int                           // 用户累计抽奖次数
+}
+
+// DrawStrategy 抽奖选鱼策略
+type DrawStrategy interface {
+	// Name 策略名称，会记录到抽奖记录中
+	Name() string
+	// Select 从奖池中选出一条鱼
+	Select(input *DrawInput) (*model.LotteryItem, error)
+}
+
+// StrategyWeigher 可选接口：策略报告本次实际用于选鱼的权重，出鱼统计据此累计期望次数。
+// 未实现时按 DrawInput.Weights 累计
+type StrategyWeigher interface {
+	EffectiveWeights(input *DrawInput) map[string]int
+}
+
+// StrategyRegistry 抽奖策略注册表
+type StrategyRegistry struct {
+	mu         sync.RWMutex
+	strategies map[string]DrawStrategy
+}
+
+// NewStrategyRegistry 创建策略注册表
+func NewStrategyRegistry(strategies ...DrawStrategy) *StrategyRegistry {
+	sr := &StrategyRegistry{
+		strategies: make(map[string]DrawStrategy),
+	}
+	for _, strategy := range strategies {
+		sr.Register(strategy)
+	}
+	return sr
+}
+
+// Register 注册策略，同名策略会被覆盖
+func (sr *StrategyRegistry) Register(strategy DrawStrategy) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	sr.strategies[strategy.Name()] = strategy
+}
+
+// Get 按名称获取策略
+func (sr *StrategyRegistry) Get(name string) (DrawStrategy, error) {
+	sr.mu.RLock()
+	defer sr.mu.RUnlock()
+
+	strategy, exists := sr.strategies[name]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownStrategy, name)
+	}
+	return strategy, nil
+}
+
+// Names 获取所有已注册的策略名称
+func (sr *StrategyRegistry) Names() []string {
+	sr.mu.RLock()
+	defer sr.mu.RUnlock()
+
+	names := make([]string, 0, len(sr.strategies))
+	for name := range sr.strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DrawStrategies 全局策略注册表
+var DrawStrategies = NewStrategyRegistry(
+	&WeightedStrategy{},
+	&NoRepeatStrategy{},
+	&NewbieStrategy{},
+)
+
+// WeightedStrategy 按权重随机选择（默认策略）
+type WeightedStrategy struct{}
+
+// Name 策略名称
+func (s *WeightedStrategy) Name() string {
+	return StrategyDefault
+}
+
+// Select 按有效权重随机选择，有别名表时O(1)采样
+func (s *WeightedStrategy) Select(input *DrawInput) (*model.LotteryItem, error) {
+	if input.Alias == nil {
+		return weightedRandomSelect(input.Items, input.Weights)
+	}
+
+	fishID, err := input.Alias.Sample()
+	if err != nil {
+		return nil, err
+	}
+	item, exists := input.Items[fishID]
+	if !exists {
+		return nil, fmt.Errorf("item %s not found in pool", fishID)
+	}
+	return item, nil
+}
+
+// EffectiveWeights 即有效权重
+func (s *WeightedStrategy) EffectiveWeights(input *DrawInput) map[string]int {
+	return input.Weights
+}
+
+// NoRepeatStrategy 避免与用户上一次钓到的鱼重复
+type NoRepeatStrategy struct{}
+
+// Name 策略名称
+func (s *NoRepeatStrategy) Name() string {
+	return StrategyNoRepeat
+}
+
+// Select 排除上一次的鱼后按权重随机选择，排除后没有可选的鱼时退化为默认策略
+func (s *NoRepeatStrategy) Select(input *DrawInput) (*model.LotteryItem, error) {
+	return weightedRandomSelect(input.Items, s.EffectiveWeights(input))
+}
+
+// EffectiveWeights 排除上一次的鱼后的权重，排除后没有可选的鱼时为有效权重
+func (s *NoRepeatStrategy) EffectiveWeights(input *DrawInput) map[string]int {
+	if input.LastItemID == "" || input.Weights[input.LastItemID] <= 0 {
+		return input.Weights
+	}
+
+	weights := make(map[string]int, len(input.Weights))
+	remaining := 0
+	for fishID, weight := range input.Weights {
+		if fishID == input.LastItemID {
+			continue
+		}
+		weights[fishID] = weight
+		remaining += weight
+	}
+
+	if remaining <= 0 {
+		return input.Weights
+	}
+	return weights
+}
+
+// NewbieStrategy 新手加成：累计抽奖次数较少的用户降低空军权重
+type NewbieStrategy struct{}
+
+// Name 策略名称
+func (s *NewbieStrategy) Name() string {
+	return StrategyNewbie
+}
+
+// Select 新手降低空军权重后按权重随机选择，非新手按默认权重选择
+func (s *NewbieStrategy) Select(input *DrawInput) (*model.LotteryItem, error) {
+	return weightedRandomSelect(input.Items, s.EffectiveWeights(input))
+}
+
+// EffectiveWeights 新手为降低空军后的权重，非新手为有效权重
+func (s *NewbieStrategy) EffectiveWeights(input *DrawInput) map[string]int {
+	if input.TotalDraws >= NewbieDrawThreshold {
+		return input.Weights
+	}
+
+	weights := make(map[string]int, len(input.Weights))
+	for fishID, weight := range input.Weights {
+		weights[fishID] = weight
+	}
+	weights[EmptyFishID] = weights[EmptyFishID] * NewbieEmptyWeightPct / 100
+	return weights
+}
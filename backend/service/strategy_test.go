@@ -0,0 +1,79 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"fishing-game/model"
+)
+
+func TestNoRepeatStrategyExcludesLastItem(t *testing.T) {
+	items := map[string]*model.LotteryItem{
+		SmallFishID:  {ID: SmallFishID},
+		MediumFishID: {ID: MediumFishID},
+	}
+	strategy := &NoRepeatStrategy{}
+
+	input := &DrawInput{Items: items, Weights: map[string]int{SmallFishID: 1, MediumFishID: 1}, LastItemID: SmallFishID}
+	for i := 0; i < 50; i++ {
+		item, err := strategy.Select(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if item.ID != MediumFishID {
+			t.Fatalf("no_repeat selected last item %s", item.ID)
+		}
+	}
+
+	// 排除后没有可选的鱼时退化为有效权重
+	input.Weights = map[string]int{SmallFishID: 1, MediumFishID: 0}
+	if weights := strategy.EffectiveWeights(input); weights[SmallFishID] != 1 {
+		t.Errorf("fallback weights %v", weights)
+	}
+}
+
+func TestNewbieStrategyReducesEmptyWeight(t *testing.T) {
+	strategy := &NewbieStrategy{}
+	input := &DrawInput{Weights: map[string]int{EmptyFishID: 1000, SmallFishID: 500}}
+
+	weights := strategy.EffectiveWeights(input)
+	if weights[EmptyFishID] != 1000*NewbieEmptyWeightPct/100 || weights[SmallFishID] != 500 {
+		t.Errorf("newbie weights %v", weights)
+	}
+	if input.Weights[EmptyFishID] != 1000 {
+		t.Error("newbie strategy modified input weights")
+	}
+
+	input.TotalDraws = NewbieDrawThreshold
+	if weights := strategy.EffectiveWeights(input); weights[EmptyFishID] != 1000 {
+		t.Errorf("veteran weights %v", weights)
+	}
+}
+
+func TestDrawRecordsRequestedStrategy(t *testing.T) {
+	ls, ctx := newTestLotteryService(t)
+
+	resp, err := ls.Draw(ctx, &model.LotteryDrawRequest{UserID: testUserID, Strategy: StrategyNoRepeat})
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, err := ls.GetDraw(ctx, resp.DrawID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Strategy != StrategyNoRepeat {
+		t.Errorf("record strategy %q, expected %q", record.Strategy, StrategyNoRepeat)
+	}
+}
+
+func TestDrawWithUnknownStrategyLeavesNoWrites(t *testing.T) {
+	ls, ctx := newTestLotteryService(t)
+
+	_, err := ls.Draw(ctx, &model.LotteryDrawRequest{UserID: testUserID, Strategy: "unknown"})
+	if !errors.Is(err, ErrUnknownStrategy) {
+		t.Fatalf("expected ErrUnknownStrategy, got %v", err)
+	}
+	if exists, _ := ls.redisClient.Exists(ctx, drawHistoryKey(testUserID), staminaKey(testUserID), drawProfileKey(testUserID)).Result(); exists != 0 {
+		t.Error("rejected draw wrote draw state")
+	}
+}
@@ -0,0 +1,727 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"fishing-game/config"
+	"fishing-game/model"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// TournamentPrizesConfigFile 比赛奖励表配置文件
+	TournamentPrizesConfigFile = "tournament_prizes.json"
+
+	// TournamentsKey 所有比赛（ZSET，比赛ID -> 创建时间毫秒）
+	TournamentsKey = "tournaments"
+)
+
+var (
+	// ErrInvalidTournament 比赛参数不合法
+	ErrInvalidTournament = errors.New("invalid tournament")
+	// ErrTournamentNotFound 比赛不存在
+	ErrTournamentNotFound = errors.New("tournament not found")
+	// ErrTournamentState 比赛当前状态不允许该操作
+	ErrTournamentState = errors.New("tournament status does not allow this action")
+	// ErrTournamentEntryClosed 不在报名窗口内或比赛已开赛
+	ErrTournamentEntryClosed = errors.New("tournament entry is closed")
+	// ErrTournamentFull 报名人数已满
+	ErrTournamentFull = errors.New("tournament is full")
+	// ErrAlreadyRegistered 已报名
+	ErrAlreadyRegistered = errors.New("already registered")
+)
+
+// tournamentKey 比赛（HASH：data 比赛定义JSON、status、spot、end_at、started_at、ended_at、bracket_count）
+func tournamentKey(tournamentID string) string {
+	return fmt.Sprintf("tournament:%s", tournamentID)
+}
+
+// tournamentPlayersKey 比赛报名玩家（ZSET，用户ID -> 报名时间毫秒）
+func tournamentPlayersKey(tournamentID string) string {
+	return fmt.Sprintf("tournament:%s:players", tournamentID)
+}
+
+// tournamentBracketsKey 玩家所在分组（HASH，用户ID -> 分组序号，开赛时写入）
+func tournamentBracketsKey(tournamentID string) string {
+	return fmt.Sprintf("tournament:%s:brackets", tournamentID)
+}
+
+// tournamentBoardKey 第bracket组的比赛榜单（ZSET，用户ID -> 比赛积分），分组序号从1开始
+func tournamentBoardKey(tournamentID string, bracket int) string {
+	return fmt.Sprintf("tournament:%s:board:%d", tournamentID, bracket)
+}
+
+// tournamentPaidKey 已发奖玩家（HASH，用户ID -> 发放金币）
+func tournamentPaidKey(tournamentID string) string {
+	return fmt.Sprintf("tournament:%s:paid", tournamentID)
+}
+
+// tournamentUserKey 用户报名且尚未结束的比赛（SET），抽奖时只需检查这些比赛
+func tournamentUserKey(userID string) string {
+	return fmt.Sprintf("tournament:user:%s", userID)
+}
+
+// tournamentEntryScript 报名：检查状态、报名窗口和人数上限后写入报名记录
+//
+// KEYS[1] 比赛 KEYS[2] 报名玩家 KEYS[3] 用户比赛
+// ARGV[1] 用户ID ARGV[2] 当前时间（毫秒） ARGV[3] 比赛ID
+// ARGV[4] 报名开始时间（毫秒） ARGV[5] 报名截止时间（毫秒） ARGV[6] 人数上限（0为不限）
+//
+// 返回报名人数
+var tournamentEntryScript = redis.NewScript(`
+local status = redis.call('HGET', KEYS[1], 'status')
+if not status then
+	return redis.error_reply('tournament not found')
+end
+local now = tonumber(ARGV[2])
+if status ~= 'registration' or now < tonumber(ARGV[4]) or now >= tonumber(ARGV[5]) then
+	return redis.error_reply('entry closed')
+end
+if redis.call('ZSCORE', KEYS[2], ARGV[1]) then
+	return redis.error_reply('already registered')
+end
+local max = tonumber(ARGV[6])
+if max > 0 and redis.call('ZCARD', KEYS[2]) >= max then
+	return redis.error_reply('tournament full')
+end
+redis.call('ZADD', KEYS[2], now, ARGV[1])
+redis.call('SADD', KEYS[3], ARGV[3])
+return redis.call('ZCARD', KEYS[2])
+`)
+
+// tournamentStartScript 开赛：按报名顺序将玩家连续、均匀地分入各组并初始化分组榜单。
+// 分组数由服务端按读取时的报名人数计算，各组榜单key均在KEYS中声明；报名人数已变化时不做任何写入
+//
+// KEYS[1] 比赛 KEYS[2] 报名玩家 KEYS[3] 玩家分组 KEYS[4...] 第1组起的分组榜单
+// ARGV[1] 开赛时间（毫秒） ARGV[2] 读取时的报名人数
+//
+// 返回分组数
+var tournamentStartScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'status') ~= 'registration' then
+	return redis.error_reply('invalid status')
+end
+local players = redis.call('ZRANGE', KEYS[2], 0, -1)
+if #players == 0 then
+	return redis.error_reply('no players')
+end
+if #players ~= tonumber(ARGV[2]) then
+	return redis.error_reply('players changed')
+end
+
+local count = #KEYS - 3
+for i, user in ipairs(players) do
+	local bracket = math.floor((i - 1) * count / #players) + 1
+	redis.call('HSET', KEYS[3], user, bracket)
+	redis.call('ZADD', KEYS[3 + bracket], 0, user)
+end
+redis.call('HSET', KEYS[1], 'status', 'running', 'started_at', ARGV[1], 'bracket_count', count)
+return count
+`)
+
+// tournamentScriptFunctions 比赛计分的Lua函数，由抽奖提交脚本调用，渔获积分与抽奖记录在同一脚本中写入
+//
+// tournamentsUnchanged 校验用户参加的比赛及各比赛状态与读取时的快照一致，tournamentKey(i, offset) 为第i场比赛的
+// 比赛（offset 1）和分组榜单（offset 2）key；scoreTournaments 将积分计入快照中需要计分的比赛的分组榜单
+const tournamentScriptFunctions = `
+local function tournamentsUnchanged(userTournaments, snapshot, tournamentKey)
+	if redis.call('SCARD', userTournaments) ~= #snapshot then
+		return false
+	end
+	for i, entry in ipairs(snapshot) do
+		if redis.call('SISMEMBER', userTournaments, entry['id']) == 0 then
+			return false
+		end
+		if (redis.call('HGET', tournamentKey(i, 1), 'status') or '') ~= entry['status'] then
+			return false
+		end
+	end
+	return true
+end
+
+local function scoreTournaments(snapshot, tournamentKey, userID, points)
+	for i, entry in ipairs(snapshot) do
+		if entry['score'] then
+			redis.call('ZINCRBY', tournamentKey(i, 2), points, userID)
+		end
+	end
+end
+`
+
+// tournamentCloseScript 结算或取消比赛。结算只允许进行中的比赛，取消允许报名中和进行中的比赛；
+// 状态改变后分组榜单不再计分，即冻结为最终排名
+//
+// KEYS[1] 比赛
+// ARGV[1] 目标状态（settled/cancelled） ARGV[2] 结束时间（毫秒）
+var tournamentCloseScript = redis.NewScript(`
+local status = redis.call('HGET', KEYS[1], 'status')
+if ARGV[1] == 'settled' then
+	if status ~= 'running' then
+		return redis.error_reply('invalid status')
+	end
+elseif status ~= 'registration' and status ~= 'running' then
+	return redis.error_reply('invalid status')
+end
+redis.call('HSET', KEYS[1], 'status', ARGV[1], 'ended_at', ARGV[2])
+return 'OK'
+`)
+
+// tournamentPrizeScript 发放比赛金币和积分奖励并记账，HSETNX保证每名玩家只发放一次
+//
+// KEYS[1] 已发奖玩家 KEYS[2] 钱包 KEYS[3] 账本 KEYS[4] 全局榜单
+// ARGV[1] 用户ID ARGV[2] 金币 ARGV[3] 账本记录JSON ARGV[4] 积分 ARGV[5] 榜单成员
+//
+// 返回 {是否发放, 新的榜单积分（未发放积分时为0）}
+var tournamentPrizeScript = redis.NewScript(`
+if redis.call('HSETNX', KEYS[1], ARGV[1], ARGV[2]) == 0 then
+	return {0, 0}
+end
+local coins = tonumber(ARGV[2])
+local balance
+if coins > 0 then
+	balance = redis.call('HINCRBY', KEYS[2], 'coins', coins)
+else
+	balance = tonumber(redis.call('HGET', KEYS[2], 'coins') or '0')
+end
+local entry = cjson.decode(ARGV[3])
+entry['balance'] = balance
+redis.call('RPUSH', KEYS[3], cjson.encode(entry))
+
+local score = 0
+if tonumber(ARGV[4]) > 0 then
+	score = tonumber(redis.call('ZINCRBY', KEYS[4], tonumber(ARGV[4]), ARGV[5]))
+end
+return {1, score}
+`)
+
+// TournamentService 钓鱼比赛服务：报名窗口内报名，开赛后的渔获计入独立的比赛榜单，结算时按奖励表发奖
+type TournamentService struct {
+	redisClient    *redis.Client
+	poolService    *PoolService
+	rankingService *RankingService
+	prizeTables    map[string]*model.TournamentPrizeTable
+}
+
+// tournamentSnapshot 抽奖时读取的用户所参加比赛的状态，提交脚本校验状态未变后按 Score 计分
+type tournamentSnapshot struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	Score  bool   `json:"score"` // 进行中、钓点匹配、抽奖时间在开赛到结束之间且已分组
+	board  string `json:"-"`     // 玩家所在分组的榜单，不计分时为空
+}
+
+// NewTournamentService 创建比赛服务，奖励表不存在时只能创建不发奖的比赛
+func NewTournamentService(poolService *PoolService, rankingService *RankingService) (*TournamentService, error) {
+	var cfg model.TournamentPrizeConfig
+	found, err := config.LoadJSON(TournamentPrizesConfigFile, &cfg)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		log.Printf("%s not found, tournament prizes disabled", TournamentPrizesConfigFile)
+	}
+	tables, err := validateTournamentPrizes(&cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TournamentService{
+		redisClient:    config.GetRedisClient(),
+		poolService:    poolService,
+		rankingService: rankingService,
+		prizeTables:    tables,
+	}, nil
+}
+
+// validateTournamentPrizes 校验奖励表：名次区间从1开始、互不重叠，奖励不为负
+func validateTournamentPrizes(cfg *model.TournamentPrizeConfig) (map[string]*model.TournamentPrizeTable, error) {
+	tables := make(map[string]*model.TournamentPrizeTable, len(cfg.Tables))
+	for _, table := range cfg.Tables {
+		if table.ID == "" {
+			return nil, fmt.Errorf("tournament prize table requires id")
+		}
+		if _, exists := tables[table.ID]; exists {
+			return nil, fmt.Errorf("duplicate tournament prize table %s", table.ID)
+		}
+
+		sort.Slice(table.Prizes, func(i, j int) bool {
+			return table.Prizes[i].RankFrom < table.Prizes[j].RankFrom
+		})
+		lastRank := 0
+		for _, prize := range table.Prizes {
+			if prize.RankFrom <= lastRank || prize.RankTo < prize.RankFrom {
+				return nil, fmt.Errorf("tournament prize table %s: invalid or overlapping ranks %d-%d", table.ID, prize.RankFrom, prize.RankTo)
+			}
+			if prize.Coins < 0 || prize.Points < 0 {
+				return nil, fmt.Errorf("tournament prize table %s: prizes must not be negative", table.ID)
+			}
+			lastRank = prize.RankTo
+		}
+		tables[table.ID] = table
+	}
+	return tables, nil
+}
+
+// prizeFor 名次对应的奖励
+func prizeFor(prizes []*model.TournamentPrize, rank int) *model.TournamentPrize {
+	for _, prize := range prizes {
+		if rank >= prize.RankFrom && rank <= prize.RankTo {
+			return prize
+		}
+	}
+	return nil
+}
+
+// tournamentScriptError 将脚本返回的业务错误转换为对应的错误类型
+func tournamentScriptError(err error) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "tournament not found"):
+		return ErrTournamentNotFound
+	case strings.Contains(msg, "entry closed"):
+		return ErrTournamentEntryClosed
+	case strings.Contains(msg, "already registered"):
+		return ErrAlreadyRegistered
+	case strings.Contains(msg, "tournament full"):
+		return ErrTournamentFull
+	case strings.Contains(msg, "no players"):
+		return fmt.Errorf("%w: no registered players", ErrTournamentState)
+	case strings.Contains(msg, "invalid status"):
+		return ErrTournamentState
+	}
+	return err
+}
+
+// CreateTournament 创建比赛，创建后即进入报名阶段
+func (ts *TournamentService) CreateTournament(ctx context.Context, req *model.CreateTournamentRequest) (*model.Tournament, error) {
+	now := time.Now()
+	opensAt := now
+	if req.EntryOpensAt != nil {
+		opensAt = *req.EntryOpensAt
+	}
+	if !opensAt.Before(req.EntryClosesAt) {
+		return nil, fmt.Errorf("%w: entry_opens_at must be before entry_closes_at", ErrInvalidTournament)
+	}
+	if req.EndAt.Before(req.EntryClosesAt) {
+		return nil, fmt.Errorf("%w: end_at must not be before entry_closes_at", ErrInvalidTournament)
+	}
+	if !req.EndAt.After(now) {
+		return nil, fmt.Errorf("%w: end_at must be in the future", ErrInvalidTournament)
+	}
+	if req.Spot != "" {
+		if _, err := ts.poolService.resolveSpot(req.Spot); err != nil {
+			return nil, err
+		}
+	}
+
+	tournament := &model.Tournament{
+		ID:            uuid.New().String(),
+		Name:          req.Name,
+		Description:   req.Description,
+		Spot:          req.Spot,
+		EntryOpensAt:  opensAt,
+		EntryClosesAt: req.EntryClosesAt,
+		EndAt:         req.EndAt,
+		MaxPlayers:    req.MaxPlayers,
+		BracketSize:   req.BracketSize,
+		PrizeTable:    req.PrizeTable,
+		CreatedAt:     now,
+	}
+	if req.PrizeTable != "" {
+		table, exists := ts.prizeTables[req.PrizeTable]
+		if !exists {
+			return nil, fmt.Errorf("%w: unknown prize table %s", ErrInvalidTournament, req.PrizeTable)
+		}
+		// 复制奖励，之后修改奖励表不影响已创建的比赛
+		tournament.Prizes = table.Prizes
+	}
+
+	data, err := json.Marshal(tournament)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal tournament: %w", err)
+	}
+
+	pipe := ts.redisClient.TxPipeline()
+	pipe.HSet(ctx, tournamentKey(tournament.ID),
+		"data", data,
+		"status", model.TournamentStatusRegistration,
+		"spot", tournament.Spot,
+		"end_at", tournament.EndAt.UnixMilli(),
+	)
+	pipe.ZAdd(ctx, TournamentsKey, redis.Z{Score: float64(now.UnixMilli()), Member: tournament.ID})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to save tournament: %w", err)
+	}
+
+	tournament.Status = model.TournamentStatusRegistration
+	return tournament, nil
+}
+
+// GetTournament 获取比赛
+func (ts *TournamentService) GetTournament(ctx context.Context, tournamentID string) (*model.Tournament, error) {
+	pipe := ts.redisClient.Pipeline()
+	fields := pipe.HGetAll(ctx, tournamentKey(tournamentID))
+	players := pipe.ZCard(ctx, tournamentPlayersKey(tournamentID))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get tournament: %w", err)
+	}
+
+	return parseTournament(tournamentID, fields.Val(), players.Val())
+}
+
+// parseTournament 由比赛hash还原比赛
+func parseTournament(tournamentID string, fields map[string]string, players int64) (*model.Tournament, error) {
+	data, exists := fields["data"]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrTournamentNotFound, tournamentID)
+	}
+
+	var tournament model.Tournament
+	if err := json.Unmarshal([]byte(data), &tournament); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal tournament: %w", err)
+	}
+	tournament.Status = fields["status"]
+	tournament.PlayerCount = int(players)
+	tournament.BracketCount, _ = strconv.Atoi(fields["bracket_count"])
+	if millis, err := strconv.ParseInt(fields["started_at"], 10, 64); err == nil {
+		startedAt := time.UnixMilli(millis)
+		tournament.StartedAt = &startedAt
+	}
+	if millis, err := strconv.ParseInt(fields["ended_at"], 10, 64); err == nil {
+		endedAt := time.UnixMilli(millis)
+		tournament.EndedAt = &endedAt
+	}
+	return &tournament, nil
+}
+
+// ListTournaments 获取最近创建的比赛，可按状态筛选
+func (ts *TournamentService) ListTournaments(ctx context.Context, status string, limit int) (*model.TournamentListResponse, error) {
+	switch status {
+	case "", model.TournamentStatusRegistration, model.TournamentStatusRunning,
+		model.TournamentStatusSettled, model.TournamentStatusCancelled:
+	default:
+		return nil, fmt.Errorf("%w: unknown status %s", ErrInvalidTournament, status)
+	}
+
+	ids, err := ts.redisClient.ZRevRange(ctx, TournamentsKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tournaments: %w", err)
+	}
+
+	pipe := ts.redisClient.Pipeline()
+	fields := make([]*redis.MapStringStringCmd, len(ids))
+	players := make([]*redis.IntCmd, len(ids))
+	for i, id := range ids {
+		fields[i] = pipe.HGetAll(ctx, tournamentKey(id))
+		players[i] = pipe.ZCard(ctx, tournamentPlayersKey(id))
+	}
+	if len(ids) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, fmt.Errorf("failed to get tournaments: %w", err)
+		}
+	}
+
+	response := &model.TournamentListResponse{Tournaments: make([]*model.Tournament, 0)}
+	for i, id := range ids {
+		if len(response.Tournaments) >= limit {
+			break
+		}
+		if status != "" && fields[i].Val()["status"] != status {
+			continue
+		}
+		tournament, err := parseTournament(id, fields[i].Val(), players[i].Val())
+		if err != nil {
+			if errors.Is(err, ErrTournamentNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		response.Tournaments = append(response.Tournaments, tournament)
+	}
+
+	return response, nil
+}
+
+// Register 报名比赛
+func (ts *TournamentService) Register(ctx context.Context, tournamentID string, req *model.TournamentEntryRequest) (*model.Tournament, error) {
+	tournament, err := ts.GetTournament(ctx, tournamentID)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := tournamentEntryScript.Run(ctx, ts.redisClient,
+		[]string{tournamentKey(tournamentID), tournamentPlayersKey(tournamentID), tournamentUserKey(req.UserID)},
+		req.UserID, time.Now().UnixMilli(), tournamentID,
+		tournament.EntryOpensAt.UnixMilli(), tournament.EntryClosesAt.UnixMilli(), tournament.MaxPlayers,
+	).Int()
+	if err != nil {
+		return nil, tournamentScriptError(err)
+	}
+
+	tournament.PlayerCount = count
+	return tournament, nil
+}
+
+// Start 开赛：关闭报名、分组，之后的渔获开始计入比赛榜单
+func (ts *TournamentService) Start(ctx context.Context, tournamentID string) (*model.Tournament, error) {
+	tournament, err := ts.GetTournament(ctx, tournamentID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if !now.Before(tournament.EndAt) {
+		return nil, fmt.Errorf("%w: tournament has already ended", ErrTournamentState)
+	}
+
+	// 开赛前仍可报名，报名人数在读取后变化时按新的人数重新分组
+	for attempt := 0; ; attempt++ {
+		err = ts.start(ctx, tournament, now)
+		if !errors.Is(err, errTournamentPlayersChanged) || attempt+1 >= drawMaxRetries {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ts.GetTournament(ctx, tournamentID)
+}
+
+// errTournamentPlayersChanged 开赛时报名人数与读取时不一致
+var errTournamentPlayersChanged = errors.New("tournament players changed")
+
+// start 按当前报名人数计算分组数并执行开赛脚本
+func (ts *TournamentService) start(ctx context.Context, tournament *model.Tournament, now time.Time) error {
+	players, err := ts.redisClient.ZCard(ctx, tournamentPlayersKey(tournament.ID)).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get tournament players: %w", err)
+	}
+	if players == 0 {
+		return fmt.Errorf("%w: no registered players", ErrTournamentState)
+	}
+
+	count := 1
+	if tournament.BracketSize > 0 {
+		count = int((players + int64(tournament.BracketSize) - 1) / int64(tournament.BracketSize))
+	}
+	keys := []string{tournamentKey(tournament.ID), tournamentPlayersKey(tournament.ID), tournamentBracketsKey(tournament.ID)}
+	for bracket := 1; bracket <= count; bracket++ {
+		keys = append(keys, tournamentBoardKey(tournament.ID, bracket))
+	}
+
+	if err := tournamentStartScript.Run(ctx, ts.redisClient, keys, now.UnixMilli(), players).Err(); err != nil {
+		if strings.Contains(err.Error(), "players changed") {
+			return errTournamentPlayersChanged
+		}
+		return tournamentScriptError(err)
+	}
+	return nil
+}
+
+// Cancel 取消报名中或进行中的比赛，不发放奖励
+func (ts *TournamentService) Cancel(ctx context.Context, tournamentID string) (*model.Tournament, error) {
+	if _, err := ts.GetTournament(ctx, tournamentID); err != nil {
+		return nil, err
+	}
+	if err := ts.close(ctx, tournamentID, model.TournamentStatusCancelled); err != nil {
+		return nil, err
+	}
+	ts.releasePlayers(ctx, tournamentID)
+
+	return ts.GetTournament(ctx, tournamentID)
+}
+
+// Settle 结算进行中的比赛：冻结榜单并按名次发奖。可在结束时间前提前结算。
+// 对已结算的比赛再次调用会补发之前失败的奖励，已发放的不会重复发放
+func (ts *TournamentService) Settle(ctx context.Context, tournamentID string) (*model.TournamentLeaderboardResponse, error) {
+	tournament, err := ts.GetTournament(ctx, tournamentID)
+	if err != nil {
+		return nil, err
+	}
+	if tournament.Status != model.TournamentStatusSettled {
+		if err := ts.close(ctx, tournamentID, model.TournamentStatusSettled); err != nil {
+			return nil, err
+		}
+	}
+
+	response, err := ts.GetLeaderboard(ctx, tournamentID, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, bracket := range response.Brackets {
+		for _, standing := range bracket.Standings {
+			if standing.Prize == nil {
+				continue
+			}
+			if err := ts.payPrize(ctx, response.Tournament, standing); err != nil {
+				return nil, err
+			}
+		}
+	}
+	ts.releasePlayers(ctx, tournamentID)
+
+	return response, nil
+}
+
+// close 将比赛置为结算或取消状态
+func (ts *TournamentService) close(ctx context.Context, tournamentID, status string) error {
+	err := tournamentCloseScript.Run(ctx, ts.redisClient, []string{tournamentKey(tournamentID)},
+		status, time.Now().UnixMilli(),
+	).Err()
+	if err != nil {
+		return tournamentScriptError(err)
+	}
+	return nil
+}
+
+// payPrize 发放一名玩家的奖励：金币、积分与账本记录原子写入
+func (ts *TournamentService) payPrize(ctx context.Context, tournament *model.Tournament, standing *model.TournamentStanding) error {
+	prize := standing.Prize
+	entry := newLedgerEntry(standing.UserID, model.LedgerTypeTournamentPrize, "", time.Now())
+	entry.Coins = prize.Coins
+	entry.Points = prize.Points
+	entry.Ref = tournament.ID
+
+	entryJSON, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal ledger entry: %w", err)
+	}
+
+	result, err := tournamentPrizeScript.Run(ctx, ts.redisClient,
+		[]string{tournamentPaidKey(tournament.ID), walletKey(standing.UserID), ledgerKey(standing.UserID), GlobalRankingKey},
+		standing.UserID, prize.Coins, entryJSON, prize.Points, rankingMember(standing.UserID),
+	).Int64Slice()
+	if err != nil {
+		return fmt.Errorf("failed to pay tournament prize: %w", err)
+	}
+	if len(result) != 2 {
+		return fmt.Errorf("unexpected tournament prize result")
+	}
+	if result[0] == 1 && prize.Points > 0 {
+		ts.rankingService.notifyScoreListeners(ctx, standing.UserID, int(result[1]))
+	}
+	return nil
+}
+
+// releasePlayers 比赛结束后从玩家的进行中比赛列表移除，失败只影响抽奖时多一次无效检查
+func (ts *TournamentService) releasePlayers(ctx context.Context, tournamentID string) {
+	players, err := ts.redisClient.ZRange(ctx, tournamentPlayersKey(tournamentID), 0, -1).Result()
+	if err != nil {
+		log.Printf("Failed to get players of tournament %s: %v", tournamentID, err)
+		return
+	}
+
+	pipe := ts.redisClient.Pipeline()
+	for _, userID := range players {
+		pipe.SRem(ctx, tournamentUserKey(userID), tournamentID)
+	}
+	if len(players) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil {
+			log.Printf("Failed to release players of tournament %s: %v", tournamentID, err)
+		}
+	}
+}
+
+// GetLeaderboard 获取比赛各分组的排名，limit为每组返回的人数（0为全部）。
+// 未开赛时没有榜单；取消的比赛保留取消时的排名但不显示奖励
+func (ts *TournamentService) GetLeaderboard(ctx context.Context, tournamentID string, limit int) (*model.TournamentLeaderboardResponse, error) {
+	tournament, err := ts.GetTournament(ctx, tournamentID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &model.TournamentLeaderboardResponse{
+		Tournament: tournament,
+		Brackets:   make([]*model.TournamentBracket, 0, tournament.BracketCount),
+	}
+	if tournament.BracketCount == 0 {
+		return response, nil
+	}
+
+	pipe := ts.redisClient.Pipeline()
+	boards := make([]*redis.ZSliceCmd, tournament.BracketCount)
+	for i := range boards {
+		boards[i] = pipe.ZRevRangeWithScores(ctx, tournamentBoardKey(tournamentID, i+1), 0, int64(limit)-1)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get tournament leaderboard: %w", err)
+	}
+
+	showPrizes := tournament.Status != model.TournamentStatusCancelled
+	for i, board := range boards {
+		bracket := &model.TournamentBracket{
+			Bracket:   i + 1,
+			Standings: make([]*model.TournamentStanding, 0, len(board.Val())),
+		}
+		for j, z := range board.Val() {
+			standing := &model.TournamentStanding{
+				Rank:   j + 1,
+				UserID: z.Member.(string),
+				Score:  int(z.Score),
+			}
+			if showPrizes {
+				standing.Prize = prizeFor(tournament.Prizes, standing.Rank)
+			}
+			bracket.Standings = append(bracket.Standings, standing)
+		}
+		response.Brackets = append(response.Brackets, bracket)
+	}
+
+	return response, nil
+}
+
+// plan 读取用户参加的比赛，返回抽奖提交时需要校验和计分的快照（按比赛ID排序），不做任何写入。
+// 比赛状态在提交前被修改（开赛、结算、取消、报名或移出）时提交脚本返回冲突并重新读取
+func (ts *TournamentService) plan(ctx context.Context, userID, spot string, now time.Time) ([]*tournamentSnapshot, error) {
+	tournamentIDs, err := ts.redisClient.SMembers(ctx, tournamentUserKey(userID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user tournaments: %w", err)
+	}
+	snapshots := make([]*tournamentSnapshot, 0, len(tournamentIDs))
+	if len(tournamentIDs) == 0 {
+		return snapshots, nil
+	}
+	sort.Strings(tournamentIDs)
+
+	pipe := ts.redisClient.Pipeline()
+	fields := make([]*redis.SliceCmd, len(tournamentIDs))
+	brackets := make([]*redis.StringCmd, len(tournamentIDs))
+	for i, tournamentID := range tournamentIDs {
+		fields[i] = pipe.HMGet(ctx, tournamentKey(tournamentID), "status", "spot", "started_at", "end_at")
+		brackets[i] = pipe.HGet(ctx, tournamentBracketsKey(tournamentID), userID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get user tournaments: %w", err)
+	}
+
+	at := now.UnixMilli()
+	for i, tournamentID := range tournamentIDs {
+		values := fields[i].Val()
+		status, _ := values[0].(string)
+		tournamentSpot, _ := values[1].(string)
+		startedAt, _ := values[2].(string)
+		endAt, _ := values[3].(string)
+		snapshot := &tournamentSnapshot{ID: tournamentID, Status: status}
+
+		started, startErr := strconv.ParseInt(startedAt, 10, 64)
+		end, endErr := strconv.ParseInt(endAt, 10, 64)
+		bracket, bracketErr := strconv.Atoi(brackets[i].Val())
+		if status == model.TournamentStatusRunning && (tournamentSpot == "" || tournamentSpot == spot) &&
+			startErr == nil && endErr == nil && at >= started && at < end && bracketErr == nil {
+			snapshot.Score = true
+			snapshot.board = tournamentBoardKey(tournamentID, bracket)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return snapshots, nil
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"fishing-game/model"
+)
+
+// newTestTournament 创建比赛并按顺序报名，抽奖服务的渔获计入该比赛
+func newTestTournament(t *testing.T, ls *LotteryService, ctx context.Context, req *model.CreateTournamentRequest, players ...string) (*TournamentService, *model.Tournament) {
+	t.Helper()
+	ts, err := NewTournamentService(ls.poolService, ls.rankingService)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ls.tournamentService = ts
+
+	now := time.Now()
+	req.Name = "测试比赛"
+	req.EntryClosesAt = now.Add(time.Hour)
+	req.EndAt = now.Add(2 * time.Hour)
+	tournament, err := ts.CreateTournament(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, userID := range players {
+		if _, err := ts.Register(ctx, tournament.ID, &model.TournamentEntryRequest{UserID: userID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ts, tournament
+}
+
+func TestTournamentStartAssignsBrackets(t *testing.T) {
+	ls, ctx := newTestLotteryService(t)
+	ts, tournament := newTestTournament(t, ls, ctx, &model.CreateTournamentRequest{BracketSize: 2}, "p1", "p2", "p3", "p4", "p5")
+
+	started, err := ts.Start(ctx, tournament.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if started.Status != model.TournamentStatusRunning || started.BracketCount != 3 {
+		t.Fatalf("started tournament status %s with %d brackets, expected running with 3", started.Status, started.BracketCount)
+	}
+
+	// 按报名顺序连续、均匀分组
+	want := map[string]string{"p1": "1", "p2": "1", "p3": "2", "p4": "2", "p5": "3"}
+	brackets, err := ls.redisClient.HGetAll(ctx, tournamentBracketsKey(tournament.ID)).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for userID, bracket := range want {
+		if brackets[userID] != bracket {
+			t.Errorf("%s in bracket %s, expected %s", userID, brackets[userID], bracket)
+		}
+		if _, err := ls.redisClient.ZScore(ctx, tournamentBoardKey(tournament.ID, int(bracket[0]-'0')), userID).Result(); err != nil {
+			t.Errorf("%s not on bracket %s board: %v", userID, bracket, err)
+		}
+	}
+
+	if _, err := ts.Start(ctx, tournament.ID); err == nil {
+		t.Error("expected starting a running tournament to fail")
+	}
+}
+
+func TestTournamentRegisterGuards(t *testing.T) {
+	ls, ctx := newTestLotteryService(t)
+	ts, tournament := newTestTournament(t, ls, ctx, &model.CreateTournamentRequest{MaxPlayers: 2}, "p1")
+
+	if _, err := ts.Register(ctx, tournament.ID, &model.TournamentEntryRequest{UserID: "p1"}); !errors.Is(err, ErrAlreadyRegistered) {
+		t.Errorf("expected ErrAlreadyRegistered, got %v", err)
+	}
+	registered, err := ts.Register(ctx, tournament.ID, &model.TournamentEntryRequest{UserID: "p2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if registered.PlayerCount != 2 {
+		t.Errorf("player count %d, expected 2", registered.PlayerCount)
+	}
+	if _, err := ts.Register(ctx, tournament.ID, &model.TournamentEntryRequest{UserID: "p3"}); !errors.Is(err, ErrTournamentFull) {
+		t.Errorf("expected ErrTournamentFull, got %v", err)
+	}
+
+	// 开赛后报名关闭
+	if _, err := ts.Start(ctx, tournament.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ts.Register(ctx, tournament.ID, &model.TournamentEntryRequest{UserID: "p4"}); !errors.Is(err, ErrTournamentEntryClosed) {
+		t.Errorf("expected ErrTournamentEntryClosed, got %v", err)
+	}
+	if n, _ := ls.redisClient.ZCard(ctx, tournamentPlayersKey(tournament.ID)).Result(); n != 2 {
+		t.Errorf("%d players registered, expected 2", n)
+	}
+	for _, userID := range []string{"p3", "p4"} {
+		if n, _ := ls.redisClient.SCard(ctx, tournamentUserKey(userID)).Result(); n != 0 {
+			t.Errorf("rejected player %s listed in tournament", userID)
+		}
+	}
+}
+
+func TestTournamentStartRetriesWhenPlayersChange(t *testing.T) {
+	ls, ctx := newTestLotteryService(t)
+	ts, tournament := newTestTournament(t, ls, ctx, &model.CreateTournamentRequest{BracketSize: 2}, "p1", "p2")
+
+	if err := tournamentStartScript.Load(ctx, ls.redisClient).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 读取报名人数后又有玩家报名，第一次开赛脚本不做任何写入
+	hook := &faultHook{match: scriptCall(tournamentStartScript)}
+	hook.onMatch = func() {
+		if hook.hits == 1 {
+			if _, err := ts.Register(ctx, tournament.ID, &model.TournamentEntryRequest{UserID: "p3"}); err != nil {
+				t.Error(err)
+			}
+		}
+	}
+	ls.redisClient.AddHook(hook)
+
+	started, err := ts.Start(ctx, tournament.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hook.hits != 2 {
+		t.Errorf("expected 2 start attempts, got %d", hook.hits)
+	}
+	if started.BracketCount != 2 || started.PlayerCount != 3 {
+		t.Errorf("started with %d players in %d brackets, expected 3 in 2", started.PlayerCount, started.BracketCount)
+	}
+	if bracket, _ := ls.redisClient.HGet(ctx, tournamentBracketsKey(tournament.ID), "p3").Result(); bracket != "2" {
+		t.Errorf("late player in bracket %q, expected 2", bracket)
+	}
+}
+
+func TestDrawScoresTournamentWithCommit(t *testing.T) {
+	ls, ctx := newTestLotteryService(t)
+	ts, tournament := newTestTournament(t, ls, ctx, &model.CreateTournamentRequest{}, testUserID)
+	if _, err := ts.Start(ctx, tournament.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// 提交后进程退出：不再有提交后的监听器，积分已随抽奖写入
+	hook := &faultHook{match: scriptCall(drawScript), fail: true}
+	ls.redisClient.AddHook(hook)
+	if _, err := ls.BatchDraw(ctx, &model.LotteryBatchDrawRequest{UserID: testUserID, Count: 10}); err == nil {
+		t.Fatal("expected injected error")
+	}
+
+	history, err := ls.redisClient.ZRange(ctx, drawHistoryKey(testUserID), 0, -1).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	points := 0
+	for _, drawID := range history {
+		record, err := ls.GetDraw(ctx, drawID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		points += record.Points
+	}
+	score, err := ls.redisClient.ZScore(ctx, tournamentBoardKey(tournament.ID, 1), testUserID).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 10 || int(score) != points {
+		t.Errorf("tournament score %v for %d committed draws, expected %d", score, len(history), points)
+	}
+}
+
+func TestDrawRetriesWhenTournamentClosesBeforeCommit(t *testing.T) {
+	ls, ctx := newTestLotteryService(t)
+	ts, tournament := newTestTournament(t, ls, ctx, &model.CreateTournamentRequest{}, testUserID)
+	if _, err := ts.Start(ctx, tournament.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// 选鱼后、提交前比赛被结算，已冻结的榜单不再计分
+	hook := &faultHook{match: scriptCall(drawScript)}
+	hook.onMatch = func() {
+		if hook.hits == 1 {
+			if _, err := ts.Settle(ctx, tournament.ID); err != nil {
+				t.Error(err)
+			}
+		}
+	}
+	ls.redisClient.AddHook(hook)
+
+	if _, err := ls.BatchDraw(ctx, &model.LotteryBatchDrawRequest{UserID: testUserID, Count: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if hook.hits != 2 {
+		t.Errorf("expected 2 commit attempts, got %d", hook.hits)
+	}
+	if score, _ := ls.redisClient.ZScore(ctx, tournamentBoardKey(tournament.ID, 1), testUserID).Result(); score != 0 {
+		t.Errorf("settled tournament scored %v", score)
+	}
+}
+
+func TestSettlePaysPrizesOnce(t *testing.T) {
+	ls, ctx := newTestLotteryService(t)
+	ts, tournament := newTestTournament(t, ls, ctx, &model.CreateTournamentRequest{PrizeTable: "standard"}, "p1", "p2")
+	if _, err := ts.Start(ctx, tournament.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := ls.redisClient.ZIncrBy(ctx, tournamentBoardKey(tournament.ID, 1), 100, "p2").Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := ts.Settle(ctx, tournament.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for userID, want := range map[string]int64{"p2": 1000, "p1": 500} {
+		coins, err := ls.redisClient.HGet(ctx, walletKey(userID), "coins").Int64()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if coins != want {
+			t.Errorf("%s coins %d, expected %d", userID, coins, want)
+		}
+		if n, _ := ls.redisClient.LLen(ctx, ledgerKey(userID)).Result(); n != 1 {
+			t.Errorf("%s has %d ledger entries, expected 1", userID, n)
+		}
+		paid, _ := ls.redisClient.HGet(ctx, tournamentPaidKey(tournament.ID), userID).Result()
+		if paid != fmt.Sprint(want) {
+			t.Errorf("%s paid %q, expected %d", userID, paid, want)
+		}
+	}
+	if score, _ := ls.redisClient.ZScore(ctx, GlobalRankingKey, rankingMember("p2")).Result(); score != 500 {
+		t.Errorf("p2 global score %v, expected 500", score)
+	}
+	if n, _ := ls.redisClient.SCard(ctx, tournamentUserKey("p1")).Result(); n != 0 {
+		t.Errorf("settled tournament still listed for p1")
+	}
+}
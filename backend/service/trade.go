@@ -0,0 +1,621 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fishing-game/config"
+	"fishing-game/model"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// 交易默认配置
+	DefaultTradeOfferTTL      = 24 * time.Hour
+	DefaultTradeMaxOpenOffers = 5  // 每个用户同时挂起的报价上限
+	DefaultTradeDailyLimit    = 20 // 每个用户每日发起交易的次数上限
+	MaxTradeItemKinds         = 10 // 每一方最多包含的鱼类数量
+
+	// TradeExpiryKey 待处理报价的过期索引（ZSET，score为过期时间毫秒，member为交易ID）
+	TradeExpiryKey = "trade:expiry"
+
+	// tradeExpireBatch 每次清理过期报价的最大数量
+	tradeExpireBatch = 50
+
+	// 幂等作用域
+	IdempotencyScopeTradeCreate = "trade_create"
+)
+
+var (
+	// ErrInvalidTrade 交易内容不合法
+	ErrInvalidTrade = errors.New("invalid trade")
+	// ErrTradeNotFound 交易不存在
+	ErrTradeNotFound = errors.New("trade not found")
+	// ErrTradeForbidden 用户无权操作该交易
+	ErrTradeForbidden = errors.New("user is not allowed to act on this trade")
+	// ErrTradeNotPending 交易已结束
+	ErrTradeNotPending = errors.New("trade is no longer pending")
+	// ErrTradeExpired 交易已过期
+	ErrTradeExpired = errors.New("trade has expired")
+	// ErrTradeLimit 超出交易次数或挂起报价上限
+	ErrTradeLimit = errors.New("trade limit reached")
+)
+
+// TradeConfig 交易配置
+type TradeConfig struct {
+	OfferTTL      time.Duration
+	MaxOpenOffers int
+	DailyLimit    int
+}
+
+// LoadTradeConfig 从环境变量加载交易配置
+func LoadTradeConfig() TradeConfig {
+	return TradeConfig{
+		OfferTTL:      config.GetEnvDuration("TRADE_OFFER_TTL", DefaultTradeOfferTTL),
+		MaxOpenOffers: config.GetEnvInt("TRADE_MAX_OPEN_OFFERS", DefaultTradeMaxOpenOffers),
+		DailyLimit:    config.GetEnvInt("TRADE_DAILY_LIMIT", DefaultTradeDailyLimit),
+	}
+}
+
+// tradeScriptFunctions 交易脚本共用的Lua函数
+const tradeScriptFunctions = `
+local function hasItems(inventory, items)
+	for _, item in ipairs(items) do
+		local count = tonumber(redis.call('HGET', inventory, 'count:' .. item['item_id']) or '0')
+		if count < item['quantity'] then
+			return false
+		end
+	end
+	return true
+end
+
+local function moveItems(inventory, items, sign)
+	for _, item in ipairs(items) do
+		redis.call('HINCRBY', inventory, 'count:' .. item['item_id'], sign * item['quantity'])
+	end
+end
+
+local function coins(wallet)
+	return tonumber(redis.call('HGET', wallet, 'coins') or '0')
+end
+
+local function credit(wallet, ledger, delta, entryJSON)
+	local balance = redis.call('HINCRBY', wallet, 'coins', delta)
+	local entry = cjson.decode(entryJSON)
+	entry['coins'] = delta
+	entry['balance'] = balance
+	redis.call('RPUSH', ledger, cjson.encode(entry))
+end
+`
+
+// tradeCreateScript 发起交易：检查上限和余额后托管发起方的鱼和金币
+//
+// KEYS[1] 发起方背包 KEYS[2] 发起方钱包 KEYS[3] 发起方账本 KEYS[4] 交易
+// KEYS[5] 发起方交易记录 KEYS[6] 对方交易记录 KEYS[7] 过期索引 KEYS[8] 发起方挂起报价 KEYS[9] 发起方当日交易次数
+// ARGV[1] 交易JSON ARGV[2] 交易ID ARGV[3] 发起时间（毫秒） ARGV[4] 过期时间（毫秒）
+// ARGV[5] 托管账本记录JSON ARGV[6] 挂起报价上限 ARGV[7] 每日交易上限 ARGV[8] 当日计数过期时间（毫秒）
+var tradeCreateScript = redis.NewScript(tradeScriptFunctions + `
+if redis.call('SCARD', KEYS[8]) >= tonumber(ARGV[6]) then
+	return redis.error_reply('trade limit: too many open offers')
+end
+if tonumber(redis.call('GET', KEYS[9]) or '0') >= tonumber(ARGV[7]) then
+	return redis.error_reply('trade limit: daily limit reached')
+end
+
+local trade = cjson.decode(ARGV[1])
+if not hasItems(KEYS[1], trade['offer_items']) then
+	return redis.error_reply('insufficient items')
+end
+local offerCoins = tonumber(trade['offer_coins'])
+if coins(KEYS[2]) < offerCoins then
+	return redis.error_reply('insufficient coins')
+end
+
+moveItems(KEYS[1], trade['offer_items'], -1)
+if offerCoins > 0 then
+	credit(KEYS[2], KEYS[3], -offerCoins, ARGV[5])
+end
+
+redis.call('HSET', KEYS[4], 'data', ARGV[1], 'status', 'pending')
+redis.call('ZADD', KEYS[5], ARGV[3], ARGV[2])
+redis.call('ZADD', KEYS[6], ARGV[3], ARGV[2])
+redis.call('ZADD', KEYS[7], ARGV[4], ARGV[2])
+redis.call('SADD', KEYS[8], ARGV[2])
+redis.call('INCR', KEYS[9])
+redis.call('PEXPIRE', KEYS[9], ARGV[8])
+return 'OK'
+`)
+
+// tradeAcceptScript 接受交易：原子交换双方的鱼和金币
+//
+// KEYS[1] 交易 KEYS[2] 发起方背包 KEYS[3] 发起方钱包 KEYS[4] 发起方账本
+// KEYS[5] 对方背包 KEYS[6] 对方钱包 KEYS[7] 对方账本 KEYS[8] 过期索引 KEYS[9] 发起方挂起报价
+// ARGV[1] 当前时间（毫秒） ARGV[2] 结算时间 ARGV[3] 发起方账本记录JSON ARGV[4] 对方账本记录JSON ARGV[5] 交易ID
+var tradeAcceptScript = redis.NewScript(tradeScriptFunctions + `
+local data = redis.call('HGET', KEYS[1], 'data')
+if not data then
+	return redis.error_reply('trade not found')
+end
+if redis.call('HGET', KEYS[1], 'status') ~= 'pending' then
+	return redis.error_reply('trade not pending')
+end
+local trade = cjson.decode(data)
+if tonumber(redis.call('ZSCORE', KEYS[8], ARGV[5]) or '0') <= tonumber(ARGV[1]) then
+	return redis.error_reply('trade expired')
+end
+
+if not hasItems(KEYS[5], trade['request_items']) then
+	return redis.error_reply('insufficient items')
+end
+local offerCoins = tonumber(trade['offer_coins'])
+local requestCoins = tonumber(trade['request_coins'])
+if coins(KEYS[6]) < requestCoins then
+	return redis.error_reply('insufficient coins')
+end
+
+-- 对方的鱼转给发起方，托管的鱼转给对方
+moveItems(KEYS[5], trade['request_items'], -1)
+moveItems(KEYS[2], trade['request_items'], 1)
+moveItems(KEYS[5], trade['offer_items'], 1)
+
+credit(KEYS[3], KEYS[4], requestCoins, ARGV[3])
+credit(KEYS[6], KEYS[7], offerCoins - requestCoins, ARGV[4])
+
+redis.call('HSET', KEYS[1], 'status', 'accepted', 'resolved_at', ARGV[2])
+redis.call('ZREM', KEYS[8], ARGV[5])
+redis.call('SREM', KEYS[9], ARGV[5])
+return 'OK'
+`)
+
+// tradeRefundScript 拒绝、取消或过期：退还托管的鱼和金币
+//
+// KEYS[1] 交易 KEYS[2] 发起方背包 KEYS[3] 发起方钱包 KEYS[4] 发起方账本 KEYS[5] 过期索引 KEYS[6] 发起方挂起报价
+// ARGV[1] 新状态 ARGV[2] 结算时间 ARGV[3] 退还账本记录JSON ARGV[4] 交易ID
+var tradeRefundScript = redis.NewScript(tradeScriptFunctions + `
+local data = redis.call('HGET', KEYS[1], 'data')
+if not data then
+	redis.call('ZREM', KEYS[5], ARGV[4])
+	return redis.error_reply('trade not found')
+end
+if redis.call('HGET', KEYS[1], 'status') ~= 'pending' then
+	redis.call('ZREM', KEYS[5], ARGV[4])
+	return redis.error_reply('trade not pending')
+end
+local trade = cjson.decode(data)
+
+moveItems(KEYS[2], trade['offer_items'], 1)
+local offerCoins = tonumber(trade['offer_coins'])
+if offerCoins > 0 then
+	credit(KEYS[3], KEYS[4], offerCoins, ARGV[3])
+end
+
+redis.call('HSET', KEYS[1], 'status', ARGV[1], 'resolved_at', ARGV[2])
+redis.call('ZREM', KEYS[5], ARGV[4])
+redis.call('SREM', KEYS[6], ARGV[4])
+return 'OK'
+`)
+
+// TradeService 玩家间交易服务：发起方的鱼和金币在报价期间托管，成交时在一个脚本中原子交换
+type TradeService struct {
+	redisClient        *redis.Client
+	poolService        *PoolService
+	idempotencyService *IdempotencyService
+	config             TradeConfig
+	location           *time.Location
+}
+
+// NewTradeService 创建交易服务
+func NewTradeService(poolService *PoolService, idempotencyService *IdempotencyService) *TradeService {
+	return &TradeService{
+		redisClient:        config.GetRedisClient(),
+		poolService:        poolService,
+		idempotencyService: idempotencyService,
+		config:             LoadTradeConfig(),
+		location:           config.GetEnvLocation("GAME_TIMEZONE", DefaultGameTimezone),
+	}
+}
+
+// tradeKey 交易key（HASH：data 为不可变的交易JSON，status / resolved_at 为状态）
+func tradeKey(tradeID string) string {
+	return fmt.Sprintf("trade:%s", tradeID)
+}
+
+// userTradesKey 用户参与的交易（ZSET，score为发起时间毫秒）
+func userTradesKey(userID string) string {
+	return fmt.Sprintf("trade:user:%s", userID)
+}
+
+// openOffersKey 用户挂起的报价
+func openOffersKey(userID string) string {
+	return fmt.Sprintf("trade:open:%s", userID)
+}
+
+// dailyTradesKey 用户当日发起交易次数
+func dailyTradesKey(userID, day string) string {
+	return fmt.Sprintf("trade:daily:%s:%s", userID, day)
+}
+
+// tradeScriptError 将脚本返回的业务错误转换为对应的错误类型
+func tradeScriptError(err error) error {
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "trade limit"):
+		return fmt.Errorf("%w: %s", ErrTradeLimit, strings.TrimPrefix(message, "trade limit: "))
+	case strings.Contains(message, "trade not found"):
+		return ErrTradeNotFound
+	case strings.Contains(message, "trade not pending"):
+		return ErrTradeNotPending
+	case strings.Contains(message, "trade expired"):
+		return ErrTradeExpired
+	case strings.Contains(message, "insufficient coins"):
+		return ErrInsufficientCoins
+	case strings.Contains(message, "insufficient items"):
+		return ErrInsufficientItems
+	}
+	return err
+}
+
+// validateTradeItems 校验并合并同一鱼类，只能交易奖池中的非空军鱼类
+func validateTradeItems(poolItems map[string]*model.LotteryItem, items []model.TradeItem) ([]model.TradeItem, error) {
+	merged := make([]model.TradeItem, 0, len(items))
+	index := make(map[string]int, len(items))
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: quantity must be positive", ErrInvalidTrade)
+		}
+		if _, exists := poolItems[item.ItemID]; !exists || item.ItemID == EmptyFishID {
+			return nil, fmt.Errorf("%w: item %s is not tradable", ErrInvalidTrade, item.ItemID)
+		}
+		if i, exists := index[item.ItemID]; exists {
+			merged[i].Quantity += item.Quantity
+			continue
+		}
+		index[item.ItemID] = len(merged)
+		merged = append(merged, item)
+	}
+	if len(merged) > MaxTradeItemKinds {
+		return nil, fmt.Errorf("%w: at most %d kinds of fish per side", ErrInvalidTrade, MaxTradeItemKinds)
+	}
+	return merged, nil
+}
+
+// CreateOffer 发起交易（携带trace_id时幂等）
+func (ts *TradeService) CreateOffer(ctx context.Context, req *model.CreateTradeRequest) (*model.TradeOffer, error) {
+	return withIdempotency(ctx, ts.idempotencyService, IdempotencyScopeTradeCreate, req.UserID, req.TraceID, req, func() (*model.TradeOffer, error) {
+		return ts.createOffer(ctx, req)
+	})
+}
+
+// createOffer 校验报价并托管发起方的鱼和金币
+func (ts *TradeService) createOffer(ctx context.Context, req *model.CreateTradeRequest) (*model.TradeOffer, error) {
+	if req.UserID == req.ToUserID {
+		return nil, fmt.Errorf("%w: cannot trade with yourself", ErrInvalidTrade)
+	}
+
+	ts.expireDue(ctx)
+
+	poolItems, err := ts.poolService.GetItems(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool: %w", err)
+	}
+	offerItems, err := validateTradeItems(poolItems, req.OfferItems)
+	if err != nil {
+		return nil, err
+	}
+	requestItems, err := validateTradeItems(poolItems, req.RequestItems)
+	if err != nil {
+		return nil, err
+	}
+	if len(offerItems) == 0 && req.OfferCoins == 0 {
+		return nil, fmt.Errorf("%w: offer must include fish or coins", ErrInvalidTrade)
+	}
+	if len(requestItems) == 0 && req.RequestCoins == 0 {
+		return nil, fmt.Errorf("%w: request must include fish or coins", ErrInvalidTrade)
+	}
+
+	now := time.Now()
+	trade := &model.TradeOffer{
+		ID:           uuid.New().String(),
+		FromUserID:   req.UserID,
+		ToUserID:     req.ToUserID,
+		OfferItems:   offerItems,
+		OfferCoins:   req.OfferCoins,
+		RequestItems: requestItems,
+		RequestCoins: req.RequestCoins,
+		Status:       model.TradeStatusPending,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(ts.config.OfferTTL),
+	}
+	tradeJSON, err := json.Marshal(trade)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal trade: %w", err)
+	}
+
+	escrowEntry := newLedgerEntry(req.UserID, model.LedgerTypeTradeEscrow, req.TraceID, now)
+	escrowEntry.Ref = trade.ID
+	escrowJSON, err := json.Marshal(escrowEntry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ledger entry: %w", err)
+	}
+
+	day := now.In(ts.location).Format("20060102")
+	keys := []string{
+		inventoryKey(req.UserID),
+		walletKey(req.UserID),
+		ledgerKey(req.UserID),
+		tradeKey(trade.ID),
+		userTradesKey(req.UserID),
+		userTradesKey(req.ToUserID),
+		TradeExpiryKey,
+		openOffersKey(req.UserID),
+		dailyTradesKey(req.UserID, day),
+	}
+	args := []interface{}{
+		tradeJSON,
+		trade.ID,
+		now.UnixMilli(),
+		trade.ExpiresAt.UnixMilli(),
+		escrowJSON,
+		ts.config.MaxOpenOffers,
+		ts.config.DailyLimit,
+		(48 * time.Hour).Milliseconds(),
+	}
+	if err := tradeCreateScript.Run(ctx, ts.redisClient, keys, args...).Err(); err != nil {
+		return nil, tradeScriptError(err)
+	}
+
+	return trade, nil
+}
+
+// Accept 对方接受交易
+func (ts *TradeService) Accept(ctx context.Context, tradeID, userID string) (*model.TradeOffer, error) {
+	trade, err := ts.GetTrade(ctx, tradeID)
+	if err != nil {
+		return nil, err
+	}
+	if trade.ToUserID != userID {
+		return nil, ErrTradeForbidden
+	}
+
+	now := time.Now()
+	fromEntry := newLedgerEntry(trade.FromUserID, model.LedgerTypeTrade, "", now)
+	fromEntry.Ref = trade.ID
+	toEntry := newLedgerEntry(trade.ToUserID, model.LedgerTypeTrade, "", now)
+	toEntry.Ref = trade.ID
+	fromJSON, err := json.Marshal(fromEntry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ledger entry: %w", err)
+	}
+	toJSON, err := json.Marshal(toEntry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ledger entry: %w", err)
+	}
+
+	keys := []string{
+		tradeKey(trade.ID),
+		inventoryKey(trade.FromUserID),
+		walletKey(trade.FromUserID),
+		ledgerKey(trade.FromUserID),
+		inventoryKey(trade.ToUserID),
+		walletKey(trade.ToUserID),
+		ledgerKey(trade.ToUserID),
+		TradeExpiryKey,
+		openOffersKey(trade.FromUserID),
+	}
+	args := []interface{}{
+		now.UnixMilli(),
+		now.Format(time.RFC3339Nano),
+		fromJSON,
+		toJSON,
+		trade.ID,
+	}
+	if err := tradeAcceptScript.Run(ctx, ts.redisClient, keys, args...).Err(); err != nil {
+		err = tradeScriptError(err)
+		if errors.Is(err, ErrTradeExpired) {
+			if refundErr := ts.refund(ctx, trade, model.TradeStatusExpired, now); refundErr != nil && !errors.Is(refundErr, ErrTradeNotPending) {
+				return nil, refundErr
+			}
+		}
+		return nil, err
+	}
+
+	return ts.GetTrade(ctx, tradeID)
+}
+
+// Reject 对方拒绝交易，退还托管
+func (ts *TradeService) Reject(ctx context.Context, tradeID, userID string) (*model.TradeOffer, error) {
+	return ts.resolve(ctx, tradeID, userID, model.TradeStatusRejected)
+}
+
+// Cancel 发起方取消交易，退还托管
+func (ts *TradeService) Cancel(ctx context.Context, tradeID, userID string) (*model.TradeOffer, error) {
+	return ts.resolve(ctx, tradeID, userID, model.TradeStatusCancelled)
+}
+
+// resolve 拒绝或取消交易
+func (ts *TradeService) resolve(ctx context.Context, tradeID, userID, status string) (*model.TradeOffer, error) {
+	trade, err := ts.GetTrade(ctx, tradeID)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := trade.ToUserID
+	if status == model.TradeStatusCancelled {
+		allowed = trade.FromUserID
+	}
+	if userID != allowed {
+		return nil, ErrTradeForbidden
+	}
+	if trade.Status == model.TradeStatusExpired {
+		return nil, ErrTradeExpired
+	}
+
+	if err := ts.refund(ctx, trade, status, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return ts.GetTrade(ctx, tradeID)
+}
+
+// refund 结束交易并退还发起方托管的鱼和金币
+func (ts *TradeService) refund(ctx context.Context, trade *model.TradeOffer, status string, now time.Time) error {
+	entry := newLedgerEntry(trade.FromUserID, model.LedgerTypeTradeRefund, "", now)
+	entry.Ref = trade.ID
+	entryJSON, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal ledger entry: %w", err)
+	}
+
+	keys := []string{
+		tradeKey(trade.ID),
+		inventoryKey(trade.FromUserID),
+		walletKey(trade.FromUserID),
+		ledgerKey(trade.FromUserID),
+		TradeExpiryKey,
+		openOffersKey(trade.FromUserID),
+	}
+	args := []interface{}{
+		status,
+		now.Format(time.RFC3339Nano),
+		entryJSON,
+		trade.ID,
+	}
+	if err := tradeRefundScript.Run(ctx, ts.redisClient, keys, args...).Err(); err != nil {
+		return tradeScriptError(err)
+	}
+
+	return nil
+}
+
+// expireDue 将已过期的报价标记为过期并退还托管，失败只记录在下次清理时重试
+func (ts *TradeService) expireDue(ctx context.Context) {
+	now := time.Now()
+	tradeIDs, err := ts.redisClient.ZRangeByScore(ctx, TradeExpiryKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(now.UnixMilli(), 10),
+		Count: tradeExpireBatch,
+	}).Result()
+	if err != nil {
+		return
+	}
+
+	for _, tradeID := range tradeIDs {
+		trade, err := ts.loadTrade(ctx, tradeID)
+		if err != nil {
+			if errors.Is(err, ErrTradeNotFound) {
+				ts.redisClient.ZRem(ctx, TradeExpiryKey, tradeID)
+			}
+			continue
+		}
+		ts.refund(ctx, trade, model.TradeStatusExpired, now)
+	}
+}
+
+// loadTrade 读取交易
+func (ts *TradeService) loadTrade(ctx context.Context, tradeID string) (*model.TradeOffer, error) {
+	fields, err := ts.redisClient.HGetAll(ctx, tradeKey(tradeID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trade: %w", err)
+	}
+	if fields["data"] == "" {
+		return nil, ErrTradeNotFound
+	}
+
+	var trade model.TradeOffer
+	if err := json.Unmarshal([]byte(fields["data"]), &trade); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal trade: %w", err)
+	}
+	trade.Status = fields["status"]
+	if resolvedAt, err := time.Parse(time.RFC3339Nano, fields["resolved_at"]); err == nil {
+		trade.ResolvedAt = &resolvedAt
+	}
+
+	return &trade, nil
+}
+
+// GetTrade 获取交易，先处理已到期的报价
+func (ts *TradeService) GetTrade(ctx context.Context, tradeID string) (*model.TradeOffer, error) {
+	ts.expireDue(ctx)
+	return ts.loadTrade(ctx, tradeID)
+}
+
+// GetUserTrades 按发起时间倒序分页获取用户参与的交易，status 为空时返回全部
+func (ts *TradeService) GetUserTrades(ctx context.Context, userID string, limit int, cursor, status string) (*model.TradeHistoryResponse, error) {
+	ts.expireDue(ctx)
+
+	maxScore := "+inf"
+	var position *historyCursor
+	if cursor != "" {
+		c, err := decodeHistoryCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		position = c
+		maxScore = strconv.FormatInt(c.score, 10)
+	}
+
+	response := &model.TradeHistoryResponse{
+		UserID: userID,
+		Trades: make([]*model.TradeOffer, 0, limit),
+	}
+
+	key := userTradesKey(userID)
+	for round := 0; round < historyScanMaxRound; round++ {
+		// Rev+ByScore时go-redis会交换Start和Stop，这里按 min/max 顺序传入
+		entries, err := ts.redisClient.ZRangeArgsWithScores(ctx, redis.ZRangeArgs{
+			Key:     key,
+			Start:   "-inf",
+			Stop:    maxScore,
+			ByScore: true,
+			Rev:     true,
+			Count:   historyScanChunk,
+		}).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get trades: %w", err)
+		}
+
+		for _, entry := range entries {
+			tradeID := entry.Member.(string)
+			score := int64(entry.Score)
+			// 同一毫秒可能有多笔交易，按member倒序跳过游标及之前的交易
+			if position != nil && score == position.score && tradeID >= position.drawID {
+				continue
+			}
+			position = &historyCursor{score: score, drawID: tradeID}
+
+			trade, err := ts.loadTrade(ctx, tradeID)
+			if err != nil {
+				continue // 交易数据缺失
+			}
+			if status != "" && trade.Status != status {
+				continue
+			}
+
+			response.Trades = append(response.Trades, trade)
+			if len(response.Trades) >= limit {
+				response.HasMore = true
+				response.NextCursor = position.encode()
+				return response, nil
+			}
+		}
+
+		if len(entries) < historyScanChunk {
+			return response, nil
+		}
+		maxScore = strconv.FormatInt(position.score, 10)
+	}
+
+	// 扫描轮数用尽，返回游标供下一页继续
+	if position != nil {
+		response.HasMore = true
+		response.NextCursor = position.encode()
+	}
+	return response, nil
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"fishing-game/model"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestTradeService 基于miniredis创建交易服务
+func newTestTradeService(t *testing.T) (*TradeService, context.Context) {
+	t.Helper()
+	ls, ctx := newTestLotteryService(t)
+	ts := NewTradeService(ls.poolService, ls.idempotencyService)
+	ts.config = TradeConfig{OfferTTL: DefaultTradeOfferTTL, MaxOpenOffers: DefaultTradeMaxOpenOffers, DailyLimit: DefaultTradeDailyLimit}
+	return ts, ctx
+}
+
+// seedTrader 设置用户的金币和背包中各鱼类数量
+func seedTrader(t *testing.T, ts *TradeService, ctx context.Context, userID string, coins int64, counts map[string]int) {
+	t.Helper()
+	if err := ts.redisClient.HSet(ctx, walletKey(userID), "coins", coins).Err(); err != nil {
+		t.Fatal(err)
+	}
+	for itemID, count := range counts {
+		if err := ts.redisClient.HSet(ctx, inventoryKey(userID), "count:"+itemID, count).Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// assertTrader 校验用户的金币和背包中各鱼类数量
+func assertTrader(t *testing.T, ts *TradeService, ctx context.Context, userID string, coins int64, counts map[string]int) {
+	t.Helper()
+	if got, _ := ts.redisClient.HGet(ctx, walletKey(userID), "coins").Int64(); got != coins {
+		t.Errorf("%s coins %d, expected %d", userID, got, coins)
+	}
+	for itemID, count := range counts {
+		if got, _ := ts.redisClient.HGet(ctx, inventoryKey(userID), "count:"+itemID).Int(); got != count {
+			t.Errorf("%s has %d %s, expected %d", userID, got, itemID, count)
+		}
+	}
+}
+
+// largeForSmallOffer a用2条大鱼和30金币向b换1条小鱼和100金币
+func largeForSmallOffer() *model.CreateTradeRequest {
+	return &model.CreateTradeRequest{
+		UserID:       "a",
+		ToUserID:     "b",
+		OfferItems:   []model.TradeItem{{ItemID: LargeFishID, Quantity: 2}},
+		OfferCoins:   30,
+		RequestItems: []model.TradeItem{{ItemID: SmallFishID, Quantity: 1}},
+		RequestCoins: 100,
+	}
+}
+
+func TestTradeAcceptSwapsEscrowOnce(t *testing.T) {
+	ts, ctx := newTestTradeService(t)
+	seedTrader(t, ts, ctx, "a", 50, map[string]int{LargeFishID: 2})
+	seedTrader(t, ts, ctx, "b", 100, map[string]int{SmallFishID: 1})
+
+	trade, err := ts.CreateOffer(ctx, largeForSmallOffer())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 发起方的鱼和金币进入托管
+	assertTrader(t, ts, ctx, "a", 20, map[string]int{LargeFishID: 0})
+
+	if _, err := ts.Accept(ctx, trade.ID, "a"); !errors.Is(err, ErrTradeForbidden) {
+		t.Fatalf("expected ErrTradeForbidden, got %v", err)
+	}
+	accepted, err := ts.Accept(ctx, trade.ID, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if accepted.Status != model.TradeStatusAccepted || accepted.ResolvedAt == nil {
+		t.Errorf("accepted trade %+v", accepted)
+	}
+	assertTrader(t, ts, ctx, "a", 120, map[string]int{LargeFishID: 0, SmallFishID: 1})
+	assertTrader(t, ts, ctx, "b", 30, map[string]int{LargeFishID: 2, SmallFishID: 0})
+
+	for _, resolve := range []func() error{
+		func() error { _, err := ts.Accept(ctx, trade.ID, "b"); return err },
+		func() error { _, err := ts.Reject(ctx, trade.ID, "b"); return err },
+	} {
+		if err := resolve(); !errors.Is(err, ErrTradeNotPending) {
+			t.Errorf("expected ErrTradeNotPending, got %v", err)
+		}
+	}
+	assertTrader(t, ts, ctx, "a", 120, map[string]int{LargeFishID: 0, SmallFishID: 1})
+	assertTrader(t, ts, ctx, "b", 30, map[string]int{LargeFishID: 2, SmallFishID: 0})
+	if n, _ := ts.redisClient.SCard(ctx, openOffersKey("a")).Result(); n != 0 {
+		t.Errorf("accepted trade still open for a")
+	}
+}
+
+func TestTradeRefundsEscrowOnCancel(t *testing.T) {
+	ts, ctx := newTestTradeService(t)
+	seedTrader(t, ts, ctx, "a", 50, map[string]int{LargeFishID: 2})
+	seedTrader(t, ts, ctx, "b", 10, map[string]int{SmallFishID: 1})
+
+	trade, err := ts.CreateOffer(ctx, largeForSmallOffer())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 对方金币不足时不做任何交换
+	if _, err := ts.Accept(ctx, trade.ID, "b"); !errors.Is(err, ErrInsufficientCoins) {
+		t.Fatalf("expected ErrInsufficientCoins, got %v", err)
+	}
+	assertTrader(t, ts, ctx, "a", 20, map[string]int{LargeFishID: 0, SmallFishID: 0})
+	assertTrader(t, ts, ctx, "b", 10, map[string]int{LargeFishID: 0, SmallFishID: 1})
+
+	cancelled, err := ts.Cancel(ctx, trade.ID, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancelled.Status != model.TradeStatusCancelled {
+		t.Errorf("trade status %s, expected cancelled", cancelled.Status)
+	}
+	assertTrader(t, ts, ctx, "a", 50, map[string]int{LargeFishID: 2})
+
+	// 已退还的交易不能再次退还
+	if _, err := ts.Cancel(ctx, trade.ID, "a"); !errors.Is(err, ErrTradeNotPending) {
+		t.Fatalf("expected ErrTradeNotPending, got %v", err)
+	}
+	assertTrader(t, ts, ctx, "a", 50, map[string]int{LargeFishID: 2})
+	if n, _ := ts.redisClient.LLen(ctx, ledgerKey("a")).Result(); n != 2 {
+		t.Errorf("a has %d ledger entries, expected escrow and refund", n)
+	}
+}
+
+func TestTradeAcceptRefundsWhenExpiredBeforeCommit(t *testing.T) {
+	ts, ctx := newTestTradeService(t)
+	seedTrader(t, ts, ctx, "a", 50, map[string]int{LargeFishID: 2})
+	seedTrader(t, ts, ctx, "b", 100, map[string]int{SmallFishID: 1})
+
+	trade, err := ts.CreateOffer(ctx, largeForSmallOffer())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tradeAcceptScript.Load(ctx, ts.redisClient).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 读取交易后、成交前报价到期
+	hook := &faultHook{match: scriptCall(tradeAcceptScript)}
+	hook.onMatch = func() {
+		if err := ts.redisClient.ZAdd(ctx, TradeExpiryKey, redis.Z{Score: 1, Member: trade.ID}).Err(); err != nil {
+			t.Error(err)
+		}
+	}
+	ts.redisClient.AddHook(hook)
+
+	if _, err := ts.Accept(ctx, trade.ID, "b"); !errors.Is(err, ErrTradeExpired) {
+		t.Fatalf("expected ErrTradeExpired, got %v", err)
+	}
+	expired, err := ts.GetTrade(ctx, trade.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expired.Status != model.TradeStatusExpired {
+		t.Errorf("trade status %s, expected expired", expired.Status)
+	}
+	assertTrader(t, ts, ctx, "a", 50, map[string]int{LargeFishID: 2, SmallFishID: 0})
+	assertTrader(t, ts, ctx, "b", 100, map[string]int{LargeFishID: 0, SmallFishID: 1})
+	if n, _ := ts.redisClient.ZCard(ctx, TradeExpiryKey).Result(); n != 0 {
+		t.Errorf("expired trade still indexed")
+	}
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"fishing-game/config"
+	"fishing-game/model"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// 钱包默认配置
+	DefaultSellRate         = 1.0 // 卖鱼时每点积分兑换的金币
+	DefaultReleaseBonusRate = 0.1 // 放生时按积分比例给予的榜单奖励
+
+	// 幂等作用域
+	IdempotencyScopeSell    = "wallet_sell"
+	IdempotencyScopeRelease = "wallet_release"
+)
+
+var (
+	// ErrInsufficientItems 背包中鱼的数量不足
+	ErrInsufficientItems = errors.New("insufficient items in inventory")
+	// ErrInsufficientCoins 金币余额不足
+	ErrInsufficientCoins = errors.New("insufficient coins")
+	// ErrItemNotFound 鱼类不在奖池中
+	ErrItemNotFound = errors.New("item not found in pool")
+)
+
+// WalletConfig 钱包配置
+type WalletConfig struct {
+	SellRate         float64 `json:"sell_rate"`
+	ReleaseBonusRate float64 `json:"release_bonus_rate"`
+}
+
+// LoadWalletConfig 从环境变量加载钱包配置
+func LoadWalletConfig() WalletConfig {
+	return WalletConfig{
+		SellRate:         config.GetEnvFloat("WALLET_SELL_RATE", DefaultSellRate),
+		ReleaseBonusRate: config.GetEnvFloat("RELEASE_BONUS_RATE", DefaultReleaseBonusRate),
+	}
+}
+
+// sellPrice 一条鱼的售价
+func (wc WalletConfig) sellPrice(item *model.LotteryItem) int64 {
+	return int64(math.Round(float64(item.Points) * wc.SellRate))
+}
+
+// releaseBonus 放生一条鱼的榜单奖励，有积分的鱼至少奖励1分
+func (wc WalletConfig) releaseBonus(item *model.LotteryItem) int {
+	if item.Points <= 0 || wc.ReleaseBonusRate <= 0 {
+		return 0
+	}
+	return int(math.Max(1, math.Round(float64(item.Points)*wc.ReleaseBonusRate)))
+}
+
+// fishTransactionScript 原子扣减背包中的鱼，并增加金币（卖鱼）或榜单积分（放生），同时写入账本
+//
+// KEYS[1] 用户背包 KEYS[2] 用户钱包 KEYS[3] 用户账本 KEYS[4] 全局榜单
+// ARGV[1] 鱼类ID ARGV[2] 数量 ARGV[3] 金币变动 ARGV[4] 榜单积分变动 ARGV[5] 榜单成员 ARGV[6] 账本记录JSON
+//
+// 数量不足时返回错误 insufficient items，成功返回 {交易后余额, 剩余数量}
+var fishTransactionScript = redis.NewScript(`
+local field = 'count:' .. ARGV[1]
+local quantity = tonumber(ARGV[2])
+local count = tonumber(redis.call('HGET', KEYS[1], field) or '0')
+if count < quantity then
+	return redis.error_reply('insufficient items')
+end
+local remaining = redis.call('HINCRBY', KEYS[1], field, -quantity)
+
+local balance = redis.call('HINCRBY', KEYS[2], 'coins', tonumber(ARGV[3]))
+local points = tonumber(ARGV[4])
+if points > 0 then
+	redis.call('ZINCRBY', KEYS[4], points, ARGV[5])
+end
+
+local entry = cjson.decode(ARGV[6])
+entry['balance'] = balance
+redis.call('RPUSH', KEYS[3], cjson.encode(entry))
+return {balance, remaining}
+`)
+
+// WalletService 用户钱包服务：金币与榜单积分分开，所有交易记入账本
+type WalletService struct {
+	redisClient        *redis.Client
+	poolService        *PoolService
+	idempotencyService *IdempotencyService
+	config             WalletConfig
+}
+
+// NewWalletService 创建钱包服务
+func NewWalletService(poolService *PoolService, idempotencyService *IdempotencyService) *WalletService {
+	return &WalletService{
+		redisClient:        config.GetRedisClient(),
+		poolService:        poolService,
+		idempotencyService: idempotencyService,
+		config:             LoadWalletConfig(),
+	}
+}
+
+// walletKey 用户钱包key
+func walletKey(userID string) string {
+	return fmt.Sprintf("wallet:%s", userID)
+}
+
+// ledgerKey 用户账本key（LIST，按时间追加）
+func ledgerKey(userID string) string {
+	return fmt.Sprintf("wallet:ledger:%s", userID)
+}
+
+// newLedgerEntry 创建账本记录，余额由脚本填写
+func newLedgerEntry(userID, entryType, traceID string, now time.Time) *model.LedgerEntry {
+	return &model.LedgerEntry{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Type:      entryType,
+		TraceID:   traceID,
+		CreatedAt: now,
+	}
+}
+
+// walletScriptError 将脚本返回的业务错误转换为对应的错误类型
+func walletScriptError(err error) error {
+	switch {
+	case strings.Contains(err.Error(), "insufficient items"):
+		return ErrInsufficientItems
+	}
+	return err
+}
+
+// GetWallet 获取用户钱包
+func (ws *WalletService) GetWallet(ctx context.Context, userID string) (*model.WalletResponse, error) {
+	coins, err := ws.redisClient.HGet(ctx, walletKey(userID), "coins").Int64()
+	if err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get wallet: %w", err)
+	}
+
+	return &model.WalletResponse{
+		UserID: userID,
+		Coins:  coins,
+	}, nil
+}
+
+// GetLedger 按时间倒序分页获取账本，cursor 为上一页返回的 next_cursor
+func (ws *WalletService) GetLedger(ctx context.Context, userID string, limit int, cursor string) (*model.LedgerResponse, error) {
+	key := ledgerKey(userID)
+
+	// 账本只追加，下标稳定，以下标作为游标
+	end, err := ws.redisClient.LLen(ctx, key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger: %w", err)
+	}
+	end--
+	if cursor != "" {
+		before, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil || before < 0 {
+			return nil, ErrInvalidCursor
+		}
+		if before-1 < end {
+			end = before - 1
+		}
+	}
+
+	response := &model.LedgerResponse{
+		UserID:  userID,
+		Entries: make([]*model.LedgerEntry, 0, limit),
+	}
+	if end < 0 {
+		return response, nil
+	}
+
+	start := end - int64(limit) + 1
+	if start < 0 {
+		start = 0
+	}
+	values, err := ws.redisClient.LRange(ctx, key, start, end).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger: %w", err)
+	}
+
+	for i := len(values) - 1; i >= 0; i-- {
+		var entry model.LedgerEntry
+		if err := json.Unmarshal([]byte(values[i]), &entry); err != nil {
+			continue // 跳过无法解析的记录
+		}
+		response.Entries = append(response.Entries, &entry)
+	}
+	if start > 0 {
+		response.HasMore = true
+		response.NextCursor = strconv.FormatInt(start, 10)
+	}
+
+	return response, nil
+}
+
+// Sell 卖出背包中的鱼换取金币（携带trace_id时幂等）
+func (ws *WalletService) Sell(ctx context.Context, req *model.FishTransactionRequest) (*model.FishTransactionResponse, error) {
+	return withIdempotency(ctx, ws.idempotencyService, IdempotencyScopeSell, req.UserID, req.TraceID, req, func() (*model.FishTransactionResponse, error) {
+		return ws.fishTransaction(ctx, req, model.LedgerTypeSell)
+	})
+}
+
+// Release 放生背包中的鱼换取少量榜单积分（携带trace_id时幂等）
+func (ws *WalletService) Release(ctx context.Context, req *model.FishTransactionRequest) (*model.FishTransactionResponse, error) {
+	return withIdempotency(ctx, ws.idempotencyService, IdempotencyScopeRelease, req.UserID, req.TraceID, req, func() (*model.FishTransactionResponse, error) {
+		return ws.fishTransaction(ctx, req, model.LedgerTypeRelease)
+	})
+}
+
+// fishTransaction 执行卖鱼或放生
+func (ws *WalletService) fishTransaction(ctx context.Context, req *model.FishTransactionRequest, entryType string) (*model.FishTransactionResponse, error) {
+	poolItems, err := ws.poolService.GetItems(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool: %w", err)
+	}
+	item, exists := poolItems[req.ItemID]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrItemNotFound, req.ItemID)
+	}
+
+	entry := newLedgerEntry(req.UserID, entryType, req.TraceID, time.Now())
+	entry.ItemID = req.ItemID
+	entry.Quantity = req.Quantity
+	if entryType == model.LedgerTypeSell {
+		entry.Coins = ws.config.sellPrice(item) * int64(req.Quantity)
+	} else {
+		entry.Points = ws.config.releaseBonus(item) * req.Quantity
+	}
+
+	entryJSON, err := json.Marshal(entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ledger entry: %w", err)
+	}
+
+	result, err := fishTransactionScript.Run(ctx, ws.redisClient,
+		[]string{inventoryKey(req.UserID), walletKey(req.UserID), ledgerKey(req.UserID), GlobalRankingKey},
+		req.ItemID, req.Quantity, entry.Coins, entry.Points, rankingMember(req.UserID), entryJSON,
+	).Int64Slice()
+	if err != nil {
+		return nil, walletScriptError(err)
+	}
+	if len(result) != 2 {
+		return nil, fmt.Errorf("unexpected transaction result")
+	}
+
+	entry.Balance = result[0]
+	return &model.FishTransactionResponse{
+		Entry:     entry,
+		Remaining: int(result[1]),
+	}, nil
+}
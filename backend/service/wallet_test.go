@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"fishing-game/model"
+)
+
+// newTestWalletService 基于miniredis创建钱包服务，背包中放入count条itemID
+func newTestWalletService(t *testing.T, itemID string, count int) (*WalletService, context.Context) {
+	t.Helper()
+	ls, ctx := newTestLotteryService(t)
+	ws := NewWalletService(ls.poolService, ls.idempotencyService)
+	ws.config = WalletConfig{SellRate: DefaultSellRate, ReleaseBonusRate: DefaultReleaseBonusRate}
+	if err := ws.redisClient.HSet(ctx, inventoryKey(testUserID), "count:"+itemID, count).Err(); err != nil {
+		t.Fatal(err)
+	}
+	return ws, ctx
+}
+
+func TestSellIsIdempotentAndRejectsMissingFish(t *testing.T) {
+	ws, ctx := newTestWalletService(t, LargeFishID, 3)
+
+	req := &model.FishTransactionRequest{UserID: testUserID, ItemID: LargeFishID, Quantity: 2, TraceID: "sell-1"}
+	for i := 0; i < 2; i++ {
+		resp, err := ws.Sell(ctx, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Remaining != 1 || resp.Entry.Coins != 200 || resp.Entry.Balance != 200 {
+			t.Errorf("sell %d: remaining %d, entry %+v", i, resp.Remaining, resp.Entry)
+		}
+	}
+
+	// 数量不足时不扣鱼、不加金币、不记账
+	_, err := ws.Sell(ctx, &model.FishTransactionRequest{UserID: testUserID, ItemID: LargeFishID, Quantity: 2})
+	if !errors.Is(err, ErrInsufficientItems) {
+		t.Fatalf("expected ErrInsufficientItems, got %v", err)
+	}
+	wallet, err := ws.GetWallet(ctx, testUserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wallet.Coins != 200 {
+		t.Errorf("coins %d, expected 200", wallet.Coins)
+	}
+	if count, _ := ws.redisClient.HGet(ctx, inventoryKey(testUserID), "count:"+LargeFishID).Int(); count != 1 {
+		t.Errorf("%d fish left, expected 1", count)
+	}
+	if n, _ := ws.redisClient.LLen(ctx, ledgerKey(testUserID)).Result(); n != 1 {
+		t.Errorf("%d ledger entries, expected 1", n)
+	}
+}
+
+func TestReleaseAddsRankingPointsOnly(t *testing.T) {
+	ws, ctx := newTestWalletService(t, LargeFishID, 1)
+
+	resp, err := ws.Release(ctx, &model.FishTransactionRequest{UserID: testUserID, ItemID: LargeFishID, Quantity: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Remaining != 0 || resp.Entry.Points != 10 || resp.Entry.Coins != 0 || resp.Entry.Balance != 0 {
+		t.Errorf("remaining %d, entry %+v", resp.Remaining, resp.Entry)
+	}
+	if score, _ := ws.redisClient.ZScore(ctx, GlobalRankingKey, rankingMember(testUserID)).Result(); score != 10 {
+		t.Errorf("ranking score %v, expected 10", score)
+	}
+
+	if _, err := ws.Release(ctx, &model.FishTransactionRequest{UserID: testUserID, ItemID: LargeFishID, Quantity: 1}); !errors.Is(err, ErrInsufficientItems) {
+		t.Fatalf("expected ErrInsufficientItems, got %v", err)
+	}
+	if score, _ := ws.redisClient.ZScore(ctx, GlobalRankingKey, rankingMember(testUserID)).Result(); score != 10 {
+		t.Errorf("ranking score %v after rejected release, expected 10", score)
+	}
+}